@@ -0,0 +1,117 @@
+package blake2s
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var updateAPI = flag.Bool("update-api", false, "rewrite testdata/api.txt with the current exported API")
+
+// exportedAPI lists every exported declaration of the package, one per line,
+// with function signatures, struct fields and interface methods spelled out.
+func exportedAPI(t *testing.T) string {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := func(n any) string {
+		var buf bytes.Buffer
+		printer.Fprint(&buf, fset, n)
+		return strings.Join(strings.Fields(buf.String()), " ")
+	}
+
+	var lines []string
+	for _, f := range pkgs["blake2s"].Files {
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if !decl.Name.IsExported() {
+					continue
+				}
+				recv := ""
+				if decl.Recv != nil {
+					recv = node(decl.Recv.List[0].Type)
+					if !ast.IsExported(strings.TrimPrefix(recv, "*")) {
+						continue
+					}
+					recv = "(" + recv + ") "
+				}
+				lines = append(lines, "func "+recv+decl.Name.Name+strings.TrimPrefix(node(decl.Type), "func"))
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						if !spec.Name.IsExported() {
+							continue
+						}
+						if st, ok := spec.Type.(*ast.StructType); ok {
+							lines = append(lines, "type "+spec.Name.Name+" struct")
+							for _, field := range st.Fields.List {
+								for _, name := range field.Names {
+									if name.IsExported() {
+										lines = append(lines, "type "+spec.Name.Name+" struct, "+name.Name+" "+node(field.Type))
+									}
+								}
+							}
+							continue
+						}
+						lines = append(lines, "type "+spec.Name.Name+" "+node(spec.Type))
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							if name.IsExported() {
+								lines = append(lines, decl.Tok.String()+" "+name.Name)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// TestAPICompat fails when an exported identifier is removed or its
+// signature changes. Additions fail too, so that the new surface is
+// reviewed; run with -update-api to accept them.
+func TestAPICompat(t *testing.T) {
+	got := exportedAPI(t)
+	const golden = "testdata/api.txt"
+	if *updateAPI {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == string(want) {
+		return
+	}
+	have := make(map[string]bool)
+	for _, line := range strings.Split(got, "\n") {
+		have[line] = true
+	}
+	for _, line := range strings.Split(string(want), "\n") {
+		if !have[line] {
+			t.Errorf("removed or changed: %s", line)
+		}
+		delete(have, line)
+	}
+	for line := range have {
+		t.Errorf("added: %s (run with -update-api to accept)", line)
+	}
+}
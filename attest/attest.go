@@ -0,0 +1,77 @@
+// Package attest produces the subject lists of in-toto and SLSA attestations
+// from a set of release artifacts, with BLAKE2s digests.
+//
+// A subject names an artifact and gives its digests keyed by algorithm:
+//
+//	{"digest":{"blake2s":"<hex>"},"name":"dist/app.tar.gz"}
+//
+// Lists are serialized as canonical JSON: subjects sorted by name, object
+// keys sorted, no insignificant whitespace and no HTML escaping, so the same
+// artifacts always serialize to the same bytes and the output can itself be
+// hashed or signed.
+package attest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"sort"
+
+	"github.com/gtank/blake2s"
+	"github.com/gtank/blake2s/manifest"
+)
+
+// DigestAlgorithm is the key of BLAKE2s digests in a subject's digest map.
+const DigestAlgorithm = "blake2s"
+
+// A Subject is one artifact of an attestation. Fields are declared in key
+// order, so encoding/json writes them canonically.
+type Subject struct {
+	Digest map[string]string `json:"digest"`
+	Name   string            `json:"name"`
+}
+
+// Subjects hashes the named files in fsys and returns their subjects, sorted
+// by name. Names are slash-separated paths and are used as given.
+func Subjects(fsys fs.FS, names []string) ([]Subject, error) {
+	subjects := make([]Subject, 0, len(names))
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			return nil, errors.New("attest: duplicate subject " + name)
+		}
+		seen[name] = true
+		sum, err := manifest.HashFile(fsys, name, blake2s.MaxOutput)
+		if err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, Subject{
+			Digest: map[string]string{DigestAlgorithm: hex.EncodeToString(sum)},
+			Name:   name,
+		})
+	}
+	sort.Slice(subjects, func(i, j int) bool { return subjects[i].Name < subjects[j].Name })
+	return subjects, nil
+}
+
+// Marshal serializes subjects as canonical JSON, sorting a copy of the list
+// by name.
+func Marshal(subjects []Subject) ([]byte, error) {
+	sorted := append(make([]Subject, 0, len(subjects)), subjects...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Name == sorted[i-1].Name {
+			return nil, errors.New("attest: duplicate subject " + sorted[i].Name)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(sorted); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,47 @@
+package attest
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSubjects(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dist/b<&>.tar.gz": {Data: []byte("first artifact")},
+		"dist/a":           {Data: nil},
+	}
+	subjects, err := Subjects(fsys, []string{"dist/b<&>.tar.gz", "dist/a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Marshal(subjects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `[{"digest":{"blake2s":"69217a3079908094e11121d042354a7c1f55b6482ca1a51e1b250dfd1ed0eef9"},"name":"dist/a"},` +
+		`{"digest":{"blake2s":"21a6e5c571b1a986a054215f199afd140e8a63604725d2b2ba9e6079e72324ab"},"name":"dist/b<&>.tar.gz"}]`
+	if string(out) != want {
+		t.Errorf("got %s", out)
+	}
+
+	// Marshal sorts its input too.
+	reversed := []Subject{subjects[1], subjects[0]}
+	if again, _ := Marshal(reversed); string(again) != want {
+		t.Errorf("order changed the output: %s", again)
+	}
+
+	if out, _ := Marshal(nil); string(out) != "[]" {
+		t.Errorf("empty list marshaled as %s", out)
+	}
+	if _, err := Marshal([]Subject{subjects[0], subjects[0]}); err == nil {
+		t.Error("accepted duplicate subjects")
+	}
+	if _, err := Subjects(fsys, []string{"dist/a", "dist/a"}); err == nil {
+		t.Error("accepted duplicate names")
+	}
+	if _, err := Subjects(fsys, []string{"missing"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file returned %v", err)
+	}
+}
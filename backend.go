@@ -0,0 +1,145 @@
+package blake2s
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/gtank/blake2s/core"
+)
+
+// A Backend is an implementation of the BLAKE2s compression function. Out of
+// tree accelerated implementations (cgo, hardware offload and so on) can be
+// plugged in by registering a Backend and selecting it with UseBackend.
+type Backend interface {
+	// Name identifies the backend. It must be unique among registered
+	// backends.
+	Name() string
+
+	// Available reports whether the backend can run on this machine.
+	Available() bool
+
+	// CompressBlocks compresses each 64-byte block of blocks into the
+	// chaining value h, in order. counter is the value of the t0/t1 counter
+	// pair (t1 in the high word) for the first block, and grows by BlockSize
+	// for each following block. flags holds f0 and f1 and applies to every
+	// block. len(blocks) is always a multiple of BlockSize.
+	CompressBlocks(h *[8]uint32, counter uint64, flags [2]uint32, blocks []byte)
+}
+
+// genericBackend is the portable Go compression function.
+type genericBackend struct{}
+
+func (genericBackend) Name() string    { return "generic" }
+func (genericBackend) Available() bool { return true }
+
+func (genericBackend) CompressBlocks(h *[8]uint32, counter uint64, flags [2]uint32, blocks []byte) {
+	for len(blocks) >= BlockSize {
+		core.BlockGeneric(h, (*[BlockSize]byte)(blocks), uint32(counter), uint32(counter>>32), flags[0], flags[1])
+		counter += BlockSize
+		blocks = blocks[BlockSize:]
+	}
+}
+
+// simdBackend is one of the assembly compression functions in core.
+type simdBackend struct {
+	impl core.Implementation
+}
+
+func (b *simdBackend) Name() string    { return b.impl.Name }
+func (b *simdBackend) Available() bool { return b.impl.Available }
+
+func (b *simdBackend) CompressBlocks(h *[8]uint32, counter uint64, flags [2]uint32, blocks []byte) {
+	for len(blocks) >= BlockSize {
+		b.impl.Block(h, (*[BlockSize]byte)(blocks), uint32(counter), uint32(counter>>32), flags[0], flags[1])
+		counter += BlockSize
+		blocks = blocks[BlockSize:]
+	}
+}
+
+// builtinBackends are "generic" and the SIMD backends built for this
+// architecture. defaultBackend is the fastest of them this CPU supports,
+// which is also what core.Block runs.
+var builtinBackends, defaultBackend = builtins()
+
+func builtins() ([]Backend, Backend) {
+	list := []Backend{genericBackend{}}
+	var best Backend = genericBackend{}
+	for _, impl := range core.Implementations() {
+		b := &simdBackend{impl}
+		list = append(list, b)
+		if best == (genericBackend{}) && impl.Available {
+			best = b
+		}
+	}
+	return list, best
+}
+
+var backends = struct {
+	sync.Mutex
+	list   []Backend
+	active Backend
+}{
+	list:   append([]Backend(nil), builtinBackends...),
+	active: defaultBackend,
+}
+
+// RegisterBackend adds a backend to the list UseBackend chooses from. It
+// panics if a backend with the same name is already registered, and is meant
+// to be called from an init function.
+func RegisterBackend(b Backend) {
+	backends.Lock()
+	defer backends.Unlock()
+	for _, existing := range backends.list {
+		if existing.Name() == b.Name() {
+			panic("blake2s: backend " + b.Name() + " registered twice")
+		}
+	}
+	backends.list = append(backends.list, b)
+}
+
+// Backends returns every registered backend, available or not, starting
+// with the built-in ones: "generic", the portable Go code, then the SIMD
+// implementations for this architecture ("ssse3" and "sse2" on amd64,
+// "neon" on arm64), none of which are built with the purego tag.
+func Backends() []Backend {
+	backends.Lock()
+	defer backends.Unlock()
+	return append([]Backend(nil), backends.list...)
+}
+
+// UseBackend selects the backend used by Digests constructed from now on.
+// Existing Digests keep the backend they were created with.
+func UseBackend(name string) error {
+	backends.Lock()
+	defer backends.Unlock()
+	for _, b := range backends.list {
+		if b.Name() != name {
+			continue
+		}
+		if !b.Available() {
+			return errors.New("blake2s: backend " + name + " is not available on this machine")
+		}
+		backends.active = b
+		return nil
+	}
+	return errors.New("blake2s: no backend named " + name)
+}
+
+// ActiveBackend returns the backend new Digests will use. Until UseBackend
+// is called, that is the fastest built-in backend this CPU supports.
+func ActiveBackend() Backend {
+	backends.Lock()
+	defer backends.Unlock()
+	return backends.active
+}
+
+// selectedBackend returns the active backend, or nil for the default one,
+// which core.Block runs directly, so the common case avoids an interface
+// call per block.
+func selectedBackend() Backend {
+	b := ActiveBackend()
+	if b == defaultBackend {
+		return nil
+	}
+	return b
+}
@@ -0,0 +1,119 @@
+package blake2s
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/gtank/blake2s/core"
+)
+
+// countingBackend wraps the generic backend and counts blocks.
+type countingBackend struct {
+	name   string
+	blocks int
+}
+
+func (c *countingBackend) Name() string    { return c.name }
+func (c *countingBackend) Available() bool { return c.name != "unavailable" }
+
+func (c *countingBackend) CompressBlocks(h *[8]uint32, counter uint64, flags [2]uint32, blocks []byte) {
+	c.blocks += len(blocks) / BlockSize
+	genericBackend{}.CompressBlocks(h, counter, flags, blocks)
+}
+
+// resetBackends leaves the global registry as a test found it.
+func resetBackends() {
+	backends.Lock()
+	backends.list = backends.list[:len(builtinBackends)]
+	backends.active = defaultBackend
+	backends.Unlock()
+}
+
+func TestBuiltinBackends(t *testing.T) {
+	defer resetBackends()
+	list := Backends()
+	if len(list) != 1+len(core.Implementations()) || list[0].Name() != "generic" {
+		t.Fatalf("unexpected built-in backends %v", list)
+	}
+	if ActiveBackend() != defaultBackend || !defaultBackend.Available() {
+		t.Errorf("default backend %s is not active or not available", defaultBackend.Name())
+	}
+
+	// RFC 7693 Appendix B.
+	want := "508c5e8c327c14e2e1a72ba34eeb452f37458b209ed63a294d999b4c86675982"
+	for _, b := range list {
+		if !b.Available() {
+			t.Logf("%s not supported by this CPU", b.Name())
+			continue
+		}
+		if err := UseBackend(b.Name()); err != nil {
+			t.Fatal(err)
+		}
+		d, _ := NewDigest(nil, nil, nil, 32)
+		if (d.backend == nil) != (b == defaultBackend) {
+			t.Errorf("%s: unexpected Digest backend %v", b.Name(), d.backend)
+		}
+		d.Write([]byte("abc"))
+		if got := hex.EncodeToString(d.Sum(nil)); got != want {
+			t.Errorf("%s: got %s", b.Name(), got)
+		}
+	}
+}
+
+func BenchmarkBackends(b *testing.B) {
+	blocks := make([]byte, 64*BlockSize)
+	for _, backend := range Backends() {
+		if !backend.Available() {
+			continue
+		}
+		b.Run(backend.Name(), func(b *testing.B) {
+			var h [8]uint32
+			b.SetBytes(int64(len(blocks)))
+			for range b.N {
+				backend.CompressBlocks(&h, 0, [2]uint32{}, blocks)
+			}
+		})
+	}
+}
+
+func TestBackendRegistration(t *testing.T) {
+	counting := &countingBackend{name: "counting"}
+	RegisterBackend(counting)
+	RegisterBackend(&countingBackend{name: "unavailable"})
+	defer resetBackends()
+
+	reference, _ := NewDigest(nil, nil, nil, 32)
+
+	if err := UseBackend("counting"); err != nil {
+		t.Fatal(err)
+	}
+	d, _ := NewDigest(nil, nil, nil, 32)
+	input := make([]byte, 200)
+	d.Write(input)
+	reference.Write(input)
+	if !bytes.Equal(d.Sum(nil), reference.Sum(nil)) {
+		t.Error("backend produced a different digest")
+	}
+	// Three full blocks during Write, one more in Sum.
+	if counting.blocks != 4 {
+		t.Errorf("expected 4 blocks through the backend, got %d", counting.blocks)
+	}
+
+	if err := UseBackend("unavailable"); err == nil {
+		t.Error("selected an unavailable backend")
+	}
+	if err := UseBackend("missing"); err == nil {
+		t.Error("selected a missing backend")
+	}
+	if len(Backends()) != len(builtinBackends)+2 || ActiveBackend().Name() != "counting" {
+		t.Error("unexpected registry state")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate registration did not panic")
+		}
+	}()
+	RegisterBackend(&countingBackend{name: "generic"})
+}
@@ -0,0 +1,87 @@
+package blake2s
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// BackendResult is the outcome of checking one backend with CheckBackends.
+type BackendResult struct {
+	Name      string
+	Available bool
+
+	// Err describes the first input on which the backend disagreed with
+	// the portable Go compression function, or is nil if it always agreed.
+	Err error
+
+	// MBPerSec is the backend's compression throughput, or zero if it
+	// wasn't measured.
+	MBPerSec float64
+}
+
+// CheckBackends runs every registered backend that is available on this
+// machine against the portable Go compression function on trials random
+// inputs drawn from seed, varying the chaining value, counter, flags and
+// number of blocks, and then measures its throughput compressing benchBytes
+// (rounded up to whole blocks). Backends that disagree are not measured.
+// Unavailable backends are listed but not run. The built-in SIMD backends
+// are checked like any other, so it is a safety net for them as well as for
+// registered accelerated implementations, which are easy to get subtly wrong.
+func CheckBackends(trials int, seed int64, benchBytes int) []BackendResult {
+	var results []BackendResult
+	for _, b := range Backends() {
+		r := BackendResult{Name: b.Name(), Available: b.Available()}
+		if r.Available {
+			r.Err = crossCheck(b, trials, seed)
+			if r.Err == nil && benchBytes > 0 {
+				r.MBPerSec = measure(b, benchBytes)
+			}
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+var flagChoices = [][2]uint32{{0, 0}, {0xFFFFFFFF, 0}, {0xFFFFFFFF, 0xFFFFFFFF}}
+
+func crossCheck(b Backend, trials int, seed int64) error {
+	rng := rand.New(rand.NewSource(seed))
+	blocks := make([]byte, 8*BlockSize)
+	for i := 0; i < trials; i++ {
+		var h [8]uint32
+		for j := range h {
+			h[j] = rng.Uint32()
+		}
+		counter := rng.Uint64()
+		if i%4 == 0 {
+			// Exercise the carry from t0 into t1.
+			counter = uint64(rng.Uint32())<<32 | (0xFFFFFFFF - uint64(rng.Intn(4*BlockSize)))
+		}
+		flags := flagChoices[rng.Intn(len(flagChoices))]
+		n := (1 + rng.Intn(8)) * BlockSize
+		rng.Read(blocks[:n])
+
+		want, got := h, h
+		genericBackend{}.CompressBlocks(&want, counter, flags, blocks[:n]) // pure Go, never SIMD
+		b.CompressBlocks(&got, counter, flags, blocks[:n])
+		if got != want {
+			return fmt.Errorf("blake2s: backend %s disagrees on trial %d (%d blocks, counter %#x, flags %#x): got %08x, want %08x",
+				b.Name(), i, n/BlockSize, counter, flags, got, want)
+		}
+	}
+	return nil
+}
+
+func measure(b Backend, n int) float64 {
+	const chunk = 64 * BlockSize
+	blocks := make([]byte, chunk)
+	var h [8]uint32
+	start := time.Now()
+	done := 0
+	for done < n {
+		b.CompressBlocks(&h, uint64(done), [2]uint32{}, blocks)
+		done += chunk
+	}
+	return float64(done) / time.Since(start).Seconds() / 1e6
+}
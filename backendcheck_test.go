@@ -0,0 +1,48 @@
+package blake2s
+
+import (
+	"strings"
+	"testing"
+)
+
+// brokenBackend gets the counter carry wrong.
+type brokenBackend struct{}
+
+func (brokenBackend) Name() string    { return "broken" }
+func (brokenBackend) Available() bool { return true }
+
+func (brokenBackend) CompressBlocks(h *[8]uint32, counter uint64, flags [2]uint32, blocks []byte) {
+	for len(blocks) >= BlockSize {
+		genericBackend{}.CompressBlocks(h, counter&0xFFFFFFFF, flags, blocks[:BlockSize])
+		counter += BlockSize
+		blocks = blocks[BlockSize:]
+	}
+}
+
+func TestCheckBackends(t *testing.T) {
+	RegisterBackend(brokenBackend{})
+	RegisterBackend(&countingBackend{name: "counting"})
+	RegisterBackend(&countingBackend{name: "unavailable"})
+	defer resetBackends()
+
+	results := CheckBackends(100, 1, 1<<16)
+	if len(results) != len(builtinBackends)+3 {
+		t.Fatalf("got %d results", len(results))
+	}
+	for _, r := range results {
+		switch r.Name {
+		default:
+			if r.Available && (r.Err != nil || r.MBPerSec <= 0) {
+				t.Errorf("%s: %+v", r.Name, r)
+			}
+		case "broken":
+			if r.Err == nil || !strings.Contains(r.Err.Error(), "broken") || r.MBPerSec != 0 {
+				t.Errorf("broken backend passed: %+v", r)
+			}
+		case "unavailable":
+			if r.Available || r.Err != nil || r.MBPerSec != 0 {
+				t.Errorf("unavailable backend was run: %+v", r)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Package batch hashes large batches of independent messages, such as Merkle
+// leaves or dedup records, optionally offloading them to an accelerator.
+//
+// EXPERIMENTAL: the Accelerator interface may change. No accelerator ships
+// with this package; GPU or other offload implementations live out of tree
+// and register themselves. Without one, batches are hashed on the CPU,
+// spread across GOMAXPROCS goroutines.
+//
+// Sum returns a whole batch at once. Stream and Files instead yield a Result
+// per item as it completes, for batches too large to hold in memory.
+package batch
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/gtank/blake2s"
+)
+
+// An Accelerator hashes many independent, unkeyed messages in one call.
+type Accelerator interface {
+	// Name identifies the accelerator.
+	Name() string
+
+	// Available reports whether the device can be used right now.
+	Available() bool
+
+	// MinBatch is the smallest number of messages worth offloading. Smaller
+	// batches are hashed on the CPU.
+	MinBatch() int
+
+	// SumBatch writes the size-byte digest of msgs[i] into out[i], which
+	// has length size. An error makes Sum fall back to the CPU.
+	SumBatch(msgs [][]byte, size int, out [][]byte) error
+}
+
+var accelerators struct {
+	sync.Mutex
+	list []Accelerator
+}
+
+// Register adds an accelerator. Sum tries registered accelerators in
+// registration order.
+func Register(a Accelerator) {
+	accelerators.Lock()
+	defer accelerators.Unlock()
+	accelerators.list = append(accelerators.list, a)
+}
+
+// Sum returns the unkeyed, size-byte BLAKE2s digest of each message. If a
+// registered accelerator is available and the batch is large enough, the
+// work is offloaded to it; otherwise, or if it fails, the CPU is used.
+func Sum(msgs [][]byte, size int) ([][]byte, error) {
+	if size <= 0 || size > blake2s.MaxOutput {
+		return nil, errors.New("batch: invalid digest size")
+	}
+
+	out := make([][]byte, len(msgs))
+	backing := make([]byte, len(msgs)*size)
+	for i := range out {
+		out[i] = backing[i*size : (i+1)*size : (i+1)*size]
+	}
+
+	accelerators.Lock()
+	list := append([]Accelerator(nil), accelerators.list...)
+	accelerators.Unlock()
+	for _, a := range list {
+		if len(msgs) >= a.MinBatch() && a.Available() && a.SumBatch(msgs, size, out) == nil {
+			return out, nil
+		}
+	}
+
+	return out, sumCPU(msgs, size, out)
+}
+
+// sumCPU hashes msgs across all available cores.
+func sumCPU(msgs [][]byte, size int, out [][]byte) error {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(msgs) {
+		workers = len(msgs)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// Each worker takes a contiguous share, so the initial state
+			// is only computed once per worker.
+			lo, hi := w*len(msgs)/workers, (w+1)*len(msgs)/workers
+			sums, err := blake2s.SumMany(msgs[lo:hi], size)
+			if err != nil {
+				errs[w] = err
+				return
+			}
+			for i, sum := range sums {
+				copy(out[lo+i], sum)
+			}
+		}(w)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
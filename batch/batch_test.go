@@ -0,0 +1,145 @@
+package batch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"runtime"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gtank/blake2s"
+)
+
+type fakeAccelerator struct {
+	calls int
+	fail  bool
+}
+
+func (f *fakeAccelerator) Name() string    { return "fake" }
+func (f *fakeAccelerator) Available() bool { return true }
+func (f *fakeAccelerator) MinBatch() int   { return 10 }
+
+func (f *fakeAccelerator) SumBatch(msgs [][]byte, size int, out [][]byte) error {
+	f.calls++
+	if f.fail {
+		return errors.New("device lost")
+	}
+	return sumCPU(msgs, size, out)
+}
+
+func messages(n int) [][]byte {
+	msgs := make([][]byte, n)
+	for i := range msgs {
+		msgs[i] = []byte(fmt.Sprintf("leaf %d", i))
+	}
+	return msgs
+}
+
+func checkSums(t *testing.T, msgs, sums [][]byte, size int) {
+	for i, msg := range msgs {
+		d, _ := blake2s.NewDigest(nil, nil, nil, size)
+		d.Write(msg)
+		if !bytes.Equal(d.Sum(nil), sums[i]) {
+			t.Fatalf("digest %d mismatch", i)
+		}
+	}
+}
+
+func TestSumCPU(t *testing.T) {
+	msgs := messages(100)
+	sums, err := Sum(msgs, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSums(t, msgs, sums, 16)
+
+	if _, err := Sum(msgs, 0); err == nil {
+		t.Error("accepted a zero digest size")
+	}
+}
+
+func TestAccelerator(t *testing.T) {
+	fake := &fakeAccelerator{}
+	Register(fake)
+	defer func() { accelerators.list = nil }()
+
+	Sum(messages(5), 32)
+	if fake.calls != 0 {
+		t.Error("small batch was offloaded")
+	}
+
+	msgs := messages(50)
+	sums, _ := Sum(msgs, 32)
+	if fake.calls != 1 {
+		t.Error("large batch was not offloaded")
+	}
+	checkSums(t, msgs, sums, 32)
+
+	fake.fail = true
+	sums, err := Sum(msgs, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSums(t, msgs, sums, 32)
+}
+
+func TestStream(t *testing.T) {
+	msgs := messages(1000)
+	var sums [][]byte
+	for r := range Stream(slices.Values(msgs), 16) {
+		if r.Err != nil || r.Index != len(sums) || r.Size != int64(len(msgs[r.Index])) || r.Backend == "" {
+			t.Fatalf("unexpected result %+v", r)
+		}
+		sums = append(sums, r.Digest)
+	}
+	if len(sums) != len(msgs) {
+		t.Fatalf("got %d results", len(sums))
+	}
+	checkSums(t, msgs, sums, 16)
+
+	// Stopping early stops reading the input.
+	read := 0
+	counted := func(yield func([]byte) bool) {
+		for _, msg := range msgs {
+			read++
+			if !yield(msg) {
+				return
+			}
+		}
+	}
+	for r := range Stream(counted, 16) {
+		if r.Index == 10 {
+			break
+		}
+	}
+	if read > 11+runtime.GOMAXPROCS(0)+1 {
+		t.Errorf("read %d messages ahead of the consumer", read)
+	}
+
+	for r := range Stream(slices.Values(msgs[:1]), 0) {
+		if r.Err == nil {
+			t.Error("accepted a zero digest size")
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	fsys := fstest.MapFS{"a": {Data: []byte("leaf 0")}, "b": {Data: []byte("leaf 1")}}
+	var sums [][]byte
+	for r := range Files(fsys, slices.Values([]string{"a", "b", "missing"}), 32) {
+		if r.Name == "missing" {
+			if !errors.Is(r.Err, fs.ErrNotExist) {
+				t.Errorf("missing file: %v", r.Err)
+			}
+			continue
+		}
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		sums = append(sums, r.Digest)
+	}
+	checkSums(t, messages(2), sums, 32)
+}
@@ -0,0 +1,131 @@
+package batch
+
+import (
+	"io"
+	"io/fs"
+	"iter"
+	"runtime"
+	"time"
+
+	"github.com/gtank/blake2s"
+)
+
+// A Result is the outcome of hashing one item of a batch. Operations that
+// hash many independent inputs report through it, so that callers can
+// consume large batches one result at a time.
+type Result struct {
+	// Index is the position of the item in the input sequence.
+	Index int
+
+	// Name identifies the item, such as a file path, where it has one.
+	Name string
+
+	Digest   []byte
+	Size     int64 // bytes hashed
+	Duration time.Duration
+	Backend  string // the compression backend or accelerator used
+	Err      error
+}
+
+// Stream hashes each message of msgs with an unkeyed, size-byte BLAKE2s and
+// yields the results in input order as they complete. Up to GOMAXPROCS
+// messages are hashed concurrently, and no more than that are read ahead of
+// the consumer, so the batch is never held in memory at once. msgs is read
+// on the consumer's goroutine, and stopping the iteration early stops reading
+// it before Stream's iterator returns.
+func Stream(msgs iter.Seq[[]byte], size int) iter.Seq[Result] {
+	return stream(msgs, func(msg []byte) Result {
+		var r Result
+		src := bytesReader(msg)
+		r.Digest, r.Err = sumReader(&src, size, &r)
+		return r
+	})
+}
+
+// Files is like Stream for the files named by names in fsys. Errors opening
+// or reading a file are reported in its Result.
+func Files(fsys fs.FS, names iter.Seq[string], size int) iter.Seq[Result] {
+	return stream(names, func(name string) Result {
+		r := Result{Name: name}
+		f, err := fsys.Open(name)
+		if err != nil {
+			r.Err = err
+			return r
+		}
+		defer f.Close()
+		r.Digest, r.Err = sumReader(f, size, &r)
+		return r
+	})
+}
+
+type bytesReader []byte
+
+func (b *bytesReader) Read(p []byte) (int, error) {
+	if len(*b) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, *b)
+	*b = (*b)[n:]
+	return n, nil
+}
+
+func sumReader(src io.Reader, size int, r *Result) ([]byte, error) {
+	start := time.Now()
+	defer func() { r.Duration = time.Since(start) }()
+	d, err := blake2s.NewDigest(nil, nil, nil, size)
+	if err != nil {
+		return nil, err
+	}
+	r.Backend = blake2s.ActiveBackend().Name()
+	r.Size, err = io.Copy(d, src)
+	if err != nil {
+		return nil, err
+	}
+	return d.Sum(nil), nil
+}
+
+// stream runs hash over items concurrently and yields the results in order.
+// Items are pulled on the caller's goroutine, at most GOMAXPROCS ahead of
+// the one being yielded, and stream waits for the hashes still in flight
+// before it returns, so neither items nor hash run after the iteration ends.
+func stream[T any](items iter.Seq[T], hash func(T) Result) iter.Seq[Result] {
+	return func(yield func(Result) bool) {
+		next, stop := iter.Pull(items)
+		defer stop()
+
+		workers := runtime.GOMAXPROCS(0)
+		var window []chan Result
+		defer func() {
+			for _, c := range window {
+				<-c
+			}
+		}()
+
+		more := true
+		for i := 0; ; {
+			for more && len(window) < workers {
+				item, ok := next()
+				if !ok {
+					more = false
+					break
+				}
+				c := make(chan Result, 1)
+				go func(i int, item T) {
+					r := hash(item)
+					r.Index = i
+					c <- r
+				}(i, item)
+				window = append(window, c)
+				i++
+			}
+			if len(window) == 0 {
+				return
+			}
+			c := window[0]
+			window = window[1:]
+			if !yield(<-c) {
+				return
+			}
+		}
+	}
+}
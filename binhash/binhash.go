@@ -0,0 +1,149 @@
+// Package binhash fingerprints Go executables for reproducible-build checks.
+//
+// A fingerprint is the BLAKE2s hash of the sections a program loads — its
+// code and initialized data — in file order, each prefixed by its name and
+// length. Metadata that legitimately differs between otherwise identical
+// builds is left out: build ID notes, the Go build ID string embedded in the
+// text, and the Go build info blob (which carries VCS revisions and commit
+// times). Two builds of the same source with the same toolchain and flags
+// therefore have the same fingerprint even if their build IDs differ, while
+// any change to what actually runs changes it.
+//
+// ELF, Mach-O and PE executables are supported.
+package binhash
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/gtank/blake2s"
+)
+
+var persona = []byte("binhash1")
+
+// ErrUnknownFormat is returned for files that are not ELF, Mach-O or PE
+// executables.
+var ErrUnknownFormat = errors.New("binhash: not an ELF, Mach-O or PE file")
+
+// Sections whose contents are build metadata rather than program.
+var ignored = map[string]bool{
+	".note.go.buildid":   true,
+	".note.gnu.build-id": true,
+	".go.buildinfo":      true,
+	"__go_buildinfo":     true,
+}
+
+type section struct {
+	name string
+	data func() ([]byte, error)
+}
+
+// Fingerprint returns the fingerprint of the executable in r.
+func Fingerprint(r io.ReaderAt) ([]byte, error) {
+	sections, err := loadedSections(r)
+	if err != nil {
+		return nil, err
+	}
+	d, err := blake2s.NewDigest(nil, nil, persona, blake2s.MaxOutput)
+	if err != nil {
+		return nil, err
+	}
+	var n [binary.MaxVarintLen64]byte
+	for _, s := range sections {
+		if ignored[s.name] {
+			continue
+		}
+		data, err := s.data()
+		if err != nil {
+			return nil, err
+		}
+		blankGoBuildID(data)
+		d.Write(n[:binary.PutUvarint(n[:], uint64(len(s.name)))])
+		d.Write([]byte(s.name))
+		d.Write(n[:binary.PutUvarint(n[:], uint64(len(data)))])
+		d.Write(data)
+	}
+	return d.Sum(nil), nil
+}
+
+// FingerprintFile returns the fingerprint of the executable at path.
+func FingerprintFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Fingerprint(f)
+}
+
+// Self returns the fingerprint of the running program.
+func Self() ([]byte, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return FingerprintFile(path)
+}
+
+func loadedSections(r io.ReaderAt) ([]section, error) {
+	if f, err := elf.NewFile(r); err == nil {
+		var sections []section
+		for _, s := range f.Sections {
+			if s.Flags&elf.SHF_ALLOC == 0 || s.Type == elf.SHT_NOBITS {
+				continue
+			}
+			sections = append(sections, section{s.Name, s.Data})
+		}
+		return sections, nil
+	}
+	if f, err := macho.NewFile(r); err == nil {
+		var sections []section
+		for _, s := range f.Sections {
+			switch s.Flags & 0xff {
+			case 0x1, 0xc, 0x12: // S_ZEROFILL, S_GB_ZEROFILL, S_THREAD_LOCAL_ZEROFILL
+				continue
+			}
+			sections = append(sections, section{s.Name, s.Data})
+		}
+		return sections, nil
+	}
+	if f, err := pe.NewFile(r); err == nil {
+		var sections []section
+		for _, s := range f.Sections {
+			if s.Characteristics&pe.IMAGE_SCN_CNT_UNINITIALIZED_DATA != 0 {
+				continue
+			}
+			sections = append(sections, section{s.Name, s.Data})
+		}
+		return sections, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+var buildIDPrefix = []byte("\xff Go build ID: \"")
+
+// blankGoBuildID zeroes any Go build ID strings in data. The linker places
+// one at the start of the text on platforms without a build ID note.
+func blankGoBuildID(data []byte) {
+	for {
+		i := bytes.Index(data, buildIDPrefix)
+		if i < 0 {
+			return
+		}
+		data = data[i+len(buildIDPrefix):]
+		end := bytes.IndexByte(data, '"')
+		if end < 0 {
+			return
+		}
+		for j := range data[:end] {
+			data[j] = 0
+		}
+		data = data[end:]
+	}
+}
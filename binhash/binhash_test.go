@@ -0,0 +1,84 @@
+package binhash
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSelf(t *testing.T) {
+	a, err := Self()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Self()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != 32 || !bytes.Equal(a, b) {
+		t.Errorf("fingerprints %x and %x", a, b)
+	}
+
+	if _, err := Fingerprint(strings.NewReader("#!/bin/sh\necho not a binary\n")); err != ErrUnknownFormat {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestBlankGoBuildID(t *testing.T) {
+	data := []byte("code\xff Go build ID: \"abc/def\"\n \xffmore\xff Go build ID: \"x\"")
+	blankGoBuildID(data)
+	want := "code\xff Go build ID: \"\x00\x00\x00\x00\x00\x00\x00\"\n \xffmore\xff Go build ID: \"\x00\""
+	if string(data) != want {
+		t.Errorf("got %q", data)
+	}
+}
+
+// TestReproducible builds the same program with two different build IDs and
+// then with a change to its code. The IDs have the same length, as real ones
+// do, so the sections after the notes don't move.
+func TestReproducible(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds programs")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go command")
+	}
+	dir := t.TempDir()
+	build := func(name, source, buildID string) []byte {
+		// Source file names end up in the binary, so only the directory
+		// differs between builds.
+		if err := os.Mkdir(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		src := filepath.Join(dir, name, "main.go")
+		if err := os.WriteFile(src, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		bin := filepath.Join(dir, name, "prog")
+		cmd := exec.Command(goTool, "build", "-trimpath", "-ldflags=-buildid="+buildID, "-o", bin, src)
+		cmd.Env = append(os.Environ(), "GOFLAGS=", "CGO_ENABLED=0")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("go build failed: %v\n%s", err, out)
+		}
+		sum, err := FingerprintFile(bin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sum
+	}
+
+	const hello = "package main\n\nfunc main() { println(\"hello\") }\n"
+	a := build("a", hello, "first-build-id")
+	b := build("b", hello, "other-build-id")
+	c := build("c", strings.Replace(hello, "hello", "howdy", 1), "first-build-id")
+	if !bytes.Equal(a, b) {
+		t.Error("build ID changed the fingerprint")
+	}
+	if bytes.Equal(a, c) {
+		t.Error("code change did not change the fingerprint")
+	}
+}
@@ -0,0 +1,209 @@
+// Package blake2b implements BLAKE2b, the 64-bit sibling of BLAKE2s, with the
+// same constructor and option API as the parent package so that tools built
+// on it can offer both. BLAKE2b is faster than BLAKE2s on 64-bit platforms
+// and produces digests of up to 64 bytes.
+package blake2b
+
+import (
+	"errors"
+	"math/bits"
+)
+
+const (
+	// The length of the key field.
+	KeyLength = 64
+	// The maximum number of bytes to produce.
+	MaxOutput = 64
+	// Max size of the salt, in bytes
+	SaltLength = 16
+	// Max size of the personalization string, in bytes
+	SeparatorLength = 16
+	// Number of G function rounds for BLAKE2b.
+	RoundCount = 12
+	// Size of a block buffer in bytes
+	BlockSize = 128
+)
+
+var iv = [8]uint64{
+	0x6a09e667f3bcc908, 0xbb67ae8584caa73b, 0x3c6ef372fe94f82b, 0xa54ff53a5f1d36f1,
+	0x510e527fade682d1, 0x9b05688c2b3e6c1f, 0x1f83d9abfb41bd6b, 0x5be0cd19137e2179,
+}
+
+var sigma = [10][16]byte{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3},
+	{11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4},
+	{7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8},
+	{9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13},
+	{2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9},
+	{12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11},
+	{13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10},
+	{6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5},
+	{10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0},
+}
+
+// Digest represents the internal state of the BLAKE2b algorithm.
+type Digest struct {
+	h      [8]uint64
+	t0, t1 uint64
+
+	buf    [BlockSize]byte
+	offset int // current offset inside the block
+
+	size int
+
+	// init is the state after the parameter block and key block, which
+	// Reset returns to.
+	init *Digest
+}
+
+// NewDigest constructs a new instance of a BLAKE2b hash with the provided
+// configuration.
+func NewDigest(key, salt, personalization []byte, outputBytes int) (*Digest, error) {
+	if outputBytes <= 0 {
+		return nil, errors.New("blake2b: asked for negative or zero output")
+	}
+	if outputBytes > MaxOutput {
+		return nil, errors.New("blake2b: asked for too much output")
+	}
+	if len(key) > KeyLength {
+		return nil, errors.New("blake2b: key too large")
+	}
+	if len(salt) > SaltLength {
+		return nil, errors.New("blake2b: salt too large")
+	}
+	if len(personalization) > SeparatorLength {
+		return nil, errors.New("blake2b: personalization string too large")
+	}
+
+	// Sequential-mode parameter block: fanout and depth 1, tree fields 0.
+	var params [64]byte
+	params[0] = byte(outputBytes)
+	params[1] = byte(len(key))
+	params[2] = 1
+	params[3] = 1
+	copy(params[32:], salt)
+	copy(params[48:], personalization)
+
+	d := &Digest{size: outputBytes}
+	for i := range d.h {
+		d.h[i] = iv[i] ^ u64LE(params[i*8:])
+	}
+	if len(key) > 0 {
+		var block [BlockSize]byte
+		copy(block[:], key)
+		d.Write(block[:])
+	}
+
+	init := *d
+	d.init = &init
+	return d, nil
+}
+
+func (d *Digest) compress(last bool) {
+	var m [16]uint64
+	for i := range m {
+		m[i] = u64LE(d.buf[i*8:])
+	}
+	v := [16]uint64{
+		d.h[0], d.h[1], d.h[2], d.h[3], d.h[4], d.h[5], d.h[6], d.h[7],
+		iv[0], iv[1], iv[2], iv[3], iv[4] ^ d.t0, iv[5] ^ d.t1, iv[6], iv[7],
+	}
+	if last {
+		v[14] = ^v[14]
+	}
+	for r := 0; r < RoundCount; r++ {
+		s := &sigma[r%10]
+		g(&v, 0, 4, 8, 12, m[s[0]], m[s[1]])
+		g(&v, 1, 5, 9, 13, m[s[2]], m[s[3]])
+		g(&v, 2, 6, 10, 14, m[s[4]], m[s[5]])
+		g(&v, 3, 7, 11, 15, m[s[6]], m[s[7]])
+		g(&v, 0, 5, 10, 15, m[s[8]], m[s[9]])
+		g(&v, 1, 6, 11, 12, m[s[10]], m[s[11]])
+		g(&v, 2, 7, 8, 13, m[s[12]], m[s[13]])
+		g(&v, 3, 4, 9, 14, m[s[14]], m[s[15]])
+	}
+	for i := range d.h {
+		d.h[i] ^= v[i] ^ v[i+8]
+	}
+}
+
+func g(v *[16]uint64, a, b, c, d int, x, y uint64) {
+	v[a] += v[b] + x
+	v[d] = bits.RotateLeft64(v[d]^v[a], -32)
+	v[c] += v[d]
+	v[b] = bits.RotateLeft64(v[b]^v[c], -24)
+	v[a] += v[b] + y
+	v[d] = bits.RotateLeft64(v[d]^v[a], -16)
+	v[c] += v[d]
+	v[b] = bits.RotateLeft64(v[b]^v[c], -63)
+}
+
+func (d *Digest) addCounter(n uint64) {
+	d.t0 += n
+	if d.t0 < n {
+		d.t1++
+	}
+}
+
+// Write adds more data to the running hash. It never returns an error.
+func (d *Digest) Write(input []byte) (n int, err error) {
+	n = len(input)
+	for len(input) > 0 {
+		// Keep the last block buffered until Sum, since it has to be
+		// compressed with the final-block flag.
+		if d.offset == BlockSize {
+			d.addCounter(BlockSize)
+			d.compress(false)
+			d.offset = 0
+		}
+		c := copy(d.buf[d.offset:], input)
+		d.offset += c
+		input = input[c:]
+	}
+	return n, nil
+}
+
+// Sum appends the current hash to b and returns the resulting slice.
+// It does not change the underlying hash state.
+func (d *Digest) Sum(b []byte) []byte {
+	final := *d
+	for i := final.offset; i < BlockSize; i++ {
+		final.buf[i] = 0
+	}
+	final.addCounter(uint64(final.offset))
+	final.compress(true)
+
+	var out [MaxOutput]byte
+	for i, w := range final.h {
+		putU64LE(out[i*8:], w)
+	}
+	return append(b, out[:d.size]...)
+}
+
+// Reset returns the Digest to its state just after construction, keeping
+// its key and parameters.
+func (d *Digest) Reset() {
+	init := d.init
+	*d = *init
+	d.init = init
+}
+
+// Size returns the digest output size in bytes.
+func (d *Digest) Size() int { return d.size }
+
+// BlockSize returns the hash's underlying block size.
+func (d *Digest) BlockSize() int { return BlockSize }
+
+func u64LE(b []byte) uint64 {
+	_ = b[7] // bounds check hint to the compiler, see golang.org/issue/14808
+	return uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16 | uint64(b[3])<<24 |
+		uint64(b[4])<<32 | uint64(b[5])<<40 | uint64(b[6])<<48 | uint64(b[7])<<56
+}
+
+func putU64LE(b []byte, n uint64) {
+	_ = b[7] // bounds check hint to the compiler, see golang.org/issue/14808
+	for i := 0; i < 8; i++ {
+		b[i] = byte(n >> (8 * i))
+	}
+}
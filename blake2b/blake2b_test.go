@@ -0,0 +1,112 @@
+package blake2b
+
+import (
+	"bytes"
+	"encoding/hex"
+	"hash"
+	"testing"
+)
+
+var _ hash.Hash = (*Digest)(nil)
+
+var fullKey = func() []byte {
+	k := make([]byte, KeyLength)
+	for i := range k {
+		k[i] = byte(i)
+	}
+	return k
+}()
+
+func input(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i % 251)
+	}
+	return b
+}
+
+// Generated with Python's hashlib.blake2b.
+var vectors = []struct {
+	length                int
+	key                   string
+	size                  int
+	salt, persona, digest string
+}{
+	{0, "", 64, "", "", "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+	{0, "key", 32, "salt", "persona", "6bad9092f23c5bdfafa6a8ca90b7bcfd7b1a25cb0c4e40fa331d525893325f91"},
+	{1, "", 64, "", "", "2fa3f686df876995167e7c2e5d74c4c7b6e48f8068fe0e44208344d480f7904c36963e44115fe3eb2a3ac8694c28bcb4f5a0f3276f2e79487d8219057a506e4b"},
+	{1, "key", 32, "salt", "persona", "0b7a9d869affd5c8a0d762e3d68d254709c3a83569b05de3bb5b46ff937c2402"},
+	{3, "", 64, "", "", "40a374727302d9a4769c17b5f409ff32f58aa24ff122d7603e4fda1509e919d4107a52c57570a6d94e50967aea573b11f86f473f537565c66f7039830a85d186"},
+	{3, "key", 32, "salt", "persona", "41245a6d168a419af62e843e870bb529dc5a0a7dabebf1162aeb32c29ef832bb"},
+	{127, "", 64, "", "", "b6292669ccd38d5f01caae96ba272c76a879a45743afa0725d83b9ebb26665b731f1848c52f11972b6644f554c064fa90780dbbbf3a89d4fc31f67df3e5857ef"},
+	{127, "key", 32, "salt", "persona", "7de416f0dc08c8f282a561b8fc9d9350d31813d42886e790b34e84c4b2d4cf1d"},
+	{128, "", 64, "", "", "2319e3789c47e2daa5fe807f61bec2a1a6537fa03f19ff32e87eecbfd64b7e0e8ccff439ac333b040f19b0c4ddd11a61e24ac1fe0f10a039806c5dcc0da3d115"},
+	{128, "key", 32, "salt", "persona", "9f56b2b74e6898ba07aedb379b9b9ce85d09b55c8bbac4648f80e02d048f440d"},
+	{129, "", 64, "", "", "f59711d44a031d5f97a9413c065d1e614c417ede998590325f49bad2fd444d3e4418be19aec4e11449ac1a57207898bc57d76a1bcf3566292c20c683a5c4648f"},
+	{129, "key", 32, "salt", "persona", "8c6fa3fff00f8493ecd0464fbe44cec6285735af2816f43cd82817899402a0bd"},
+	{255, "", 64, "", "", "fe2c02da499516b0e9fb2dd70c49eb3629039f632e20a880946fb7bc97a7ab09deb7d48774d7f0648141c9d9ede19ae6e0dbf07863a128cf4b00195f0f179f74"},
+	{255, "key", 32, "salt", "persona", "32fa8f8e201c1c4c6064bfe1f1cfe69c74922f0751b9bb7d7abccc5641c05310"},
+	{256, "", 64, "", "", "93463ac058b6163eb43be3f5bb32b28541498f4e3366f1effe253ad44e1e076e41c3616046027c82a7124f8f4746668ad10b12e8e25a95ac8f3151df01cd5a93"},
+	{256, "key", 32, "salt", "persona", "cf36c4bce4214d5523013daf3f4bf919a55d5f065d7072fa9a82c6ac6bb7475d"},
+	{257, "", 64, "", "", "9ca40e2ddee9436dbbd08efc65dbaf4870059f5eb3d76efd20241ae5bf13c60f250b882ea5c564838257a3fc95c496819ace2c6490b55b268535208dfc31822c"},
+	{257, "key", 32, "salt", "persona", "73b317bc62caa5e94d27a29ca344c961a5bff33af46defe434a3c0c8c420d1f2"},
+	{1000, "", 64, "", "", "c11e1c0340bd7e5a1b275f1230c962fad215ecb1391486e74e31b960a2f2996381a5fad092da06841d5f26e38f6ecfeaf441acbcd1c2de61aef121e7927175f5"},
+	{1000, "key", 32, "salt", "persona", "1708a6f16ffc3c2a5c64381405e38a64562f8cf748ab9a62f7f3cba4ae8f8879"},
+	{200, string(fullKey), 64, "", "", "3095a349d245708c7cf550118703d7302c27b60af5d4e67fc978f8a4e60953c7a04f92fcf41aee64321ccb707a895851552b1e37b00bc5e6b72fa5bcef9e3fff"},
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range vectors {
+		expected, _ := hex.DecodeString(v.digest)
+		data := input(v.length)
+		for _, split := range []int{1, 127, 128, 129, 1000} {
+			d, err := New(WithKey([]byte(v.key)), WithSize(v.size), WithSalt([]byte(v.salt)), WithPersonalization([]byte(v.persona)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for rest := data; len(rest) > 0; {
+				n := split
+				if n > len(rest) {
+					n = len(rest)
+				}
+				d.Write(rest[:n])
+				rest = rest[n:]
+			}
+			if got := d.Sum(nil); !bytes.Equal(got, expected) {
+				t.Errorf("%d bytes, key %q, writes of %d: got %x", v.length, v.key, split, got)
+			}
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	d, _ := NewDigest([]byte("key"), nil, nil, 32)
+	fresh := d.Sum(nil)
+	d.Write(input(300))
+	first := d.Sum(nil)
+	d.Reset()
+	if !bytes.Equal(d.Sum(nil), fresh) {
+		t.Error("Reset did not return to the keyed initial state")
+	}
+	d.Write(input(300))
+	if !bytes.Equal(d.Sum(nil), first) {
+		t.Error("digest after Reset differs")
+	}
+}
+
+func TestNewDigestErrors(t *testing.T) {
+	for _, tc := range []struct {
+		key, salt, persona []byte
+		size               int
+	}{
+		{nil, nil, nil, 0},
+		{nil, nil, nil, 65},
+		{make([]byte, 65), nil, nil, 64},
+		{nil, make([]byte, 17), nil, 64},
+		{nil, nil, make([]byte, 17), 64},
+	} {
+		if _, err := NewDigest(tc.key, tc.salt, tc.persona, tc.size); err == nil {
+			t.Errorf("accepted %+v", tc)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package blake2b
+
+// An Option configures a Digest constructed by New.
+type Option func(*config) error
+
+type config struct {
+	key, salt, personalization []byte
+	size                       int
+}
+
+// WithKey sets the key for a keyed (MAC) instance.
+func WithKey(key []byte) Option {
+	return func(c *config) error {
+		c.key = key
+		return nil
+	}
+}
+
+// WithSalt sets the salt field of the parameter block.
+func WithSalt(salt []byte) Option {
+	return func(c *config) error {
+		c.salt = salt
+		return nil
+	}
+}
+
+// WithPersonalization sets the personalization field of the parameter block.
+func WithPersonalization(personalization []byte) Option {
+	return func(c *config) error {
+		c.personalization = personalization
+		return nil
+	}
+}
+
+// WithSize sets the number of bytes of output, between 1 and MaxOutput.
+func WithSize(outputBytes int) Option {
+	return func(c *config) error {
+		c.size = outputBytes
+		return nil
+	}
+}
+
+// New constructs a new instance of a BLAKE2b hash configured by opts. With no
+// options, it is an unkeyed hash producing MaxOutput bytes.
+func New(opts ...Option) (*Digest, error) {
+	c := &config{size: MaxOutput}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	return NewDigest(c.key, c.salt, c.personalization, c.size)
+}
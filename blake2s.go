@@ -5,6 +5,9 @@ package blake2s
 
 import (
 	"errors"
+	"math"
+
+	"github.com/gtank/blake2s/core"
 )
 
 // The constant values will be different for other BLAKE2 variants. These are
@@ -24,54 +27,28 @@ const (
 	BlockSize = 64
 
 	// Initialization vector for BLAKE2s
-	IV0 uint32 = 0x6a09e667
-	IV1 uint32 = 0xbb67ae85
-	IV2 uint32 = 0x3c6ef372
-	IV3 uint32 = 0xa54ff53a
-	IV4 uint32 = 0x510e527f
-	IV5 uint32 = 0x9b05688c
-	IV6 uint32 = 0x1f83d9ab
-	IV7 uint32 = 0x5be0cd19
+	IV0 = core.IV0
+	IV1 = core.IV1
+	IV2 = core.IV2
+	IV3 = core.IV3
+	IV4 = core.IV4
+	IV5 = core.IV5
+	IV6 = core.IV6
+	IV7 = core.IV7
 )
 
-// These are the user-visible parameters of a BLAKE2 hash instance. The
-// parameter block is XOR'd with the IV at the beginning of the hash.
-// Currently we only support sequential mode, so many of these values will be
-// hardcoded to a default. They are nevertheless defined for clarity.
-type parameterBlock struct {
-	DigestSize      byte   // 0
-	KeyLength       byte   // 1
-	fanout          byte   // 2
-	depth           byte   // 3
-	leafLength      uint32 // 4-7
-	nodeOffset      uint32 // 8-11
-	xofLength       uint16 // 12-13
-	nodeDepth       byte   // 14
-	innerLength     byte   // 15
-	Salt            []byte // 16-23
-	Personalization []byte // 24-31
-}
+// ErrInputTooLong is returned by Write if the input would take the total
+// number of bytes hashed past 2^64-1, the most BLAKE2s's counter can
+// represent. The rejected input is not hashed.
+var ErrInputTooLong = errors.New("blake2s: input exceeds 2^64-1 bytes")
 
-// Packs a BLAKE2 parameter block.
-func (p *parameterBlock) Marshal() []byte {
-	buf := make([]byte, 32)
-	buf[0] = p.DigestSize
-	buf[1] = p.KeyLength
-	buf[2] = p.fanout
-	buf[3] = p.depth
-	putU32LE(buf[4:], p.leafLength)
-	putU32LE(buf[8:], p.nodeOffset)
-	putU16LE(buf[12:], p.xofLength)
-	buf[14] = p.nodeDepth
-	buf[15] = p.innerLength
-	copy(buf[16:], p.Salt)
-	copy(buf[24:], p.Personalization)
-	return buf
-}
+// maxCounter is the largest value of the t0/t1 counter pair.
+const maxCounter = math.MaxUint64
 
 // Digest represents the internal state of the BLAKE2s algorithm.
 type Digest struct {
 	h      [8]uint32
+	init   [8]uint32 // chaining value from the parameter block, for Reset
 	t0, t1 uint32
 	f0, f1 uint32
 
@@ -81,10 +58,34 @@ type Digest struct {
 	// size is definted in hash.Hash, and returns the number of bytes Sum will
 	// return. Since BLAKE2 output length is dynamic, so is this.
 	size int
+
+	// The key, salt and personalization this instance was constructed with,
+	// retained so that related instances can be derived from it.
+	key     [KeyLength]byte
+	keyLen  int
+	salt    [SaltLength]byte
+	persona [SeparatorLength]byte
+
+	// backend is the compression function selected when this instance was
+	// constructed, or nil for the built-in one.
+	backend Backend
+
+	// hooks are called around each compression, if set.
+	hooks *CompressHooks
+
+	// maxInput is the number of bytes Write will accept, or zero for no
+	// limit beyond the algorithm's own.
+	maxInput uint64
+
+	// guard, if set, is charged for every Write.
+	guard *Guard
+
+	// lastNode marks the last node of a level in tree hashing.
+	lastNode bool
 }
 
 // After this function is called, the ParameterBlock can be discarded.
-func initFromParams(p *parameterBlock) *Digest {
+func initFromParams(p *ParameterBlock) *Digest {
 	paramBytes := p.Marshal()
 
 	h0 := IV0 ^ u32LE(paramBytes[0:4])
@@ -101,237 +102,100 @@ func initFromParams(p *parameterBlock) *Digest {
 		buf:  [BlockSize]byte{},
 		size: int(p.DigestSize),
 	}
+	d.init = d.h
 
 	return d
 }
 
 func (d *Digest) compress() {
-
-	// Create the internal round state. Copy the current hash state to the top,
-	// then the tweaked IVs to the bottom. Use local variables to avoid
-	// allocating another slice.
-	v0, v1, v2, v3 := d.h[0], d.h[1], d.h[2], d.h[3]
-	v4, v5, v6, v7 := d.h[4], d.h[5], d.h[6], d.h[7]
-	v8, v9, v10, v11 := IV0, IV1, IV2, IV3
-	v12 := IV4 ^ d.t0
-	v13 := IV5 ^ d.t1
-	v14 := IV6 ^ d.f0
-	v15 := IV7 ^ d.f1
-
-	// This round structure is several steps removed from the spec and
-	// reference implementation. We unrolled the loops and calculated the
-	// offsets from the permutation table entry for each round, then directly
-	// mapped it to the correct word of the input block. This is a tradeoff:
-	// the doubly-indirect lookups were horrible for performance, but it's not
-	// at all obvious what this code is doing anymore.
-	//
-	// We also split the message buffer into 16x32-bit words (m0..m15) as late
-	// as possible before they're needed. The small decrease in liveness scope
-	// matters ever-so-slightly.
-
-	// Round 0 w/ precomputed permutation offsets
-	m0 := u32LE(d.buf[0*4 : 0*4+4])
-	m1 := u32LE(d.buf[1*4 : 1*4+4])
-	v0, v4, v8, v12 = g(v0+v4+m0, v4, v8, v12, m1)
-	m2 := u32LE(d.buf[2*4 : 2*4+4])
-	m3 := u32LE(d.buf[3*4 : 3*4+4])
-	v1, v5, v9, v13 = g(v1+v5+m2, v5, v9, v13, m3)
-	m4 := u32LE(d.buf[4*4 : 4*4+4])
-	m5 := u32LE(d.buf[5*4 : 5*4+4])
-	v2, v6, v10, v14 = g(v2+v6+m4, v6, v10, v14, m5)
-	m6 := u32LE(d.buf[6*4 : 6*4+4])
-	m7 := u32LE(d.buf[7*4 : 7*4+4])
-	v3, v7, v11, v15 = g(v3+v7+m6, v7, v11, v15, m7)
-
-	m8 := u32LE(d.buf[8*4 : 8*4+4])
-	m9 := u32LE(d.buf[9*4 : 9*4+4])
-	v0, v5, v10, v15 = g(v0+v5+m8, v5, v10, v15, m9)
-	m10 := u32LE(d.buf[10*4 : 10*4+4])
-	m11 := u32LE(d.buf[11*4 : 11*4+4])
-	v1, v6, v11, v12 = g(v1+v6+m10, v6, v11, v12, m11)
-	m12 := u32LE(d.buf[12*4 : 12*4+4])
-	m13 := u32LE(d.buf[13*4 : 13*4+4])
-	v2, v7, v8, v13 = g(v2+v7+m12, v7, v8, v13, m13)
-	m14 := u32LE(d.buf[14*4 : 14*4+4])
-	m15 := u32LE(d.buf[15*4 : 15*4+4])
-	v3, v4, v9, v14 = g(v3+v4+m14, v4, v9, v14, m15)
-
-	// Round 1
-	v0, v4, v8, v12 = g(v0+v4+m14, v4, v8, v12, m10)
-	v1, v5, v9, v13 = g(v1+v5+m4, v5, v9, v13, m8)
-	v2, v6, v10, v14 = g(v2+v6+m9, v6, v10, v14, m15)
-	v3, v7, v11, v15 = g(v3+v7+m13, v7, v11, v15, m6)
-
-	v0, v5, v10, v15 = g(v0+v5+m1, v5, v10, v15, m12)
-	v1, v6, v11, v12 = g(v1+v6+m0, v6, v11, v12, m2)
-	v2, v7, v8, v13 = g(v2+v7+m11, v7, v8, v13, m7)
-	v3, v4, v9, v14 = g(v3+v4+m5, v4, v9, v14, m3)
-
-	// Round 2
-	v0, v4, v8, v12 = g(v0+v4+m11, v4, v8, v12, m8)
-	v1, v5, v9, v13 = g(v1+v5+m12, v5, v9, v13, m0)
-	v2, v6, v10, v14 = g(v2+v6+m5, v6, v10, v14, m2)
-	v3, v7, v11, v15 = g(v3+v7+m15, v7, v11, v15, m13)
-
-	v0, v5, v10, v15 = g(v0+v5+m10, v5, v10, v15, m14)
-	v1, v6, v11, v12 = g(v1+v6+m3, v6, v11, v12, m6)
-	v2, v7, v8, v13 = g(v2+v7+m7, v7, v8, v13, m1)
-	v3, v4, v9, v14 = g(v3+v4+m9, v4, v9, v14, m4)
-
-	// Round 3
-	v0, v4, v8, v12 = g(v0+v4+m7, v4, v8, v12, m9)
-	v1, v5, v9, v13 = g(v1+v5+m3, v5, v9, v13, m1)
-	v2, v6, v10, v14 = g(v2+v6+m13, v6, v10, v14, m12)
-	v3, v7, v11, v15 = g(v3+v7+m11, v7, v11, v15, m14)
-
-	v0, v5, v10, v15 = g(v0+v5+m2, v5, v10, v15, m6)
-	v1, v6, v11, v12 = g(v1+v6+m5, v6, v11, v12, m10)
-	v2, v7, v8, v13 = g(v2+v7+m4, v7, v8, v13, m0)
-	v3, v4, v9, v14 = g(v3+v4+m15, v4, v9, v14, m8)
-
-	// Round 4
-	v0, v4, v8, v12 = g(v0+v4+m9, v4, v8, v12, m0)
-	v1, v5, v9, v13 = g(v1+v5+m5, v5, v9, v13, m7)
-	v2, v6, v10, v14 = g(v2+v6+m2, v6, v10, v14, m4)
-	v3, v7, v11, v15 = g(v3+v7+m10, v7, v11, v15, m15)
-
-	v0, v5, v10, v15 = g(v0+v5+m14, v5, v10, v15, m1)
-	v1, v6, v11, v12 = g(v1+v6+m11, v6, v11, v12, m12)
-	v2, v7, v8, v13 = g(v2+v7+m6, v7, v8, v13, m8)
-	v3, v4, v9, v14 = g(v3+v4+m3, v4, v9, v14, m13)
-
-	// Round 5
-	v0, v4, v8, v12 = g(v0+v4+m2, v4, v8, v12, m12)
-	v1, v5, v9, v13 = g(v1+v5+m6, v5, v9, v13, m10)
-	v2, v6, v10, v14 = g(v2+v6+m0, v6, v10, v14, m11)
-	v3, v7, v11, v15 = g(v3+v7+m8, v7, v11, v15, m3)
-
-	v0, v5, v10, v15 = g(v0+v5+m4, v5, v10, v15, m13)
-	v1, v6, v11, v12 = g(v1+v6+m7, v6, v11, v12, m5)
-	v2, v7, v8, v13 = g(v2+v7+m15, v7, v8, v13, m14)
-	v3, v4, v9, v14 = g(v3+v4+m1, v4, v9, v14, m9)
-
-	// Round 6
-	v0, v4, v8, v12 = g(v0+v4+m12, v4, v8, v12, m5)
-	v1, v5, v9, v13 = g(v1+v5+m1, v5, v9, v13, m15)
-	v2, v6, v10, v14 = g(v2+v6+m14, v6, v10, v14, m13)
-	v3, v7, v11, v15 = g(v3+v7+m4, v7, v11, v15, m10)
-
-	v0, v5, v10, v15 = g(v0+v5+m0, v5, v10, v15, m7)
-	v1, v6, v11, v12 = g(v1+v6+m6, v6, v11, v12, m3)
-	v2, v7, v8, v13 = g(v2+v7+m9, v7, v8, v13, m2)
-	v3, v4, v9, v14 = g(v3+v4+m8, v4, v9, v14, m11)
-
-	// Round 7
-	v0, v4, v8, v12 = g(v0+v4+m13, v4, v8, v12, m11)
-	v1, v5, v9, v13 = g(v1+v5+m7, v5, v9, v13, m14)
-	v2, v6, v10, v14 = g(v2+v6+m12, v6, v10, v14, m1)
-	v3, v7, v11, v15 = g(v3+v7+m3, v7, v11, v15, m9)
-
-	v0, v5, v10, v15 = g(v0+v5+m5, v5, v10, v15, m0)
-	v1, v6, v11, v12 = g(v1+v6+m15, v6, v11, v12, m4)
-	v2, v7, v8, v13 = g(v2+v7+m8, v7, v8, v13, m6)
-	v3, v4, v9, v14 = g(v3+v4+m2, v4, v9, v14, m10)
-
-	// Round 8
-	v0, v4, v8, v12 = g(v0+v4+m6, v4, v8, v12, m15)
-	v1, v5, v9, v13 = g(v1+v5+m14, v5, v9, v13, m9)
-	v2, v6, v10, v14 = g(v2+v6+m11, v6, v10, v14, m3)
-	v3, v7, v11, v15 = g(v3+v7+m0, v7, v11, v15, m8)
-
-	v0, v5, v10, v15 = g(v0+v5+m12, v5, v10, v15, m2)
-	v1, v6, v11, v12 = g(v1+v6+m13, v6, v11, v12, m7)
-	v2, v7, v8, v13 = g(v2+v7+m1, v7, v8, v13, m4)
-	v3, v4, v9, v14 = g(v3+v4+m10, v4, v9, v14, m5)
-
-	// Round 9
-	v0, v4, v8, v12 = g(v0+v4+m10, v4, v8, v12, m2)
-	v1, v5, v9, v13 = g(v1+v5+m8, v5, v9, v13, m4)
-	v2, v6, v10, v14 = g(v2+v6+m7, v6, v10, v14, m6)
-	v3, v7, v11, v15 = g(v3+v7+m1, v7, v11, v15, m5)
-
-	v0, v5, v10, v15 = g(v0+v5+m15, v5, v10, v15, m11)
-	v1, v6, v11, v12 = g(v1+v6+m9, v6, v11, v12, m14)
-	v2, v7, v8, v13 = g(v2+v7+m3, v7, v8, v13, m12)
-	v3, v4, v9, v14 = g(v3+v4+m13, v4, v9, v14, m0)
-
-	d.h[0] = d.h[0] ^ v0 ^ v8
-	d.h[1] = d.h[1] ^ v1 ^ v9
-	d.h[2] = d.h[2] ^ v2 ^ v10
-	d.h[3] = d.h[3] ^ v3 ^ v11
-	d.h[4] = d.h[4] ^ v4 ^ v12
-	d.h[5] = d.h[5] ^ v5 ^ v13
-	d.h[6] = d.h[6] ^ v6 ^ v14
-	d.h[7] = d.h[7] ^ v7 ^ v15
-}
-
-// The internal BLAKE2s round function.
-func g(a, b, c, d, m1 uint32) (uint32, uint32, uint32, uint32) {
-	// We lift the table lookups and the initial triple addition into the
-	// caller so this function has a better chance of inlining. Similarly, the
-	// math/bits calls are themselves inlinable but seem to count against us in
-	// the AST budget anyway. TODO: file a bug for that
-
-	// a = a + b + m0
-	d = ((d ^ a) >> 16) | ((d ^ a) << (32 - 16))
-	c = c + d
-	b = ((b ^ c) >> 12) | ((b ^ c) << (32 - 12))
-	a = a + b + m1
-	d = ((d ^ a) >> 8) | ((d ^ a) << (32 - 8))
-	c = c + d
-	b = ((b ^ c) >> 7) | ((b ^ c) << (32 - 7))
-
-	// TODO does assigning into the parameters result in spills if the function isn't inlined?
-	return a, b, c, d
+	if d.hooks != nil {
+		d.compressWithHooks()
+		return
+	}
+	if d.backend != nil {
+		d.backend.CompressBlocks(&d.h, uint64(d.t1)<<32|uint64(d.t0), [2]uint32{d.f0, d.f1}, d.buf[:])
+		return
+	}
+	core.Block(&d.h, &d.buf, d.t0, d.t1, d.f0, d.f1)
 }
 
 // Note that due to the nature of the hash.Hash interface, calling finalize
 // WILL NOT permanently update the underlying hash state. Instead it will
 // simulate what would happen if the current block were the final block.
-func (d *Digest) finalize(out []byte) error {
-	if d.f0 != 0 {
-		return errors.New("blake2s: tried to finalize but last flag already set")
+// The last block flag is only ever set on that copy, never on d, so
+// finalizing can't fail.
+func (d *Digest) finalize(out []byte) {
+	// increment counter by size of pending input before padding
+	t0, t1 := d.t0+uint32(d.offset), d.t1
+	if t0 < uint32(d.offset) {
+		t1++
+	}
+	// set last block flag, and last node flag for the last node of a tree
+	// level
+	var f1 uint32
+	if d.lastNode {
+		f1 = 0xFFFFFFFF
 	}
 
-	// make copies of everything
-	dCopy := *d
+	var h [8]uint32
+	if d.backend == nil && d.hooks == nil {
+		// Only the chaining value and the zero-padded block are copied,
+		// and both stay on the stack, so Sum into a buffer with room
+		// doesn't allocate.
+		h = d.h
+		var buf [BlockSize]byte
+		copy(buf[:], d.buf[:d.offset])
+		core.Block(&h, &buf, t0, t1, 0xFFFFFFFF, f1)
+	} else {
+		h = d.finalState(t0, t1, f1)
+	}
+
+	// extract output. The full chaining value is always 32 bytes, so
+	// truncated digests go through a local buffer.
+	var full [MaxOutput]byte
+	putU32LE(full[0*4:], h[0])
+	putU32LE(full[1*4:], h[1])
+	putU32LE(full[2*4:], h[2])
+	putU32LE(full[3*4:], h[3])
+	putU32LE(full[4*4:], h[4])
+	putU32LE(full[5*4:], h[5])
+	putU32LE(full[6*4:], h[6])
+	putU32LE(full[7*4:], h[7])
+	copy(out, full[:d.size])
+}
+
+// finalState compresses a copy of the pending block as the final one,
+// through the Digest's backend and hooks, and returns the chaining value.
+func (d *Digest) finalState(t0, t1, f1 uint32) [8]uint32 {
+	final := *d
 
 	// Zero the unused portion of the buffer. This triggers a specific
 	// optimization for memset, see https://codereview.appspot.com/137880043
-	memclrBuf := dCopy.buf[dCopy.offset:BlockSize]
+	memclrBuf := final.buf[final.offset:BlockSize]
 	for i := range memclrBuf {
 		memclrBuf[i] = 0
 	}
 
-	// increment counter by size of pending input before padding
-	dCopy.t0 += uint32(d.offset)
-	if dCopy.t0 < uint32(d.offset) {
-		dCopy.t1++
-	}
-	// set last block flag
-	dCopy.f0 = 0xFFFFFFFF
-
-	dCopy.compress()
-
-	// extract output
-	putU32LE(out[0*4:], dCopy.h[0])
-	putU32LE(out[1*4:], dCopy.h[1])
-	putU32LE(out[2*4:], dCopy.h[2])
-	putU32LE(out[3*4:], dCopy.h[3])
-	putU32LE(out[4*4:], dCopy.h[4])
-	putU32LE(out[5*4:], dCopy.h[5])
-	putU32LE(out[6*4:], dCopy.h[6])
-	putU32LE(out[7*4:], dCopy.h[7])
-
-	return nil
+	final.t0, final.t1 = t0, t1
+	final.f0, final.f1 = 0xFFFFFFFF, f1
+	final.compress()
+	return final.h
 }
 
 // NewDigest constructs a new instance of a BLAKE2s hash with the provided
-// configuration.
+// configuration. A nil or empty key both mean unkeyed hashing, as in RFC
+// 7693: no key block is processed. (Earlier versions hashed a block of zeros
+// for a non-nil empty key, which matches no other implementation.)
 func NewDigest(key, salt, personalization []byte, outputBytes int) (*Digest, error) {
-	params := &parameterBlock{
-		fanout: 1, // sequential mode
-		depth:  1, // sequential mode
+	return newDigest(key, salt, personalization, outputBytes, nil)
+}
+
+// newDigest is NewDigest with optional tree parameters. A nil tree means
+// sequential mode.
+func newDigest(key, salt, personalization []byte, outputBytes int, tree *TreeParams) (*Digest, error) {
+	params := &ParameterBlock{
+		Fanout: 1, // sequential mode
+		Depth:  1, // sequential mode
 	}
 
 	if outputBytes <= 0 {
@@ -342,87 +206,144 @@ func NewDigest(key, salt, personalization []byte, outputBytes int) (*Digest, err
 	}
 	params.DigestSize = byte(outputBytes & 0xFF)
 
-	if key != nil {
-		if len(key) > KeyLength {
-			return nil, errors.New("blake2s: key too large")
-		}
-		params.KeyLength = byte(len(key) & 0xFF)
+	if len(key) > KeyLength {
+		return nil, errors.New("blake2s: key too large")
 	}
+	params.KeyLength = byte(len(key))
 
-	params.Salt = make([]byte, SaltLength)
-	if salt != nil {
-		if len(salt) > SaltLength {
-			return nil, errors.New("blake2s: salt too large")
-		}
-		// If salt is too short, this will implicitly right-pad with zero.
-		copy(params.Salt, salt)
+	if len(salt) > SaltLength {
+		return nil, errors.New("blake2s: salt too large")
 	}
+	// If salt is too short, this will implicitly right-pad with zero.
+	copy(params.Salt[:], salt)
 
-	params.Personalization = make([]byte, SeparatorLength)
-	if personalization != nil {
-		if len(personalization) > SeparatorLength {
-			return nil, errors.New("blake2s: personalization string too large")
-		}
-		// If personalization string is short, this will implicitly right-pad with zero.
-		copy(params.Personalization, personalization)
+	if len(personalization) > SeparatorLength {
+		return nil, errors.New("blake2s: personalization string too large")
 	}
-
-	// Initialize the internal state
-	digest := initFromParams(params)
-
-	if key != nil {
-		// Write key to entire first block and compress
-		if len(key) < BlockSize {
-			keyBuf := make([]byte, BlockSize)
-			copy(keyBuf, key)
-			digest.Write(keyBuf)
+	// If personalization string is short, this will implicitly right-pad with zero.
+	copy(params.Personalization[:], personalization)
+
+	// Only WithTree can set the tree fields; NewDigest and New without it
+	// are always in sequential mode.
+	if tree != nil {
+		if err := tree.apply(params); err != nil {
+			return nil, err
+		}
+		if err := ValidateParameterBlock(params); err != nil {
+			return nil, err
 		}
 	}
 
+	// Initialize the internal state
+	digest := fromParams(params, key)
+	digest.lastNode = tree != nil && tree.LastNode
 	return digest, nil
 }
 
-// Write adds more data to the running hash.
+// Write adds more data to the running hash. It returns len(input) and a nil
+// error unless the input would exceed a limit (see ErrMaxInput,
+// ErrInputTooLong and WithGuard), in which case none of it is hashed. Writing nil or an
+// empty slice has no effect, and Write may be called again after Sum.
 func (d *Digest) Write(input []byte) (n int, err error) {
-	bytesWritten := 0
-
-	// If we have capacity, just copy and wait for a full block. If we don't
-	// have capacity, we'll need to take a full block and compress.
-	for bytesWritten < len(input) {
-		// How much space do we have left in the block?
-		freeBytes := BlockSize - d.offset
-		inputLeft := len(input) - bytesWritten
-
-		if inputLeft <= freeBytes {
-			newOffset := d.offset + inputLeft
-			copy(d.buf[d.offset:newOffset], input[bytesWritten:])
-			d.offset = newOffset
-			return bytesWritten + inputLeft, nil
+	if d.maxInput != 0 && uint64(len(input)) > d.maxInput-d.BytesWritten() {
+		return 0, ErrMaxInput
+	}
+	if uint64(len(input)) > maxCounter-d.counter() {
+		return 0, ErrInputTooLong
+	}
+	if d.guard != nil {
+		if err := d.guard.Charge(len(input)); err != nil {
+			return 0, err
 		}
+	}
 
-		copy(d.buf[d.offset:], input[bytesWritten:bytesWritten+freeBytes])
+	n = len(input)
 
-		// increment counter, preserving overflow behavior
-		d.t0 += BlockSize
-		if d.t0 < BlockSize {
-			d.t1++
-		}
+	// If we have capacity, just copy and wait for a full block.
+	if len(input) <= BlockSize-d.offset {
+		d.offset += copy(d.buf[d.offset:], input)
+		return n, nil
+	}
 
-		d.compress()
+	// Otherwise the buffered block isn't the last one: fill and compress it.
+	c := copy(d.buf[d.offset:], input)
+	input = input[c:]
+	d.addCounter(BlockSize)
+	d.compress()
+
+	// Compress whole blocks straight from the input, always keeping the
+	// last block, even if it's full, buffered for finalize.
+	if len(input) > BlockSize {
+		blocks := (len(input) - 1) / BlockSize * BlockSize
+		d.compressBlocks(input[:blocks])
+		input = input[blocks:]
+	}
+	d.offset = copy(d.buf[:], input)
+	return n, nil
+}
 
-		// advance pointers
-		bytesWritten += freeBytes
-		d.offset = 0
+// addCounter increments the t0/t1 counter pair, preserving overflow behavior.
+func (d *Digest) addCounter(n uint32) {
+	d.t0 += n
+	if d.t0 < n {
+		d.t1++
+	}
+}
 
-		// loop until we can't fill another buffer
+// compressBlocks compresses p, a whole number of blocks none of which is the
+// last, without copying it into the block buffer.
+func (d *Digest) compressBlocks(p []byte) {
+	if d.hooks != nil {
+		// Hooks run around each compression of the buffered block.
+		for ; len(p) > 0; p = p[BlockSize:] {
+			copy(d.buf[:], p)
+			d.addCounter(BlockSize)
+			d.compress()
+		}
+		return
+	}
+	if d.backend != nil {
+		counter := uint64(d.t1)<<32 | uint64(d.t0)
+		d.backend.CompressBlocks(&d.h, counter+BlockSize, [2]uint32{d.f0, d.f1}, p)
+		counter += uint64(len(p))
+		d.t0, d.t1 = uint32(counter), uint32(counter>>32)
+		return
 	}
+	for ; len(p) > 0; p = p[BlockSize:] {
+		d.addCounter(BlockSize)
+		core.Block(&d.h, (*[BlockSize]byte)(p), d.t0, d.t1, d.f0, d.f1)
+	}
+}
 
-	return bytesWritten, nil
+// counter returns the value the t0/t1 counter pair would hold if the pending
+// input were compressed now, including the key block.
+func (d *Digest) counter() uint64 {
+	return (uint64(d.t1)<<32 | uint64(d.t0)) + uint64(d.offset)
 }
 
+// BytesWritten returns the number of message bytes written so far. The key
+// block of a keyed instance is not included.
+func (d *Digest) BytesWritten() uint64 {
+	n := d.counter()
+	if d.keyLen > 0 {
+		n -= BlockSize
+	}
+	return n
+}
+
+// ErrUninitialized is returned by Final for the zero Digest or one that has
+// been wiped, which has no output size and would hash to nothing.
+var ErrUninitialized = errors.New("blake2s: Digest is zero or wiped")
+
 // Sum appends the current hash to b and returns the resulting slice.
-// It does not change the underlying hash state.
+// It does not change the underlying hash state. If b has room for the
+// digest, Sum doesn't allocate. Sum can't fail on a Digest from one of the
+// constructors; it panics if d is the zero Digest or has been wiped, rather
+// than appending an empty digest. Use Final to get an error instead.
 func (d *Digest) Sum(b []byte) (out []byte) {
+	if d.size == 0 {
+		panic(ErrUninitialized)
+	}
 	// if there's space, reuse the b slice
 	if n := len(b) + d.size; cap(b) >= n {
 		out = b[:n]
@@ -431,19 +352,44 @@ func (d *Digest) Sum(b []byte) (out []byte) {
 		copy(out, b)
 	}
 
-	err := d.finalize(out[len(b):])
+	d.finalize(out[len(b):])
+	return out
+}
 
-	if err != nil {
-		return out[:len(b)]
+// Final returns the current hash, like Sum(nil), but returns
+// ErrUninitialized instead of panicking if d is the zero Digest or has been
+// wiped. It does not change the underlying hash state.
+func (d *Digest) Final() ([]byte, error) {
+	if d.size == 0 {
+		return nil, ErrUninitialized
 	}
-
-	return out
+	out := make([]byte, d.size)
+	d.finalize(out)
+	return out, nil
 }
 
-// Reset resets the Hash to its initial state.
+// Reset returns the Digest to its state just after construction, keeping its
+// key, parameters and options. A Guard's charges are not refunded.
 func (d *Digest) Reset() {
-	// TODO: not this
-	panic("BLAKE2 cannot be reset without storing the key")
+	d.h = d.init
+	d.t0, d.t1 = 0, 0
+	d.f0, d.f1 = 0, 0
+	d.buf = [BlockSize]byte{}
+	d.offset = 0
+	if d.keyLen > 0 {
+		// As in NewDigest, the zero-padded key block stays buffered until
+		// the next Write or Sum.
+		copy(d.buf[:], d.key[:d.keyLen])
+		d.offset = BlockSize
+	}
+}
+
+// Wipe zeroes the Digest's state, including the chaining value, the stored
+// key, salt and personalization, and any pending input. Keyed Digests hold
+// their key until then, so code handling secrets should defer Wipe. A wiped
+// Digest must not be used again.
+func (d *Digest) Wipe() {
+	*d = Digest{}
 }
 
 // Size returns the digest output size in bytes.
@@ -2,11 +2,18 @@ package blake2s
 
 import (
 	"bytes"
+	"crypto/hmac"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
+	"io"
 	"io/ioutil"
+	"math"
 	"testing"
+
+	"github.com/gtank/blake2s/core"
+	"github.com/gtank/blake2s/testutil"
 )
 
 const (
@@ -15,9 +22,9 @@ const (
 )
 
 func TestParameterBlockInit(t *testing.T) {
-	params := &parameterBlock{
-		fanout:     1,
-		depth:      1,
+	params := &ParameterBlock{
+		Fanout:     1,
+		Depth:      1,
 		KeyLength:  32,
 		DigestSize: 32,
 	}
@@ -25,7 +32,7 @@ func TestParameterBlockInit(t *testing.T) {
 	packedBytes := params.Marshal()
 	expectedBytes, _ := hex.DecodeString(SeqNoKeySaltOrPersonal)
 
-	if !bytes.Equal(packedBytes, expectedBytes) {
+	if !bytes.Equal(packedBytes[:], expectedBytes) {
 		t.Errorf("packed bytes mismatch: %x %x", packedBytes, expectedBytes)
 	}
 
@@ -192,6 +199,20 @@ func TestStreamingWrite(t *testing.T) {
 	}
 }
 
+func TestTruncatedOutput(t *testing.T) {
+	expected, _ := hex.DecodeString("aa4938119b1dc7b87cbad0ffd200d0ae")
+
+	d, err := NewDigest(nil, nil, nil, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Write([]byte("abc"))
+
+	if !bytes.Equal(expected, d.Sum(nil)) {
+		t.Errorf("16-byte digest mismatch: %x", d.Sum(nil))
+	}
+}
+
 var extrasVectors = []struct {
 	input, key, salt, personality, output string
 }{
@@ -332,3 +353,191 @@ func BenchmarkHash1K(b *testing.B) {
 func BenchmarkHash8K(b *testing.B) {
 	benchmarkHashSize(b, 8192)
 }
+
+// benchmarkCopy hashes 1MiB delivered in the chunks of pattern, to see how
+// the streaming path copes with reads that don't line up with blocks.
+func benchmarkCopy(b *testing.B, pattern testutil.ReadPattern) {
+	data := testutil.Input(1 << 20)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d, _ := NewDigest(nil, nil, nil, 32)
+		io.Copy(d, testutil.SimulatedReader(bytes.NewReader(data), pattern))
+		d.Sum(nil)
+	}
+}
+
+func BenchmarkCopySmallReads(b *testing.B) {
+	benchmarkCopy(b, testutil.ReadPattern{ChunkMin: 1, ChunkMax: 512})
+}
+
+func BenchmarkCopyNetworkReads(b *testing.B) {
+	benchmarkCopy(b, testutil.ReadPattern{ChunkMin: 1200, ChunkMax: 1500})
+}
+
+func BenchmarkCopyLargeReads(b *testing.B) {
+	benchmarkCopy(b, testutil.ReadPattern{})
+}
+
+func TestBytesWritten(t *testing.T) {
+	for _, key := range [][]byte{nil, []byte("key")} {
+		d, err := NewDigest(key, nil, nil, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.BytesWritten() != 0 {
+			t.Errorf("fresh digest reports %d bytes", d.BytesWritten())
+		}
+		d.Write(make([]byte, 100))
+		d.Write(make([]byte, 28))
+		d.Write(make([]byte, 1))
+		if d.BytesWritten() != 129 {
+			t.Errorf("expected 129 bytes, got %d", d.BytesWritten())
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	msg := []byte("the quick brown fox jumps over the lazy dog, repeatedly and at length")
+	for _, key := range [][]byte{nil, []byte("key"), bytes.Repeat([]byte{7}, KeyLength)} {
+		fresh, _ := NewDigest(key, []byte("salt"), []byte("persona"), 24)
+		fresh.Write(msg)
+		want := fresh.Sum(nil)
+
+		d, _ := NewDigest(key, []byte("salt"), []byte("persona"), 24)
+		d.Write(bytes.Repeat(msg, 5))
+		d.Sum(nil)
+		d.Reset()
+		if d.BytesWritten() != 0 {
+			t.Errorf("key %q: %d bytes written after Reset", key, d.BytesWritten())
+		}
+		d.Write(msg)
+		if got := d.Sum(nil); !bytes.Equal(got, want) {
+			t.Errorf("key %q: got %x after Reset, want %x", key, got, want)
+		}
+	}
+
+	// crypto/hmac resets its inner and outer hashes between messages.
+	mac := hmac.New(func() hash.Hash {
+		d, _ := NewDigest(nil, nil, nil, 32)
+		return d
+	}, []byte("hmac key"))
+	mac.Write(msg)
+	first := mac.Sum(nil)
+	mac.Reset()
+	mac.Write(msg)
+	if second := mac.Sum(nil); !bytes.Equal(first, second) {
+		t.Errorf("HMAC changed after Reset: %x, %x", first, second)
+	}
+}
+
+func TestWipe(t *testing.T) {
+	d, _ := NewDigest([]byte("secret key"), []byte("salt"), []byte("persona"), 32)
+	d.Write([]byte("secret message"))
+	d.Wipe()
+	if *d != (Digest{}) {
+		t.Errorf("state survived Wipe: %+v", *d)
+	}
+}
+
+func TestEmptyKey(t *testing.T) {
+	// RFC 7693 Appendix B: the unkeyed BLAKE2s-256 of "abc". An empty key
+	// is no key at all, not a zero key block.
+	want := "508c5e8c327c14e2e1a72ba34eeb452f37458b209ed63a294d999b4c86675982"
+	for _, key := range [][]byte{nil, {}} {
+		d, err := NewDigest(key, nil, nil, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Write([]byte("abc"))
+		if got := hex.EncodeToString(d.Sum(nil)); got != want {
+			t.Errorf("key %#v: got %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestFinal(t *testing.T) {
+	d, _ := NewDigest([]byte("key"), nil, nil, 20)
+	d.Write([]byte("message"))
+	got, err := d.Final()
+	if err != nil || !bytes.Equal(got, d.Sum(nil)) {
+		t.Errorf("Final returned %x, %v; Sum returned %x", got, err, d.Sum(nil))
+	}
+
+	d.Wipe()
+	if got, err := d.Final(); got != nil || err != ErrUninitialized {
+		t.Errorf("Final of a wiped Digest returned %x, %v", got, err)
+	}
+	for _, d := range []*Digest{d, new(Digest)} {
+		func() {
+			defer func() {
+				if r := recover(); r != ErrUninitialized {
+					t.Errorf("Sum panicked with %v, want ErrUninitialized", r)
+				}
+			}()
+			d.Sum(nil)
+		}()
+	}
+}
+
+func TestCounterLimit(t *testing.T) {
+	d, _ := NewDigest(nil, nil, nil, 32)
+	d.t0, d.t1 = 0xFFFFFFC0, 0xFFFFFFFF
+
+	if n, err := d.Write(make([]byte, BlockSize-2)); n != BlockSize-2 || err != nil {
+		t.Fatalf("write up to the limit failed: %d, %v", n, err)
+	}
+	if n, err := d.Write([]byte{0, 0}); n != 0 || err != ErrInputTooLong {
+		t.Fatalf("write past the limit returned %d, %v", n, err)
+	}
+	if _, err := d.Write([]byte{0}); err != nil {
+		t.Fatalf("final byte rejected: %v", err)
+	}
+	if d.BytesWritten() != math.MaxUint64 {
+		t.Errorf("unexpected count %d", d.BytesWritten())
+	}
+}
+
+func TestSharedIV(t *testing.T) {
+	iv := [8]uint32{IV0, IV1, IV2, IV3, IV4, IV5, IV6, IV7}
+	shared := [8]uint32{core.IV0, core.IV1, core.IV2, core.IV3, core.IV4, core.IV5, core.IV6, core.IV7}
+	if iv != shared {
+		t.Error("exported IV differs from the compression function's")
+	}
+}
+
+func TestSumAllocations(t *testing.T) {
+	for _, key := range [][]byte{nil, []byte("key")} {
+		d, _ := NewDigest(key, nil, nil, 32)
+		d.Write([]byte("message"))
+		buf := make([]byte, 0, MaxOutput)
+		if n := testing.AllocsPerRun(100, func() { d.Sum(buf[:0]) }); n != 0 {
+			t.Errorf("key %q: Sum into a buffer with room allocates %v times", key, n)
+		}
+		if n := testing.AllocsPerRun(100, func() { d.Sum(nil) }); n != 1 {
+			t.Errorf("key %q: Sum(nil) allocates %v times", key, n)
+		}
+	}
+}
+
+func TestContract(t *testing.T) {
+	err := testutil.Contract(func() hash.Hash {
+		d, _ := NewDigest(nil, nil, nil, 32)
+		return d
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Empty writes to a keyed instance leave the key block alone.
+	key := []byte("key")
+	d, _ := NewDigest(key, nil, nil, 32)
+	fresh := d.Sum(nil)
+	if n, err := d.Write(nil); n != 0 || err != nil {
+		t.Errorf("Write(nil) returned %d, %v", n, err)
+	}
+	d.Write([]byte{})
+	if !bytes.Equal(d.Sum(nil), fresh) || d.BytesWritten() != 0 {
+		t.Error("empty writes changed a keyed digest")
+	}
+}
@@ -0,0 +1,144 @@
+// Package blake2sp implements BLAKE2sp, the 8-way parallel variant of
+// BLAKE2s, compatible with the reference implementation and "b2sum -a
+// blake2sp".
+//
+// The input is dealt out in 64-byte blocks to eight leaf hashes in turn, and
+// a root hash combines the leaves' digests. The leaves are independent, so
+// large writes hash them on separate goroutines. BLAKE2sp digests differ
+// from BLAKE2s digests of the same input.
+package blake2sp
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/gtank/blake2s/core"
+)
+
+const (
+	// Size is the size of a BLAKE2sp digest in bytes.
+	Size = 32
+	// BlockSize is the block size of each leaf in bytes.
+	BlockSize = core.BlockSize
+	// KeySize is the largest key size in bytes.
+	KeySize = core.KeySize
+
+	degree = 8
+	stripe = degree * BlockSize
+
+	// parallelMin is the smallest write whose stripes are hashed on
+	// separate goroutines.
+	parallelMin = 32 * stripe
+)
+
+// Digest is a BLAKE2sp hash in progress. It implements hash.Hash.
+type Digest struct {
+	keyLength byte
+	leaves    [degree]*core.Digest
+	written   uint64
+}
+
+// New returns a BLAKE2sp hash, keyed with key if it is not empty.
+func New(key []byte) (*Digest, error) {
+	if len(key) > KeySize {
+		return nil, errors.New("blake2sp: key too large")
+	}
+	d := &Digest{keyLength: byte(len(key))}
+	for i := range d.leaves {
+		p := d.params(0)
+		p.NodeOffset = uint32(i)
+		leaf, err := core.New(p, key)
+		if err != nil {
+			return nil, err
+		}
+		if i == degree-1 {
+			leaf.SetLastNode()
+		}
+		d.leaves[i] = leaf
+	}
+	return d, nil
+}
+
+func (d *Digest) params(nodeDepth byte) *core.ParameterBlock {
+	return &core.ParameterBlock{
+		DigestSize:  Size,
+		KeyLength:   d.keyLength,
+		Fanout:      degree,
+		Depth:       2,
+		NodeDepth:   nodeDepth,
+		InnerLength: Size,
+	}
+}
+
+// Write adds more data to the running hash. It never returns an error.
+func (d *Digest) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(p) >= parallelMin {
+		// Align to a stripe, hash the whole stripes with one goroutine
+		// per leaf, then carry on with the rest.
+		head := (stripe - int(d.written%stripe)) % stripe
+		d.writeSequential(p[:head])
+		p = p[head:]
+		whole := len(p) / stripe * stripe
+		d.writeStripes(p[:whole])
+		p = p[whole:]
+	}
+	d.writeSequential(p)
+	return n, nil
+}
+
+func (d *Digest) writeSequential(p []byte) {
+	for len(p) > 0 {
+		leaf := d.leaves[d.written/BlockSize%degree]
+		c := min(len(p), BlockSize-int(d.written%BlockSize))
+		leaf.Write(p[:c])
+		d.written += uint64(c)
+		p = p[c:]
+	}
+}
+
+// writeStripes hashes p, a whole number of stripes starting at a stripe
+// boundary.
+func (d *Digest) writeStripes(p []byte) {
+	var wg sync.WaitGroup
+	for i, leaf := range d.leaves {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for off := i * BlockSize; off < len(p); off += stripe {
+				leaf.Write(p[off : off+BlockSize])
+			}
+		}()
+	}
+	wg.Wait()
+	d.written += uint64(len(p))
+}
+
+// Sum appends the digest to b without changing the hash state.
+func (d *Digest) Sum(b []byte) []byte {
+	root, err := core.New(d.params(1), nil)
+	if err != nil {
+		panic("blake2sp: " + err.Error())
+	}
+	root.SetLastNode()
+	var leaf [Size]byte
+	for _, l := range d.leaves {
+		root.Write(l.Sum(leaf[:0]))
+	}
+	return root.Sum(b)
+}
+
+// Reset returns the Digest to its state just after New, keeping its key.
+func (d *Digest) Reset() {
+	for _, l := range d.leaves {
+		l.Reset()
+	}
+	d.written = 0
+}
+
+// Size returns the digest size in bytes.
+func (d *Digest) Size() int { return Size }
+
+// BlockSize returns the block size in bytes. Writes of whole stripes of
+// eight blocks are the most efficient.
+func (d *Digest) BlockSize() int { return BlockSize }
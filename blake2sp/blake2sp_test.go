@@ -0,0 +1,81 @@
+package blake2sp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+type vector struct {
+	Hash   string `json:"hash"`
+	Input  string `json:"in"`
+	Key    string `json:"key"`
+	Output string `json:"out"`
+}
+
+//go:generate sh -c "cd .. && python3 gen_blake2sp_vectors.py testdata/blake2sp-kat.json"
+
+func TestVectors(t *testing.T) {
+	data, err := os.ReadFile("../testdata/blake2sp-kat.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []vector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) == 0 {
+		t.Fatal("no vectors")
+	}
+	for _, v := range vectors {
+		in, _ := hex.DecodeString(v.Input)
+		key, _ := hex.DecodeString(v.Key)
+		d, err := New(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Odd-sized writes cross block and stripe boundaries.
+		for rest := in; len(rest) > 0; {
+			n := min(len(rest), 100)
+			d.Write(rest[:n])
+			rest = rest[n:]
+		}
+		if got := hex.EncodeToString(d.Sum(nil)); got != v.Output {
+			t.Errorf("key %q, %d bytes: got %s", v.Key, len(in), got)
+		}
+		d.Reset()
+		d.Write(in)
+		if got := hex.EncodeToString(d.Sum(nil)); got != v.Output {
+			t.Errorf("key %q, %d bytes after Reset: got %s", v.Key, len(in), got)
+		}
+	}
+}
+
+// TestParallelWrites checks that the goroutine path, entered from any
+// alignment, matches small sequential writes.
+func TestParallelWrites(t *testing.T) {
+	in := make([]byte, 3*parallelMin+123)
+	for i := range in {
+		in[i] = byte(i * 31)
+	}
+	small, _ := New([]byte("key"))
+	for _, c := range in {
+		small.Write([]byte{c})
+	}
+	want := small.Sum(nil)
+
+	for _, head := range []int{0, 1, 64, stripe - 1, stripe} {
+		d, _ := New([]byte("key"))
+		d.Write(in[:head])
+		d.Write(in[head:])
+		if !bytes.Equal(d.Sum(nil), want) {
+			t.Errorf("large write after %d bytes differs", head)
+		}
+	}
+
+	if _, err := New(make([]byte, 33)); err == nil {
+		t.Error("accepted a 33-byte key")
+	}
+}
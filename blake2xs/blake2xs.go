@@ -0,0 +1,156 @@
+// Package blake2xs implements BLAKE2Xs, the extendable-output form of
+// BLAKE2s, for producing keys, keystreams or digests of any length.
+//
+// Input is hashed once with a BLAKE2s whose parameter block records the
+// requested output length. The output is then expanded from that root hash
+// in 32-byte blocks, each the BLAKE2s hash of the root under a parameter
+// block carrying the block's index, as described in the BLAKE2X paper. An
+// XOF is written like any hash and then read from like an io.Reader.
+package blake2xs
+
+import (
+	"errors"
+	"io"
+
+	"github.com/gtank/blake2s/core"
+)
+
+const (
+	// MaxOutput is the largest output length, in bytes, that can be
+	// requested in advance.
+	MaxOutput = 0xFFFE
+
+	// OutputLengthUnknown, used as the size argument to NewXOF, produces
+	// output until 2^32 blocks of 32 bytes have been read, for callers that
+	// don't know in advance how much they need. Such output differs from
+	// output of any fixed length.
+	OutputLengthUnknown = 0xFFFF
+)
+
+// ErrWriteAfterRead is returned by Write once output has been read.
+var ErrWriteAfterRead = errors.New("blake2xs: write after read")
+
+// An XOF absorbs input through Write and produces output through Read.
+type XOF struct {
+	size          uint32
+	salt, persona [8]byte
+	root          *core.Digest
+	h0            []byte // root hash, once reading starts
+	block         []byte // unread part of the current output block
+	nodeOffset    uint64 // index of the next output block
+	remaining     uint64 // bytes left to read
+}
+
+// NewXOF returns an XOF producing size bytes, keyed with key if it is not
+// empty. size is between 1 and MaxOutput, or OutputLengthUnknown.
+func NewXOF(size uint32, key []byte) (*XOF, error) {
+	return NewXOFWithParams(size, key, nil, nil)
+}
+
+// NewXOFWithParams is like NewXOF but also sets the salt and
+// personalization fields, which apply to the root and every output block.
+func NewXOFWithParams(size uint32, key, salt, personalization []byte) (*XOF, error) {
+	if size == 0 || size > OutputLengthUnknown {
+		return nil, errors.New("blake2xs: output length must be 1 to 65534 bytes, or OutputLengthUnknown")
+	}
+	if len(key) > core.KeySize {
+		return nil, errors.New("blake2xs: key too large")
+	}
+	if len(salt) > core.SaltSize {
+		return nil, errors.New("blake2xs: salt too large")
+	}
+	if len(personalization) > core.PersonalizationSize {
+		return nil, errors.New("blake2xs: personalization too large")
+	}
+	x := &XOF{size: size}
+	copy(x.salt[:], salt)
+	copy(x.persona[:], personalization)
+
+	p := &core.ParameterBlock{
+		DigestSize:      core.MaxSize,
+		KeyLength:       byte(len(key)),
+		Fanout:          1,
+		Depth:           1,
+		XOFLength:       uint16(size),
+		Salt:            x.salt,
+		Personalization: x.persona,
+	}
+	root, err := core.New(p, key)
+	if err != nil {
+		return nil, err
+	}
+	x.root = root
+	x.Reset()
+	return x, nil
+}
+
+// Write absorbs more input. It returns ErrWriteAfterRead once Read has been
+// called.
+func (x *XOF) Write(p []byte) (int, error) {
+	if x.h0 != nil {
+		return 0, ErrWriteAfterRead
+	}
+	return x.root.Write(p)
+}
+
+// Read fills p with the next bytes of output. It returns io.EOF once the
+// requested output length has been produced.
+func (x *XOF) Read(p []byte) (int, error) {
+	if x.h0 == nil {
+		x.h0 = x.root.Sum(nil)
+	}
+	if x.remaining == 0 {
+		return 0, io.EOF
+	}
+	if uint64(len(p)) > x.remaining {
+		p = p[:x.remaining]
+	}
+	n := 0
+	for n < len(p) {
+		if len(x.block) == 0 {
+			x.block = x.outputBlock()
+		}
+		c := copy(p[n:], x.block)
+		x.block = x.block[c:]
+		n += c
+	}
+	x.remaining -= uint64(n)
+	return n, nil
+}
+
+// outputBlock computes the next block of output, which is shorter than 32
+// bytes only at the end of a fixed-length output.
+func (x *XOF) outputBlock() []byte {
+	size := uint64(core.MaxSize)
+	if x.size != OutputLengthUnknown {
+		size = min(size, uint64(x.size)-x.nodeOffset*core.MaxSize)
+	}
+	d, err := core.New(&core.ParameterBlock{
+		DigestSize:      byte(size),
+		LeafLength:      core.MaxSize,
+		NodeOffset:      uint32(x.nodeOffset),
+		XOFLength:       uint16(x.size),
+		InnerLength:     core.MaxSize,
+		Salt:            x.salt,
+		Personalization: x.persona,
+	}, nil)
+	if err != nil {
+		panic("blake2xs: " + err.Error())
+	}
+	d.Write(x.h0)
+	x.nodeOffset++
+	return d.Sum(nil)
+}
+
+// Reset discards all input and output, returning the XOF to its state just
+// after construction.
+func (x *XOF) Reset() {
+	x.root.Reset()
+	x.h0 = nil
+	x.block = nil
+	x.nodeOffset = 0
+	x.remaining = uint64(x.size)
+	if x.size == OutputLengthUnknown {
+		x.remaining = 1 << 32 * core.MaxSize
+	}
+}
@@ -0,0 +1,109 @@
+package blake2xs
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+)
+
+type vector struct {
+	Hash    string `json:"hash"`
+	Input   string `json:"in"`
+	Key     string `json:"key"`
+	Persona string `json:"persona,omitempty"`
+	Salt    string `json:"salt,omitempty"`
+	Output  string `json:"out"`
+}
+
+func TestVectors(t *testing.T) {
+	data, err := os.ReadFile("../testdata/blake2xs-kat.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []vector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		in, _ := hex.DecodeString(v.Input)
+		key, _ := hex.DecodeString(v.Key)
+		salt, _ := hex.DecodeString(v.Salt)
+		persona, _ := hex.DecodeString(v.Persona)
+		expected, _ := hex.DecodeString(v.Output)
+
+		x, err := NewXOFWithParams(uint32(len(expected)), key, salt, persona)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x.Write(in)
+		got, err := io.ReadAll(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, expected) {
+			t.Errorf("key %q, %d bytes: got %x", v.Key, len(expected), got)
+		}
+	}
+}
+
+func TestReadInPieces(t *testing.T) {
+	x, _ := NewXOF(1000, []byte("key"))
+	x.Write([]byte("input"))
+	whole, _ := io.ReadAll(x)
+
+	x.Reset()
+	x.Write([]byte("in"))
+	x.Write([]byte("put"))
+	var pieces []byte
+	buf := make([]byte, 7)
+	for {
+		n, err := x.Read(buf)
+		pieces = append(pieces, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+	}
+	if len(whole) != 1000 || !bytes.Equal(whole, pieces) {
+		t.Error("output depends on read sizes")
+	}
+	if _, err := x.Write([]byte("more")); err != ErrWriteAfterRead {
+		t.Errorf("write after read returned %v", err)
+	}
+}
+
+func TestUnknownLength(t *testing.T) {
+	x, err := NewXOF(OutputLengthUnknown, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]byte, 100000)
+	if _, err := io.ReadFull(x, out); err != nil {
+		t.Fatal(err)
+	}
+	// The output length is part of every block's parameters.
+	fixed, _ := NewXOF(64, nil)
+	prefix, _ := io.ReadAll(fixed)
+	if bytes.Equal(out[:64], prefix) {
+		t.Error("unknown-length output matches fixed-length output")
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	for _, size := range []uint32{0, OutputLengthUnknown + 1} {
+		if _, err := NewXOF(size, nil); err == nil {
+			t.Errorf("accepted size %d", size)
+		}
+	}
+	if _, err := NewXOF(32, make([]byte, 33)); err == nil {
+		t.Error("accepted a 33-byte key")
+	}
+	if _, err := NewXOFWithParams(32, nil, make([]byte, 9), nil); err == nil {
+		t.Error("accepted a 9-byte salt")
+	}
+	if _, err := NewXOFWithParams(32, nil, nil, make([]byte, 9)); err == nil {
+		t.Error("accepted a 9-byte personalization")
+	}
+}
@@ -0,0 +1,110 @@
+package blake2s
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+)
+
+// A Guard enforces a hashing budget: a byte limit and the deadline or
+// cancellation of a context. One Guard can be shared by every Digest and
+// reader working on behalf of a request, so that a server hashing untrusted
+// input bounds the total work per request rather than per stream. It is
+// safe for concurrent use.
+type Guard struct {
+	ctx      context.Context
+	maxBytes uint64
+	used     atomic.Uint64
+}
+
+// NewGuard returns a Guard that allows maxBytes bytes in total, or any number
+// if maxBytes is zero, until ctx is done. Use context.WithTimeout or
+// context.WithDeadline to limit time.
+func NewGuard(ctx context.Context, maxBytes uint64) *Guard {
+	return &Guard{ctx: ctx, maxBytes: maxBytes}
+}
+
+// Charge accounts for n more bytes of work. It returns ctx.Err() once the
+// context is done, and ErrMaxInput if n would exceed the byte limit, in which
+// case nothing is charged.
+func (g *Guard) Charge(n int) error {
+	if err := g.ctx.Err(); err != nil {
+		return err
+	}
+	for {
+		used := g.used.Load()
+		if g.maxBytes != 0 && uint64(n) > g.maxBytes-used {
+			return ErrMaxInput
+		}
+		if g.used.CompareAndSwap(used, used+uint64(n)) {
+			return nil
+		}
+	}
+}
+
+// Used returns the number of bytes charged so far.
+func (g *Guard) Used() uint64 {
+	return g.used.Load()
+}
+
+// Reader returns a reader that charges everything it reads from r to the
+// Guard, failing with the Guard's error once the budget is spent.
+func (g *Guard) Reader(r io.Reader) io.Reader {
+	return &guardedReader{g: g, r: r}
+}
+
+type guardedReader struct {
+	g *Guard
+	r io.Reader
+}
+
+func (gr *guardedReader) Read(p []byte) (int, error) {
+	if err := gr.g.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := gr.r.Read(p)
+	if n > 0 {
+		if cerr := gr.g.Charge(n); cerr != nil {
+			return 0, cerr
+		}
+	}
+	return n, err
+}
+
+// WithGuard charges every Write to the Digest against g, so a Write fails
+// with the Guard's error, hashing nothing, once its budget is spent.
+func WithGuard(g *Guard) Option {
+	return func(c *config) error {
+		c.guard = g
+		return nil
+	}
+}
+
+// HashReaderContext hashes everything r produces with a Digest configured by
+// opts and returns the digest. It checks ctx between reads, so hashing stops
+// with ctx.Err() soon after ctx is done even if r never ends; combine with
+// WithGuard or WithMaxInput to also bound the number of bytes.
+func HashReaderContext(ctx context.Context, r io.Reader, opts ...Option) ([]byte, error) {
+	d, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, readerChunkSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := d.Write(buf[:n]); werr != nil {
+				return nil, werr
+			}
+		}
+		if err == io.EOF {
+			return d.Sum(nil), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package blake2s
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestGuard(t *testing.T) {
+	g := NewGuard(context.Background(), 100)
+	a, _ := New(WithGuard(g))
+	b, _ := New(WithKey([]byte("key")), WithGuard(g))
+
+	// The limit is shared, and the key block is not charged.
+	if _, err := a.Write(make([]byte, 60)); err != nil {
+		t.Fatal(err)
+	}
+	before := b.Sum(nil)
+	if n, err := b.Write(make([]byte, 41)); n != 0 || err != ErrMaxInput {
+		t.Fatalf("write over the shared limit returned %d, %v", n, err)
+	}
+	if !bytes.Equal(before, b.Sum(nil)) {
+		t.Error("rejected write changed the hash state")
+	}
+	if _, err := b.Write(make([]byte, 40)); err != nil {
+		t.Fatal(err)
+	}
+	if g.Used() != 100 {
+		t.Errorf("used %d, want 100", g.Used())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g = NewGuard(ctx, 0)
+	r := g.Reader(strings.NewReader("some input"))
+	if _, err := io.ReadAll(r); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	d, _ := New(WithGuard(g))
+	if _, err := d.Write([]byte("x")); err != context.Canceled {
+		t.Errorf("write after cancellation returned %v", err)
+	}
+	if _, err := g.Reader(strings.NewReader("x")).Read(make([]byte, 1)); err != context.Canceled {
+		t.Errorf("read after cancellation returned %v", err)
+	}
+}
+
+type endlessReader struct{ cancel func() }
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	r.cancel()
+	return len(p), nil
+}
+
+func TestHashReaderContext(t *testing.T) {
+	input := strings.Repeat("input ", 10000)
+	sum, err := HashReaderContext(context.Background(), strings.NewReader(input), WithSize(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := NewDigest(nil, nil, nil, 16)
+	expected.Write([]byte(input))
+	if !bytes.Equal(sum, expected.Sum(nil)) {
+		t.Error("digest differs from NewDigest")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := HashReaderContext(ctx, &endlessReader{cancel}); err != context.Canceled {
+		t.Errorf("endless input returned %v", err)
+	}
+
+	g := NewGuard(context.Background(), 1000)
+	if _, err := HashReaderContext(context.Background(), strings.NewReader(input), WithGuard(g)); err != ErrMaxInput {
+		t.Errorf("input over budget returned %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+// Package cache remembers file digests across processes so unchanged files
+// aren't hashed again.
+//
+// Entries are keyed by the file's device and inode numbers, size and
+// modification time, plus a fingerprint of the hash parameters, so a file
+// that is replaced, rewritten or hashed with a different key misses the
+// cache. Entries are stored in a journal (see package journal), and an
+// advisory lock on a companion ".lock" file serializes access between
+// processes on platforms that support it. Both files are readable only by
+// their owner, since digests, keyed ones included, reveal something about
+// the files they describe.
+//
+// Open compacts the journal, dropping superseded records and all but the
+// newest MaxEntries entries, so entries for files that have since changed,
+// or for parameters no longer in use, age out instead of accumulating.
+package cache
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gtank/blake2s"
+	"github.com/gtank/blake2s/journal"
+)
+
+// racyWindow is how recently a file may have been modified and still be
+// cached. A file written within the file system's timestamp granularity of
+// being hashed could change again without its modification time changing.
+const racyWindow = 2 * time.Second
+
+// MaxEntries is the most entries Open keeps when it compacts the cache.
+const MaxEntries = 1 << 16
+
+// A Cache maps file identities to digests.
+type Cache struct {
+	mu      sync.Mutex
+	lock    *os.File
+	j       *journal.Journal
+	entries map[string][]byte
+}
+
+// Open opens or creates the cache stored at path.
+func Open(path string) (*Cache, error) {
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	defer unlockFile(lock)
+	j, records, err := journal.Open(path, journal.SyncOnClose)
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	live := compact(records, MaxEntries)
+	if len(live) < len(records) {
+		if err := j.Compact(live); err != nil {
+			j.Close()
+			lock.Close()
+			return nil, err
+		}
+	}
+
+	c := &Cache{lock: lock, j: j, entries: make(map[string][]byte, len(live))}
+	for _, r := range live {
+		c.entries[r.Name] = r.Digest
+	}
+	return c, nil
+}
+
+// compact returns the latest record for each of the newest max names, in
+// journal order.
+func compact(records []journal.Record, max int) []journal.Record {
+	seen := make(map[string]bool, len(records))
+	var live []journal.Record
+	for i := len(records) - 1; i >= 0 && len(live) < max; i-- {
+		if r := records[i]; !seen[r.Name] {
+			seen[r.Name] = true
+			live = append(live, r)
+		}
+	}
+	slices.Reverse(live)
+	return live
+}
+
+// Close flushes and closes the cache.
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.j.Close()
+	if cerr := c.lock.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// fingerprint identifies the parameters of a fresh digest without revealing
+// its key: it is the hash of the digest's output on empty input.
+func fingerprint(d hash.Hash) string {
+	h, _ := blake2s.NewDigest(nil, nil, nil, 16)
+	h.Write(d.Sum(nil))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func entryName(id fileID, info os.FileInfo, fp string) string {
+	return fmt.Sprintf("%d:%d:%d:%d:%s", id.dev, id.ino, info.Size(), info.ModTime().UnixNano(), fp)
+}
+
+// HashFile returns the digest of the named file as computed by the fresh
+// hash d, such as a *blake2s.Digest or *blake2b.Digest, from the cache if
+// possible. d is only written to on a miss.
+func (c *Cache) HashFile(name string, d hash.Hash) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	id, cacheable := identify(info)
+	cacheable = cacheable && info.Mode().IsRegular() && time.Since(info.ModTime()) > racyWindow
+
+	var key string
+	if cacheable {
+		key = entryName(id, info, fingerprint(d))
+		c.mu.Lock()
+		digest, ok := c.entries[key]
+		c.mu.Unlock()
+		if ok {
+			return append([]byte(nil), digest...), nil
+		}
+	}
+
+	if _, err := io.Copy(d, f); err != nil {
+		return nil, err
+	}
+	digest := d.Sum(nil)
+	if cacheable {
+		if err := c.store(key, digest); err != nil {
+			return nil, err
+		}
+	}
+	return digest, nil
+}
+
+func (c *Cache) store(key string, digest []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := lockFile(c.lock); err != nil {
+		return err
+	}
+	defer unlockFile(c.lock)
+	if err := c.j.Append(journal.Record{Name: key, Digest: digest}); err != nil {
+		return err
+	}
+	c.entries[key] = append([]byte(nil), digest...)
+	return nil
+}
+
+// Len returns the number of cached entries.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gtank/blake2s"
+	"github.com/gtank/blake2s/journal"
+)
+
+func newDigest(t *testing.T, key []byte) *blake2s.Digest {
+	d, err := blake2s.NewDigest(key, nil, nil, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func writeOld(t *testing.T, path, data string) {
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	writeOld(t, file, "contents")
+
+	c, err := Open(filepath.Join(dir, "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := c.HashFile(file, newDigest(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 1 {
+		t.Fatalf("expected one entry, got %d", c.Len())
+	}
+	keyed, _ := c.HashFile(file, newDigest(t, []byte("key")))
+	if string(keyed) == string(first) || c.Len() != 2 {
+		t.Error("different parameters shared a cache entry")
+	}
+	c.Close()
+
+	// A new process sees the earlier entries.
+	c, err = Open(filepath.Join(dir, "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if c.Len() != 2 {
+		t.Fatalf("reopened cache has %d entries", c.Len())
+	}
+	again, _ := c.HashFile(file, newDigest(t, nil))
+	if string(again) != string(first) || c.Len() != 2 {
+		t.Error("cache miss for an unchanged file")
+	}
+
+	// Changing the file invalidates its entry.
+	writeOld(t, file, "changed!")
+	os.Chtimes(file, time.Now().Add(-time.Minute), time.Now().Add(-time.Minute))
+	changed, _ := c.HashFile(file, newDigest(t, nil))
+	expected := newDigest(t, nil)
+	expected.Write([]byte("changed!"))
+	if string(changed) != string(expected.Sum(nil)) {
+		t.Error("stale digest returned for a changed file")
+	}
+}
+
+func TestRecentFilesNotCached(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	os.WriteFile(file, []byte("fresh"), 0644)
+
+	c, _ := Open(filepath.Join(dir, "cache"))
+	defer c.Close()
+	if _, err := c.HashFile(file, newDigest(t, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 0 {
+		t.Error("cached a file modified moments ago")
+	}
+}
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cache")
+	j, _, err := journal.Open(path, journal.SyncOnClose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "a", "c", "a"} {
+		j.Append(journal.Record{Name: name, Digest: []byte(name)})
+	}
+	j.Close()
+
+	c, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 3 {
+		t.Errorf("expected 3 entries, got %d", c.Len())
+	}
+	c.Close()
+	for _, name := range []string{path, path + ".lock"} {
+		if info, err := os.Stat(name); err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("%s: mode %v, %v; want 0600", name, info.Mode(), err)
+		}
+	}
+
+	// Superseded records were dropped from the file itself.
+	j, records, err := journal.Open(path, journal.SyncOnClose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+	if len(records) != 3 {
+		t.Errorf("journal still holds %d records", len(records))
+	}
+
+	// Only the newest entries are kept.
+	var history []journal.Record
+	for _, name := range []string{"a", "b", "c", "b", "d"} {
+		history = append(history, journal.Record{Name: name})
+	}
+	var names []string
+	for _, r := range compact(history, 3) {
+		names = append(names, r.Name)
+	}
+	if strings.Join(names, ",") != "c,b,d" {
+		t.Errorf("compact kept %v", names)
+	}
+}
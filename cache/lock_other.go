@@ -0,0 +1,19 @@
+//go:build !unix
+
+package cache
+
+import (
+	"os"
+)
+
+type fileID struct {
+	dev, ino uint64
+}
+
+// Without a stable file identity nothing is cached, which is always safe.
+func identify(info os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
+
+func lockFile(f *os.File) error   { return nil }
+func unlockFile(f *os.File) error { return nil }
@@ -0,0 +1,176 @@
+// Package cas is a minimal content-addressed blob store keyed by BLAKE2s
+// digests.
+//
+// Blobs are stored as files named by the hex digest of their contents,
+// spread over 256 subdirectories by the first digest byte. Writes go to a
+// temporary file that is renamed into place once complete, so a blob is
+// either fully present or absent. Reads are verified as they stream.
+//
+// A Store created with NewKeyed names blobs by a keyed digest instead. Without
+// the key, nobody can work out the name a given content would be stored
+// under, so they can neither probe the store for known content nor plant
+// blobs under names chosen in advance.
+package cas
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gtank/blake2s"
+)
+
+// A Digest names a blob.
+type Digest [blake2s.MaxOutput]byte
+
+func (d Digest) String() string {
+	return hex.EncodeToString(d[:])
+}
+
+// ParseDigest decodes the hex form of a Digest.
+func ParseDigest(s string) (Digest, error) {
+	var d Digest
+	if hex.DecodedLen(len(s)) != len(d) {
+		return d, fmt.Errorf("cas: malformed digest %q", s)
+	}
+	if _, err := hex.Decode(d[:], []byte(s)); err != nil {
+		return d, fmt.Errorf("cas: malformed digest %q", s)
+	}
+	return d, nil
+}
+
+// ErrCorrupt is returned from the final Read of a blob whose contents no
+// longer match its digest.
+var ErrCorrupt = errors.New("cas: blob does not match its digest")
+
+// A Store is a directory of blobs.
+type Store struct {
+	dir string
+	key []byte // nil for an unkeyed store
+}
+
+// New returns a Store rooted at dir, creating the directory if needed.
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// NewKeyed returns a Store rooted at dir whose blobs are named by their
+// BLAKE2s digest keyed with key, which should be a secret of up to
+// blake2s.KeyLength bytes specific to the deployment. Digests from an
+// unkeyed store, or one with a different key, don't name the same blobs.
+func NewKeyed(dir string, key []byte) (*Store, error) {
+	if len(key) == 0 {
+		return nil, errors.New("cas: empty key")
+	}
+	if _, err := blake2s.NewDigest(key, nil, nil, blake2s.MaxOutput); err != nil {
+		return nil, err
+	}
+	s, err := New(dir)
+	if err != nil {
+		return nil, err
+	}
+	s.key = append([]byte(nil), key...)
+	return s, nil
+}
+
+func (s *Store) newHash() (*blake2s.Digest, error) {
+	return blake2s.NewDigest(s.key, nil, nil, blake2s.MaxOutput)
+}
+
+func (s *Store) path(d Digest) string {
+	name := d.String()
+	return filepath.Join(s.dir, name[:2], name)
+}
+
+// Put stores the contents of r and returns their digest. Storing a blob
+// that is already present is not an error.
+func (s *Store) Put(r io.Reader) (Digest, error) {
+	var digest Digest
+
+	tmp, err := os.CreateTemp(s.dir, ".put-*")
+	if err != nil {
+		return digest, err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly after the rename
+
+	h, err := s.newHash()
+	if err != nil {
+		tmp.Close()
+		return digest, err
+	}
+	if _, err := io.Copy(io.MultiWriter(tmp, h), r); err != nil {
+		tmp.Close()
+		return digest, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return digest, err
+	}
+	if err := tmp.Close(); err != nil {
+		return digest, err
+	}
+	h.Sum(digest[:0])
+
+	final := s.path(digest)
+	if err := os.MkdirAll(filepath.Dir(final), 0755); err != nil {
+		return digest, err
+	}
+	if err := os.Chmod(tmp.Name(), 0444); err != nil {
+		return digest, err
+	}
+	return digest, os.Rename(tmp.Name(), final)
+}
+
+// Has reports whether the blob is present, without verifying it.
+func (s *Store) Has(d Digest) bool {
+	_, err := os.Stat(s.path(d))
+	return err == nil
+}
+
+// Open returns a reader for the blob. The contents are hashed as they are
+// read, and the Read that would return io.EOF returns ErrCorrupt instead if
+// they don't match, so data must not be trusted until it has been read to
+// the end.
+func (s *Store) Open(d Digest) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(d))
+	if err != nil {
+		return nil, err
+	}
+	h, err := s.newHash()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &verifyingReader{f: f, h: h, expected: d}, nil
+}
+
+// Delete removes a blob.
+func (s *Store) Delete(d Digest) error {
+	return os.Remove(s.path(d))
+}
+
+type verifyingReader struct {
+	f        *os.File
+	h        *blake2s.Digest
+	expected Digest
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.f.Read(p)
+	r.h.Write(p[:n])
+	if err == io.EOF && subtle.ConstantTimeCompare(r.h.Sum(nil), r.expected[:]) != 1 {
+		err = ErrCorrupt
+	}
+	return n, err
+}
+
+func (r *verifyingReader) Close() error {
+	return r.f.Close()
+}
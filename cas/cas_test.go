@@ -0,0 +1,112 @@
+package cas
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPutOpen(t *testing.T) {
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := s.Put(strings.NewReader("blob contents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Has(d) {
+		t.Fatal("blob not stored")
+	}
+
+	// Storing the same content again is fine and yields the same digest.
+	again, err := s.Put(strings.NewReader("blob contents"))
+	if err != nil || again != d {
+		t.Fatalf("second put: %v, %v", again, err)
+	}
+
+	r, err := s.Open(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "blob contents" {
+		t.Errorf("read back %q, %v", data, err)
+	}
+
+	parsed, err := ParseDigest(d.String())
+	if err != nil || parsed != d {
+		t.Errorf("digest did not round-trip: %v", err)
+	}
+}
+
+func TestOpenCorrupt(t *testing.T) {
+	s, _ := New(t.TempDir())
+	d, _ := s.Put(strings.NewReader("original"))
+	path := s.path(d)
+	os.Chmod(path, 0644)
+	if err := os.WriteFile(path, []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := s.Open(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := io.ReadAll(r); err != ErrCorrupt {
+		t.Errorf("expected ErrCorrupt, got %v", err)
+	}
+}
+
+func TestMissing(t *testing.T) {
+	s, _ := New(t.TempDir())
+	var d Digest
+	if s.Has(d) {
+		t.Error("empty store has a blob")
+	}
+	if _, err := s.Open(d); !os.IsNotExist(err) {
+		t.Errorf("expected not-exist error, got %v", err)
+	}
+	if _, err := ParseDigest("abc"); err == nil {
+		t.Error("accepted a short digest")
+	}
+}
+
+func TestKeyed(t *testing.T) {
+	dir := t.TempDir()
+	plain, _ := New(dir)
+	keyed, err := NewKeyed(dir, []byte("deployment secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := NewKeyed(dir, []byte("another secret"))
+
+	d, err := keyed.Put(strings.NewReader("blob contents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := plain.Put(strings.NewReader("blob contents"))
+	o, _ := other.Put(strings.NewReader("blob contents"))
+	if d == p || d == o {
+		t.Error("keyed digest matches one computed without the key")
+	}
+
+	r, err := keyed.Open(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if data, err := io.ReadAll(r); err != nil || string(data) != "blob contents" {
+		t.Errorf("read back %q, %v", data, err)
+	}
+
+	if _, err := NewKeyed(dir, nil); err == nil {
+		t.Error("accepted an empty key")
+	}
+	if _, err := NewKeyed(dir, make([]byte, 33)); err == nil {
+		t.Error("accepted an oversized key")
+	}
+}
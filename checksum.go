@@ -0,0 +1,26 @@
+package blake2s
+
+import (
+	"github.com/gtank/blake2s/minimal"
+)
+
+// Checksum writes the BLAKE2s digest of data into out, producing len(out)
+// bytes of output and using key if it is not empty. It never allocates and
+// has no error path, in the style of low-level C APIs: it panics if out is
+// empty or longer than MaxOutput, or if key is longer than KeyLength.
+//
+// Checksum always uses the built-in compression function, regardless of
+// UseBackend.
+func Checksum(out []byte, key, data []byte) {
+	if len(out) == 0 || len(out) > MaxOutput {
+		panic("blake2s: Checksum output must be 1 to 32 bytes")
+	}
+	if len(key) > KeyLength {
+		panic("blake2s: key too large")
+	}
+
+	var d minimal.Digest
+	d.Init(key, len(out))
+	d.Write(data)
+	d.Sum(out)
+}
@@ -0,0 +1,53 @@
+package blake2s
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestChecksum(t *testing.T) {
+	data := make([]byte, 200)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	for _, key := range [][]byte{nil, []byte("key")} {
+		for _, size := range []int{1, 20, 32} {
+			expected, _ := NewDigest(key, nil, nil, size)
+			expected.Write(data)
+
+			out := make([]byte, size)
+			Checksum(out, key, data)
+			if !bytes.Equal(out, expected.Sum(nil)) {
+				t.Errorf("key %q, size %d: mismatch", key, size)
+			}
+		}
+	}
+}
+
+func TestChecksumAllocs(t *testing.T) {
+	var out [32]byte
+	key := []byte("key")
+	data := make([]byte, 1000)
+	if allocs := testing.AllocsPerRun(100, func() { Checksum(out[:], key, data) }); allocs != 0 {
+		t.Errorf("Checksum allocated %v times", allocs)
+	}
+}
+
+func TestChecksumPanics(t *testing.T) {
+	for _, test := range []struct {
+		out, key []byte
+	}{
+		{nil, nil},
+		{make([]byte, 33), nil},
+		{make([]byte, 32), make([]byte, 33)},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("no panic for %d-byte output, %d-byte key", len(test.out), len(test.key))
+				}
+			}()
+			Checksum(test.out, test.key, nil)
+		}()
+	}
+}
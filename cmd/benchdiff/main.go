@@ -0,0 +1,214 @@
+// Command benchdiff runs benchmarks and compares them with a stored
+// baseline, failing if any got slower than a threshold allows.
+//
+// Baselines are JSON files named after GOARCH in the baseline directory, so
+// each architecture is only compared with itself. A typical session:
+//
+//	benchdiff -update        # record a baseline on the main branch
+//	benchdiff                # compare a change against it
+//
+// Each benchmark is run -count times and the best run is kept, which is far
+// less noisy than the mean on a shared machine. Throughput (MB/s) is
+// compared when a benchmark reports it, and time per operation otherwise.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// A Result is the best measurement of one benchmark.
+type Result struct {
+	NsPerOp  float64 `json:"ns_per_op"`
+	MBPerSec float64 `json:"mb_per_s,omitempty"`
+}
+
+// A Baseline maps benchmark names, without the GOMAXPROCS suffix, to
+// results.
+type Baseline map[string]Result
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("benchdiff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dir := flags.String("dir", "testdata/bench", "directory holding the per-GOARCH baselines")
+	bench := flags.String("bench", ".", "benchmarks to run, as for go test -bench")
+	count := flags.Int("count", 5, "runs of each benchmark")
+	threshold := flags.Float64("threshold", 10, "allowed slowdown, in percent")
+	update := flags.Bool("update", false, "record the results as the new baseline")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	pkgs := flags.Args()
+	if len(pkgs) == 0 {
+		pkgs = []string{"."}
+	}
+
+	cmdArgs := append([]string{"test", "-run", "^$", "-bench", *bench, "-count", strconv.Itoa(*count)}, pkgs...)
+	cmd := exec.Command("go", cmdArgs...)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		fmt.Fprintf(stderr, "benchdiff: go test: %v\n%s", err, out)
+		return 2
+	}
+	current, err := parse(bytes.NewReader(out))
+	if err != nil {
+		fmt.Fprintln(stderr, "benchdiff:", err)
+		return 2
+	}
+	if len(current) == 0 {
+		fmt.Fprintln(stderr, "benchdiff: no benchmarks matched")
+		return 2
+	}
+
+	path := filepath.Join(*dir, runtime.GOARCH+".json")
+	if *update {
+		if err := save(path, current); err != nil {
+			fmt.Fprintln(stderr, "benchdiff:", err)
+			return 2
+		}
+		fmt.Fprintf(stdout, "recorded %d benchmarks in %s\n", len(current), path)
+		return 0
+	}
+
+	baseline, err := load(path)
+	if err != nil {
+		fmt.Fprintln(stderr, "benchdiff:", err)
+		return 2
+	}
+	report, regressed := compare(baseline, current, *threshold)
+	fmt.Fprint(stdout, report)
+	if regressed {
+		return 1
+	}
+	return 0
+}
+
+// parse reads go test -bench output, keeping the best of repeated runs.
+func parse(r io.Reader) (Baseline, error) {
+	results := make(Baseline)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		name := fields[0]
+		if i := strings.LastIndexByte(name, '-'); i > 0 {
+			if _, err := strconv.Atoi(name[i+1:]); err == nil {
+				name = name[:i]
+			}
+		}
+		var res Result
+		// After the iteration count come value/unit pairs.
+		for i := 2; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad value %q for %s", fields[i], name)
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				res.NsPerOp = v
+			case "MB/s":
+				res.MBPerSec = v
+			}
+		}
+		if res.NsPerOp == 0 {
+			continue
+		}
+		if prev, ok := results[name]; !ok || better(res, prev) {
+			results[name] = res
+		}
+	}
+	return results, scanner.Err()
+}
+
+func better(a, b Result) bool {
+	if a.MBPerSec != 0 || b.MBPerSec != 0 {
+		return a.MBPerSec > b.MBPerSec
+	}
+	return a.NsPerOp < b.NsPerOp
+}
+
+// compare reports the change in every benchmark present in both sets, and
+// whether any slowed down by more than threshold percent.
+func compare(baseline, current Baseline, threshold float64) (string, bool) {
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	regressed := false
+	for _, name := range names {
+		cur := current[name]
+		base, ok := baseline[name]
+		if !ok {
+			fmt.Fprintf(&b, "%-40s %12s  (new)\n", name, format(cur))
+			continue
+		}
+		// Positive change means slower.
+		var change float64
+		if base.MBPerSec != 0 && cur.MBPerSec != 0 {
+			change = (base.MBPerSec/cur.MBPerSec - 1) * 100
+		} else {
+			change = (cur.NsPerOp/base.NsPerOp - 1) * 100
+		}
+		verdict := fmt.Sprintf("%5.1f%% slower", change)
+		if change < 0 {
+			verdict = fmt.Sprintf("%5.1f%% faster", -change)
+		}
+		if change > threshold {
+			verdict += "  REGRESSION"
+			regressed = true
+		}
+		fmt.Fprintf(&b, "%-40s %12s -> %12s  %s\n", name, format(base), format(cur), verdict)
+	}
+	return b.String(), regressed
+}
+
+func format(r Result) string {
+	if r.MBPerSec != 0 {
+		return fmt.Sprintf("%.2f MB/s", r.MBPerSec)
+	}
+	return fmt.Sprintf("%.1f ns/op", r.NsPerOp)
+}
+
+func load(path string) (Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return b, nil
+}
+
+func save(path string, b Baseline) error {
+	data, err := json.MarshalIndent(b, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
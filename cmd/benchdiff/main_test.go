@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const output = `goos: linux
+goarch: amd64
+pkg: github.com/gtank/blake2s
+BenchmarkHash8Bytes-8   	 5000000	       250.0 ns/op	  32.00 MB/s
+BenchmarkHash8Bytes-8   	 5000000	       240.0 ns/op	  33.33 MB/s
+BenchmarkHash1K-8       	  300000	      4000 ns/op	 256.00 MB/s
+BenchmarkNoBytes-8      	 1000000	      1000 ns/op	       0 B/op	       0 allocs/op
+BenchmarkNoBytes-8      	 1000000	      1100 ns/op
+PASS
+ok  	github.com/gtank/blake2s	5.000s
+`
+
+func TestParse(t *testing.T) {
+	got, err := parse(strings.NewReader(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Baseline{
+		"BenchmarkHash8Bytes": {NsPerOp: 240, MBPerSec: 33.33},
+		"BenchmarkHash1K":     {NsPerOp: 4000, MBPerSec: 256},
+		"BenchmarkNoBytes":    {NsPerOp: 1000},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := Baseline{
+		"BenchmarkA": {NsPerOp: 100, MBPerSec: 100},
+		"BenchmarkB": {NsPerOp: 100},
+	}
+	report, regressed := compare(base, Baseline{
+		"BenchmarkA": {NsPerOp: 105, MBPerSec: 95},
+		"BenchmarkB": {NsPerOp: 90},
+		"BenchmarkC": {NsPerOp: 10},
+	}, 10)
+	if regressed {
+		t.Errorf("small changes reported as a regression:\n%s", report)
+	}
+	if !strings.Contains(report, "BenchmarkC") || !strings.Contains(report, "(new)") {
+		t.Errorf("new benchmark not reported:\n%s", report)
+	}
+
+	report, regressed = compare(base, Baseline{"BenchmarkA": {NsPerOp: 150, MBPerSec: 66}}, 10)
+	if !regressed || !strings.Contains(report, "REGRESSION") {
+		t.Errorf("throughput drop not reported:\n%s", report)
+	}
+	_, regressed = compare(base, Baseline{"BenchmarkB": {NsPerOp: 120}}, 10)
+	if !regressed {
+		t.Error("time per op increase not reported")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "amd64.json")
+	b := Baseline{"BenchmarkA": {NsPerOp: 1.5, MBPerSec: 2}}
+	if err := save(path, b); err != nil {
+		t.Fatal(err)
+	}
+	got, err := load(path)
+	if err != nil || !reflect.DeepEqual(got, b) {
+		t.Errorf("got %v, %v", got, err)
+	}
+}
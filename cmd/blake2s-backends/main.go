@@ -0,0 +1,39 @@
+// Command blake2s-backends cross-checks every BLAKE2s compression backend
+// available on this machine, including the built-in SIMD ones, against the
+// portable Go code and reports their throughput. It exits with status 1 if
+// any backend disagrees.
+//
+// Out of tree backends register themselves from init functions, so import
+// them here as they are added to be covered.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gtank/blake2s"
+)
+
+func main() {
+	trials := flag.Int("trials", 10000, "random inputs per backend")
+	seed := flag.Int64("seed", 1, "seed for the random inputs")
+	size := flag.Int("size", 64<<20, "bytes to compress when measuring throughput")
+	flag.Parse()
+
+	failed := false
+	for _, r := range blake2s.CheckBackends(*trials, *seed, *size) {
+		switch {
+		case !r.Available:
+			fmt.Printf("%-12s unavailable\n", r.Name)
+		case r.Err != nil:
+			fmt.Printf("%-12s FAIL %v\n", r.Name, r.Err)
+			failed = true
+		default:
+			fmt.Printf("%-12s ok   %8.2f MB/s\n", r.Name, r.MBPerSec)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden file with the current output")
+
+func TestGenerate(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "personas_gen.go")
+	var stderr bytes.Buffer
+	args := []string{"-package", "example", "-o", out, "Manifest=manifst1", "seal/v1"}
+	if code := run(args, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const golden = "testdata/personas_gen.golden"
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\n%s", golden, got)
+	}
+}
+
+func TestBadDomains(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"Manifest="},
+		{"Manifest=nine-byte"},
+		{"manifest=manifst1"},
+		{"Bad Name=x"},
+		{"A=x", "A=y"},
+		{"A=x", "B=x"},
+		{"A=x", "!!"},
+	} {
+		var stderr bytes.Buffer
+		out := filepath.Join(t.TempDir(), "out.go")
+		if code := run(append([]string{"-package", "p", "-o", out}, args...), &stderr); code != 1 {
+			t.Errorf("%q: exit %d", args, code)
+		}
+		if !strings.HasPrefix(stderr.String(), "blake2s-personagen: ") {
+			t.Errorf("%q: stderr %q", args, stderr.String())
+		}
+	}
+
+	var stderr bytes.Buffer
+	t.Setenv("GOPACKAGE", "")
+	if code := run([]string{"-o", filepath.Join(t.TempDir(), "out.go"), "A=x"}, &stderr); code != 1 {
+		t.Errorf("missing package: exit %d", code)
+	}
+}
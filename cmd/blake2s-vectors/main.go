@@ -0,0 +1,90 @@
+// Command blake2s-vectors answers BLAKE2s hash requests on stdin, one per
+// line, so that other implementations' test suites can check themselves
+// against this one.
+//
+// A request is a line of space-separated name=value fields, all values in
+// hex except size:
+//
+//	size=32 key=000102 salt= persona=6162 in=616263
+//
+// Every field is optional; size defaults to 32 and the others to empty. Each
+// request gets one line in reply, either "ok <hex digest>" or "err
+// <message>". Blank lines and lines starting with # are ignored without a
+// reply. Output is flushed after each reply, so the command can be driven
+// interactively over a pipe.
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gtank/blake2s"
+)
+
+func main() {
+	if err := serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func serve(r io.Reader, w io.Writer) error {
+	out := bufio.NewWriter(w)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if sum, err := answer(line); err != nil {
+			fmt.Fprintf(out, "err %v\n", err)
+		} else {
+			fmt.Fprintf(out, "ok %x\n", sum)
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func answer(line string) ([]byte, error) {
+	size := blake2s.MaxOutput
+	var key, salt, persona, in []byte
+	for _, field := range strings.Fields(line) {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("field %q has no value", field)
+		}
+		var err error
+		switch name {
+		case "size":
+			size, err = strconv.Atoi(value)
+		case "key":
+			key, err = hex.DecodeString(value)
+		case "salt":
+			salt, err = hex.DecodeString(value)
+		case "persona":
+			persona, err = hex.DecodeString(value)
+		case "in":
+			in, err = hex.DecodeString(value)
+		default:
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	d, err := blake2s.NewDigest(key, salt, persona, size)
+	if err != nil {
+		return nil, err
+	}
+	d.Write(in)
+	return d.Sum(nil), nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	// Expected digests come from Python's hashlib.
+	input := strings.Join([]string{
+		"# comment",
+		"in=616263",
+		"",
+		"size=16 key=000102 salt=6162 persona=6364 in=616263",
+		"size=0",
+		"in=zz",
+		"colour=blue",
+		"in",
+	}, "\n")
+	expected := strings.Join([]string{
+		"ok 508c5e8c327c14e2e1a72ba34eeb452f37458b209ed63a294d999b4c86675982",
+		"ok 28508ba98ee7985a2c3cdf0f24fbfe83",
+		"err blake2s: asked for negative or zero output",
+		"err in: encoding/hex: invalid byte: U+007A 'z'",
+		`err unknown field "colour"`,
+		`err field "in" has no value`,
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	if err := serve(strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != expected {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), expected)
+	}
+}
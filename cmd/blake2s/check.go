@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/gtank/blake2s/manifest"
+)
+
+// checkFiles reads each checksum list in lists, rehashes the files it names
+// with the parameters p and reports each as OK or FAILED, the way
+// sha256sum -c does. Untagged lines are digests by the named algorithm. With
+// percent below 100, only the files manifest.Sample chooses are checked. Up
+// to jobs files are hashed at once, with the report in list order. It
+// returns the exit status: 1 if any list or file could not be read or any
+// digest differs.
+func checkFiles(lists []string, algorithm string, p *params, percent float64, seed int64, jobs int, stdout, stderr io.Writer) int {
+	status := 0
+	var mismatched, unreadable int
+	for _, list := range lists {
+		m, err := readList(list, algorithm)
+		if err != nil {
+			fmt.Fprintln(stderr, "blake2s:", err)
+			status = 1
+			continue
+		}
+		if len(m.Entries) == 0 {
+			fmt.Fprintf(stderr, "blake2s: %s: no checksum lines found\n", list)
+			status = 1
+			continue
+		}
+
+		var entries []manifest.Entry
+		for i, ok := range manifest.Sample(len(m.Entries), percent, seed) {
+			if ok {
+				entries = append(entries, m.Entries[i])
+			}
+		}
+		results := ordered(len(entries), jobs, func(i int) hashed {
+			e := entries[i]
+			a := e.Algorithm
+			if a == "" {
+				a = manifest.DefaultAlgorithm
+			}
+			sum, err := hashFile(e.Path, func() (hash.Hash, error) { return p.newHash(a, len(e.Digest)) }, nil)
+			return hashed{sum: sum, err: err}
+		})
+		for i, r := range results {
+			e := entries[i]
+			switch {
+			case r.err != nil:
+				fmt.Fprintln(stderr, "blake2s:", r.err)
+				fmt.Fprintf(stdout, "%s: FAILED open or read\n", e.Path)
+				unreadable++
+			case subtle.ConstantTimeCompare(r.sum, e.Digest) != 1:
+				fmt.Fprintf(stdout, "%s: FAILED\n", e.Path)
+				mismatched++
+			default:
+				fmt.Fprintf(stdout, "%s: OK\n", e.Path)
+			}
+		}
+	}
+
+	if unreadable > 0 {
+		fmt.Fprintf(stderr, "blake2s: WARNING: %d listed %s could not be read\n", unreadable, plural(unreadable, "file", "files"))
+		status = 1
+	}
+	if mismatched > 0 {
+		fmt.Fprintf(stderr, "blake2s: WARNING: %d computed %s did NOT match\n", mismatched, plural(mismatched, "checksum", "checksums"))
+		status = 1
+	}
+	return status
+}
+
+func readList(name, algorithm string) (*manifest.Manifest, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := manifest.ParseAlgorithm(f, algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return m, nil
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+)
+
+// A jsonFile is one element of the array -json prints. The digest is under
+// the name of the algorithm that produced it.
+type jsonFile struct {
+	File    string     `json:"file"`
+	Size    int64      `json:"size"`
+	BLAKE2s string     `json:"blake2s,omitempty"`
+	BLAKE2b string     `json:"blake2b,omitempty"`
+	Params  jsonParams `json:"params"`
+}
+
+// jsonParams are the hash parameters that were set. The key itself is never
+// printed, only whether there was one.
+type jsonParams struct {
+	Keyed    bool   `json:"keyed,omitempty"`
+	Salt     string `json:"salt,omitempty"`
+	Personal string `json:"personal,omitempty"`
+	Length   int    `json:"length,omitempty"` // in bits, if not the default
+}
+
+func newJSONFile(path string, size int64, algorithm string, sum []byte, p *params, length int) jsonFile {
+	f := jsonFile{
+		File: path,
+		Size: size,
+		Params: jsonParams{
+			Keyed:    len(p.key) > 0,
+			Salt:     hex.EncodeToString(p.salt),
+			Personal: hex.EncodeToString(p.personal),
+			Length:   length,
+		},
+	}
+	switch algorithm {
+	case "BLAKE2s":
+		f.BLAKE2s = hex.EncodeToString(sum)
+	case "BLAKE2b":
+		f.BLAKE2b = hex.EncodeToString(sum)
+	}
+	return f
+}
+
+// writeJSON prints files as an indented JSON array, which is empty rather
+// than null when no file could be hashed.
+func writeJSON(w io.Writer, files []jsonFile) error {
+	if files == nil {
+		files = []jsonFile{}
+	}
+	b, err := json.MarshalIndent(files, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
@@ -1,38 +1,260 @@
 package main
 
 import (
+	"encoding/hex"
+	"flag"
 	"fmt"
+	"hash"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/gtank/blake2s"
+	"github.com/gtank/blake2s/binhash"
+	"github.com/gtank/blake2s/blake2b"
+	"github.com/gtank/blake2s/cache"
+	"github.com/gtank/blake2s/manifest"
 )
 
 func main() {
-	if len(os.Args) != 2 {
-		os.Exit(1)
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run is the whole command, minus the process. It returns the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "inspect" {
+		return inspect(args[1:], stdout, stderr)
 	}
 
-	f, err := os.Open(os.ExpandEnv(os.Args[1]))
-	if err != nil {
-		os.Exit(1)
+	flags := flag.NewFlagSet("blake2s", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	cachePath := flags.String("cache", "", "reuse digests of unchanged files recorded in this cache file")
+	algorithm := flags.String("algorithm", "2s", "hash algorithm: 2s for BLAKE2s or 2b for BLAKE2b")
+	self := flags.Bool("self", false, "print the reproducible-build fingerprint of this program and exit")
+	var check bool
+	flags.BoolVar(&check, "c", false, "read checksums from the files and check them")
+	flags.BoolVar(&check, "check", false, "same as -c")
+	sample := flags.Float64("sample", 100, "with -c, check only about this percentage of the listed files")
+	seed := flags.Int64("seed", 0, "with -sample, the seed choosing the files; vary it between runs to cover them all")
+	p := &params{}
+	flags.Func("key", "key for keyed hashing, as a string or hex:<digits>", bytesFlag(&p.key))
+	flags.Func("salt", "salt, as a string or hex:<digits>", bytesFlag(&p.salt))
+	flags.Func("personal", "personalization, as a string or hex:<digits>", bytesFlag(&p.personal))
+	var recursive bool
+	flags.BoolVar(&recursive, "r", false, "hash every regular file under directory arguments, in sorted order")
+	flags.BoolVar(&recursive, "recursive", false, "same as -r")
+	follow := flags.Bool("follow", false, "with -r, follow symbolic links instead of skipping them")
+	tag := flags.Bool("tag", false, "print BSD-style \"BLAKE2s (file) = <hex>\" lines, as b2sum --tag does")
+	jsonOut := flags.Bool("json", false, "print a JSON array of {file, size, blake2s, params} objects")
+	jobs := flags.Int("j", 1, "hash up to this many files at once; output stays in argument order")
+	length := flags.Int("length", 0, "digest length in bits, a multiple of 8 (default the algorithm's maximum); ignored by -c")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	if *self {
+		sum, err := binhash.Self()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "%x\n", sum)
+		return 0
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(stderr, "usage: blake2s [flags] file...")
+		return 1
 	}
-	defer f.Close()
 
-	d, err := blake2s.NewDigest([]byte{0x0}, nil, nil, 32)
-	if err != nil {
-		os.Exit(1)
+	if *jsonOut && (check || *tag) {
+		fmt.Fprintln(stderr, "blake2s: -json can't be used with -c or -tag")
+		return 1
+	}
+	if *jobs < 1 {
+		fmt.Fprintln(stderr, "blake2s: -j must be at least 1")
+		return 1
+	}
+
+	name, ok := algorithms[*algorithm]
+	if !ok {
+		fmt.Fprintf(stderr, "blake2s: unknown algorithm %q\n", *algorithm)
+		return 1
+	}
+	a, _ := manifest.LookupAlgorithm(name)
+	size := a.MaxSize
+	if *length != 0 {
+		if *length%8 != 0 || *length < 0 || *length > 8*a.MaxSize {
+			fmt.Fprintf(stderr, "blake2s: -length must be a multiple of 8 from 8 to %d\n", 8*a.MaxSize)
+			return 1
+		}
+		size = *length / 8
+	}
+	newHash := func() (hash.Hash, error) { return p.newHash(name, size) }
+	// Reject bad parameters once, rather than for every file.
+	if _, err := newHash(); err != nil {
+		// The package errors already carry an algorithm prefix.
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if check {
+		return checkFiles(flags.Args(), name, p, *sample, *seed, *jobs, stdout, stderr)
+	}
+
+	var c *cache.Cache
+	if *cachePath != "" {
+		var err error
+		c, err = cache.Open(*cachePath)
+		if err != nil {
+			fmt.Fprintln(stderr, "blake2s:", err)
+			return 1
+		}
+	}
+
+	// Like other *sum tools, report each file that can't be hashed and
+	// carry on with the rest.
+	status := 0
+	var paths []string
+	for _, arg := range flags.Args() {
+		path := os.ExpandEnv(arg)
+		if recursive {
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				paths = append(paths, walk(path, *follow, func(err error) {
+					fmt.Fprintln(stderr, "blake2s:", err)
+					status = 1
+				})...)
+				continue
+			}
+		}
+		paths = append(paths, path)
+	}
+	results := ordered(len(paths), *jobs, func(i int) hashed {
+		sum, err := hashFile(paths[i], newHash, c)
+		if err != nil || !*jsonOut {
+			return hashed{sum: sum, err: err}
+		}
+		info, err := os.Stat(paths[i])
+		if err != nil {
+			return hashed{err: err}
+		}
+		return hashed{sum: sum, size: info.Size()}
+	})
+	var files []jsonFile
+	for i, r := range results {
+		if r.err != nil {
+			fmt.Fprintln(stderr, "blake2s:", r.err)
+			status = 1
+			continue
+		}
+		if *jsonOut {
+			files = append(files, newJSONFile(paths[i], r.size, name, r.sum, p, *length))
+			continue
+		}
+		e := manifest.Entry{Path: paths[i], Digest: r.sum}
+		if *tag {
+			e.Algorithm = name
+		}
+		if _, err := fmt.Fprintln(stdout, manifest.FormatLine(e)); err != nil {
+			status = 1
+			break
+		}
+	}
+
+	if *jsonOut {
+		if err := writeJSON(stdout, files); err != nil {
+			status = 1
+		}
+	}
+
+	if c != nil {
+		if err := c.Close(); err != nil {
+			fmt.Fprintln(stderr, "blake2s:", err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// algorithms maps -algorithm values to manifest algorithm names.
+var algorithms = map[string]string{
+	"2s": "BLAKE2s",
+	"2b": "BLAKE2b",
+}
+
+// params are the hash parameters shared by every file.
+type params struct {
+	key, salt, personal []byte
+}
+
+// newHash returns a hash by the named algorithm producing size bytes.
+func (p *params) newHash(algorithm string, size int) (hash.Hash, error) {
+	switch algorithm {
+	case "BLAKE2s":
+		return blake2s.NewDigest(p.key, p.salt, p.personal, size)
+	case "BLAKE2b":
+		return blake2b.NewDigest(p.key, p.salt, p.personal, size)
+	}
+	return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+}
+
+// bytesFlag returns a flag parser storing its value in b: the bytes of the
+// string, or with a "hex:" prefix, the hex digits decoded.
+func bytesFlag(b *[]byte) func(string) error {
+	return func(s string) error {
+		if digits, ok := strings.CutPrefix(s, "hex:"); ok {
+			v, err := hex.DecodeString(digits)
+			if err != nil {
+				return err
+			}
+			*b = v
+			return nil
+		}
+		*b = []byte(s)
+		return nil
 	}
+}
 
-	_, err = io.Copy(d, f)
+// hashFile returns the digest of the named file under a fresh hash from
+// newHash, through c if it is not nil.
+func hashFile(name string, newHash func() (hash.Hash, error), c *cache.Cache) ([]byte, error) {
+	d, err := newHash()
 	if err != nil {
-		os.Exit(1)
+		return nil, err
+	}
+	if c != nil {
+		return c.HashFile(name, d)
 	}
 
-	_, err = fmt.Fprintf(os.Stdout, "%x", d.Sum(nil))
+	f, err := os.Open(name)
 	if err != nil {
-		os.Exit(1)
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := io.Copy(d, f); err != nil {
+		return nil, err
 	}
+	return d.Sum(nil), nil
+}
 
-	return
+// inspect decodes a hex parameter block given as its only argument, prints
+// every field to stdout and any warnings to stderr.
+func inspect(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(stderr, "usage: blake2s inspect <64 hex digits>")
+		return 1
+	}
+	block, err := hex.DecodeString(args[0])
+	if err != nil {
+		fmt.Fprintln(stderr, "blake2s:", err)
+		return 1
+	}
+	report, warnings, err := blake2s.InspectParameterBlock(block)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprint(stdout, report)
+	for _, w := range warnings {
+		fmt.Fprintln(stderr, "warning:", w)
+	}
+	return 0
 }
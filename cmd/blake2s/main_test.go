@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gtank/blake2s/binhash"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+// Each case runs the command with args against the fixture tree in
+// testdata/tree and compares its exit status and output with
+// testdata/golden/<name>.golden. Run with -update after an intended
+// change to the output formats and review the diff.
+var goldenCases = []struct {
+	name string
+	args []string
+}{
+	{"file", []string{"testdata/tree/a.txt"}},
+	{"empty-file", []string{"testdata/tree/empty"}},
+	{"subdir-file", []string{"testdata/tree/dir/b.txt"}},
+	{"missing-file", []string{"testdata/tree/missing"}},
+	{"no-args", nil},
+	{"multiple-files", []string{"testdata/tree/a.txt", "testdata/tree/empty", "testdata/tree/dir/b.txt"}},
+	{"multiple-files-missing", []string{"testdata/tree/a.txt", "testdata/tree/missing", "testdata/tree/empty"}},
+	{"unknown-flag", []string{"-bogus", "testdata/tree/a.txt"}},
+	{"cache", []string{"-cache", "$CACHE", "testdata/tree/a.txt"}},
+	{"algorithm-2s", []string{"-algorithm", "2s", "testdata/tree/a.txt"}},
+	{"algorithm-2b", []string{"-algorithm", "2b", "testdata/tree/a.txt"}},
+	{"algorithm-2b-cache", []string{"-algorithm", "2b", "-cache", "$CACHE", "testdata/tree/a.txt"}},
+	{"algorithm-unknown", []string{"-algorithm", "md5", "testdata/tree/a.txt"}},
+	{"check", []string{"-c", "testdata/sums/good.sums"}},
+	{"check-long-flag", []string{"--check", "testdata/sums/good.sums"}},
+	{"check-failures", []string{"-c", "testdata/sums/bad.sums"}},
+	{"check-several-lists", []string{"-c", "testdata/sums/good.sums", "testdata/sums/missing.sums", "testdata/sums/malformed.sums"}},
+	{"check-2b", []string{"-c", "-algorithm", "2b", "testdata/sums/good-2b.sums"}},
+	{"check-2b-as-2s", []string{"-c", "testdata/sums/good-2b.sums"}},
+	{"check-sample", []string{"-c", "-sample", "50", "-seed", "7", "testdata/sums/good.sums"}},
+	{"key", []string{"-key", "secret", "testdata/tree/a.txt"}},
+	{"key-hex", []string{"-key", "hex:00", "testdata/tree/a.txt"}},
+	{"key-bad-hex", []string{"-key", "hex:zz", "testdata/tree/a.txt"}},
+	{"key-too-long", []string{"-key", "0123456789abcdef0123456789abcdef!", "testdata/tree/a.txt"}},
+	{"salt-personal", []string{"-salt", "hex:0102", "-personal", "me", "testdata/tree/a.txt"}},
+	{"length", []string{"-length", "128", "testdata/tree/a.txt"}},
+	{"length-2b", []string{"-algorithm", "2b", "-length", "512", "testdata/tree/a.txt"}},
+	{"length-bad", []string{"-length", "12", "testdata/tree/a.txt"}},
+	{"all-params", []string{"-key", "secret", "-salt", "hex:0102", "-personal", "me", "-length", "128", "testdata/tree/a.txt"}},
+	{"check-keyed", []string{"-c", "-key", "secret", "testdata/sums/good.sums"}},
+	{"tag", []string{"--tag", "testdata/tree/a.txt", "testdata/tree/empty"}},
+	{"tag-length", []string{"--tag", "-length", "128", "testdata/tree/a.txt"}},
+	{"tag-2b", []string{"--tag", "-algorithm", "2b", "testdata/tree/a.txt"}},
+	{"check-tagged", []string{"-c", "testdata/sums/tagged.sums"}},
+	{"directory", []string{"testdata/tree"}},
+	{"recursive", []string{"-r", "testdata/tree"}},
+	{"recursive-long-flag", []string{"--recursive", "testdata/tree/dir", "testdata/tree/a.txt"}},
+	{"recursive-skip-links", []string{"-r", "testdata/links"}},
+	{"recursive-follow-links", []string{"-r", "-follow", "testdata/links"}},
+	{"jobs", []string{"-j", "4", "-r", "testdata/tree", "testdata/tree/missing", "testdata/links/c.txt"}},
+	{"jobs-check", []string{"-j", "3", "-c", "testdata/sums/good.sums", "testdata/sums/bad.sums"}},
+	{"jobs-zero", []string{"-j", "0", "testdata/tree/a.txt"}},
+	{"json", []string{"-json", "testdata/tree/a.txt", "testdata/tree/missing", "testdata/tree/empty"}},
+	{"json-params", []string{"--json", "-key", "secret", "-salt", "hex:0102", "-personal", "me", "-length", "128", "testdata/tree/a.txt"}},
+	{"json-2b", []string{"-json", "-algorithm", "2b", "testdata/tree/dir/b.txt"}},
+	{"json-none", []string{"-json", "testdata/tree/missing"}},
+	{"json-check", []string{"-json", "-c", "testdata/sums/good.sums"}},
+	{"inspect", []string{"inspect", "2000010100000000000000000000000000000000000000006c6f677365616c31"}},
+	{"inspect-warnings", []string{"inspect", "0821020000000000000000000000000000000000000000000000000000000000"}},
+	{"inspect-bad-hex", []string{"inspect", "zz"}},
+	{"inspect-short", []string{"inspect", "2000"}},
+}
+
+func render(code int, stdout, stderr string) string {
+	return fmt.Sprintf("exit: %d\n-- stdout --\n%s\n-- stderr --\n%s", code, stdout, stderr)
+}
+
+func TestGolden(t *testing.T) {
+	for _, tc := range goldenCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := filepath.Join(t.TempDir(), "cache")
+			args := make([]string, len(tc.args))
+			for i, arg := range tc.args {
+				args[i] = strings.ReplaceAll(arg, "$CACHE", cache)
+			}
+
+			var stdout, stderr bytes.Buffer
+			code := run(args, &stdout, &stderr)
+			got := render(code, stdout.String(), stderr.String())
+
+			golden := filepath.Join("testdata", "golden", tc.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("output differs from %s\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+// The fingerprint depends on the binary, so it can't have a golden file.
+func TestSelf(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-self"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	want, err := binhash.Self()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != fmt.Sprintf("%x\n", want) {
+		t.Errorf("printed %q", stdout.String())
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import "iter"
+
+// A hashed is the outcome of hashing one file.
+type hashed struct {
+	sum  []byte
+	size int64 // only set for -json
+	err  error
+}
+
+// ordered runs work on items 0 to n-1, at most jobs at a time, and yields
+// each index and result in index order, so output doesn't depend on which
+// file finishes first. No more than jobs results are computed ahead of the
+// consumer, and stopping the iteration early stops starting new work.
+func ordered[R any](n, jobs int, work func(i int) R) iter.Seq2[int, R] {
+	return func(yield func(int, R) bool) {
+		pending := make(chan chan R, jobs)
+		running := make(chan struct{}, jobs)
+		done := make(chan struct{})
+		defer close(done)
+
+		go func() {
+			defer close(pending)
+			for i := 0; i < n; i++ {
+				c := make(chan R, 1)
+				select {
+				case pending <- c:
+				case <-done:
+					return
+				}
+				select {
+				case running <- struct{}{}:
+				case <-done:
+					return
+				}
+				go func() {
+					c <- work(i)
+					<-running
+				}()
+			}
+		}()
+
+		i := 0
+		for c := range pending {
+			if !yield(i, <-c) {
+				return
+			}
+			i++
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOrdered(t *testing.T) {
+	const n, jobs = 50, 4
+	var running, most atomic.Int32
+	work := func(i int) int {
+		r := running.Add(1)
+		for {
+			m := most.Load()
+			if r <= m || most.CompareAndSwap(m, r) {
+				break
+			}
+		}
+		// Later items finish first, to shake out any ordering by completion.
+		time.Sleep(time.Duration(n-i) * 50 * time.Microsecond)
+		running.Add(-1)
+		return i * i
+	}
+
+	next := 0
+	for i, r := range ordered(n, jobs, work) {
+		if i != next || r != i*i {
+			t.Fatalf("got result %d for item %d, want item %d", r, i, next)
+		}
+		next++
+	}
+	if next != n {
+		t.Errorf("got %d results, want %d", next, n)
+	}
+	if m := most.Load(); m > jobs {
+		t.Errorf("%d items ran at once, want at most %d", m, jobs)
+	}
+
+	// Stopping early must not hang or keep going.
+	for i := range ordered(n, jobs, work) {
+		if i == 2 {
+			break
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// walk returns the regular files in the tree under the directory root,
+// sorted so the output is the same from run to run. Symbolic links are
+// skipped unless follow is set; a followed link back to a directory being
+// walked is reported rather than descended into. Errors are passed to report
+// and the rest of the tree is still walked.
+func walk(root string, follow bool, report func(error)) []string {
+	var files []string
+	var visit func(dir string, ancestors []os.FileInfo)
+	visit = func(dir string, ancestors []os.FileInfo) {
+		// ReadDir returns the entries it read before any error.
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			report(err)
+		}
+		for _, e := range entries {
+			path := filepath.Join(dir, e.Name())
+			mode := e.Type()
+			if mode&fs.ModeSymlink != 0 {
+				if !follow {
+					continue
+				}
+				info, err := os.Stat(path)
+				if err != nil {
+					report(err)
+					continue
+				}
+				mode = info.Mode().Type()
+			}
+			switch {
+			case mode.IsRegular():
+				files = append(files, path)
+			case mode.IsDir():
+				info, err := os.Stat(path)
+				if err != nil {
+					report(err)
+					continue
+				}
+				if loops(info, ancestors) {
+					report(fmt.Errorf("%s: directory loop, not followed", path))
+					continue
+				}
+				visit(path, append(ancestors, info))
+			}
+		}
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		report(err)
+		return nil
+	}
+	visit(root, []os.FileInfo{info})
+	sort.Strings(files)
+	return files
+}
+
+func loops(dir os.FileInfo, ancestors []os.FileInfo) bool {
+	for _, a := range ancestors {
+		if os.SameFile(dir, a) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+// Command dedupe-dir finds files with identical contents under a directory
+// and prints each group of duplicates under its BLAKE2s digest:
+//
+//	dedupe-dir [-min-size n] <dir>
+//
+// Files are hashed concurrently and streamed through batch.Files, so the
+// directory can be arbitrarily large. Files smaller than -min-size bytes are
+// ignored. The exit status is 0 if there are no duplicates, 1 if there are,
+// and 2 on error.
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/gtank/blake2s"
+	"github.com/gtank/blake2s/batch"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("dedupe-dir", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	minSize := flags.Int64("min-size", 1, "ignore files smaller than this many bytes")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: dedupe-dir [-min-size n] <dir>")
+		return 2
+	}
+
+	groups, err := duplicates(os.DirFS(flags.Arg(0)), *minSize)
+	if err != nil {
+		fmt.Fprintln(stderr, "dedupe-dir:", err)
+		return 2
+	}
+	for _, g := range groups {
+		fmt.Fprintln(stdout, g.digest)
+		for _, name := range g.names {
+			fmt.Fprintf(stdout, "  %s\n", name)
+		}
+	}
+	if len(groups) > 0 {
+		return 1
+	}
+	return 0
+}
+
+type group struct {
+	digest string
+	names  []string
+}
+
+// duplicates returns the groups of two or more files with the same digest,
+// ordered by their first name.
+func duplicates(fsys fs.FS, minSize int64) ([]group, error) {
+	var walkErr error
+	names := func(yield func(string) bool) {
+		walkErr = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if info.Size() < minSize {
+				return nil
+			}
+			if !yield(path) {
+				return fs.SkipAll
+			}
+			return nil
+		})
+	}
+
+	byDigest := make(map[string][]string)
+	for r := range batch.Files(fsys, names, blake2s.MaxOutput) {
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		key := hex.EncodeToString(r.Digest)
+		byDigest[key] = append(byDigest[key], r.Name)
+	}
+	if walkErr != nil {
+		return nil, walkErr
+	}
+
+	var groups []group
+	for digest, names := range byDigest {
+		if len(names) > 1 {
+			groups = append(groups, group{digest, names})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].names[0] < groups[j].names[0] })
+	return groups, nil
+}
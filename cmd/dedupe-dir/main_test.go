@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gtank/blake2s"
+)
+
+func TestDedupeDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt":       "same",
+		"b/copy.txt":  "same",
+		"b/c/again":   "same",
+		"unique.txt":  "different",
+		"x.bin":       "pair",
+		"z/x-too.bin": "pair",
+		"empty1":      "",
+		"empty2":      "",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sum := func(s string) string {
+		d, _ := blake2s.NewDigest(nil, nil, nil, blake2s.MaxOutput)
+		d.Write([]byte(s))
+		return fmt.Sprintf("%x", d.Sum(nil))
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	want := sum("same") + "\n  a.txt\n  b/c/again\n  b/copy.txt\n" +
+		sum("pair") + "\n  x.bin\n  z/x-too.bin\n"
+	if stdout.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", stdout.String(), want)
+	}
+
+	// Empty files are duplicates of each other once they count.
+	stdout.Reset()
+	run([]string{"-min-size", "0", dir}, &stdout, &stderr)
+	if !bytes.Contains(stdout.Bytes(), []byte(sum("")+"\n  empty1\n  empty2\n")) {
+		t.Errorf("empty files not grouped:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{filepath.Join(dir, "b", "c")}, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("no duplicates: exit %d, output %q", code, stdout.String())
+	}
+	if code := run([]string{filepath.Join(dir, "missing")}, &stdout, &stderr); code != 2 {
+		t.Errorf("missing directory: exit %d", code)
+	}
+}
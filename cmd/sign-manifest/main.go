@@ -0,0 +1,144 @@
+// Command sign-manifest writes and checks signed manifests of a directory.
+//
+//	sign-manifest -genkey <key file>
+//	sign-manifest -key <key file> -o <manifest> <dir>
+//	sign-manifest -verify -pub <hex public key> -o <manifest> <dir>
+//
+// -genkey writes a new Ed25519 private key seed, in hex, and prints the
+// public key. Signing writes the manifest and its signature, in hex, to
+// <manifest>.sig. Verification checks the signature first and then every
+// file the manifest lists.
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/gtank/blake2s/manifest"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("sign-manifest", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	genkey := flags.String("genkey", "", "write a new private key to this file and print the public key")
+	keyFile := flags.String("key", "", "private key file to sign with")
+	verify := flags.Bool("verify", false, "verify the manifest instead of writing it")
+	pub := flags.String("pub", "", "public key to verify with, in hex")
+	out := flags.String("o", "MANIFEST", "manifest file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var err error
+	switch {
+	case *genkey != "":
+		err = generateKey(*genkey, stdout)
+	case flags.NArg() != 1:
+		fmt.Fprintln(stderr, "usage: sign-manifest [-verify -pub <hex> | -key <file>] -o <manifest> <dir>")
+		return 2
+	case *verify:
+		err = verifyDir(flags.Arg(0), *out, *pub, stdout)
+	default:
+		err = signDir(flags.Arg(0), *out, *keyFile)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "sign-manifest:", err)
+		return 1
+	}
+	return 0
+}
+
+func generateKey(path string, stdout io.Writer) error {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(private.Seed())+"\n"), 0o600); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "%x\n", []byte(public))
+	return err
+}
+
+func signDir(dir, out, keyFile string) error {
+	if keyFile == "" {
+		return errors.New("-key is required to sign")
+	}
+	seedHex, err := os.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+	seed, err := hex.DecodeString(strings.TrimSpace(string(seedHex)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return errors.New("key file must hold a 32-byte hex seed")
+	}
+
+	m, err := manifest.Generate(os.DirFS(dir), ".")
+	if err != nil {
+		return err
+	}
+	sig, err := manifest.Sign(m, ed25519.NewKeyFromSeed(seed))
+	if err != nil {
+		return err
+	}
+	var text strings.Builder
+	if _, err := m.WriteTo(&text); err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, []byte(text.String()), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(out+".sig", []byte(hex.EncodeToString(sig)+"\n"), 0o644)
+}
+
+func verifyDir(dir, out, pubHex string, stdout io.Writer) error {
+	pub, err := hex.DecodeString(pubHex)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return errors.New("-pub must be a 32-byte hex public key")
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	m, err := manifest.Parse(f)
+	if err != nil {
+		return err
+	}
+	sigHex, err := os.ReadFile(out + ".sig")
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(strings.TrimSpace(string(sigHex)))
+	if err != nil {
+		return errors.New("malformed signature file")
+	}
+	if err := manifest.VerifySignature(m, ed25519.PublicKey(pub), sig); err != nil {
+		return err
+	}
+
+	result, err := manifest.VerifyParallel(context.Background(), os.DirFS(dir), m, runtime.GOMAXPROCS(0))
+	if err != nil {
+		return err
+	}
+	for _, r := range result.Files {
+		fmt.Fprintf(stdout, "%s: %s\n", r.Path, r.Status)
+	}
+	if !result.Passed() {
+		return result.FirstErr
+	}
+	return nil
+}
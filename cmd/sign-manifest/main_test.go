@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	work := t.TempDir()
+	dir := filepath.Join(work, "release")
+	os.MkdirAll(filepath.Join(dir, "docs"), 0o755)
+	os.WriteFile(filepath.Join(dir, "app"), []byte("binary"), 0o755)
+	os.WriteFile(filepath.Join(dir, "docs", "README"), []byte("read me"), 0o644)
+	key := filepath.Join(work, "key")
+	out := filepath.Join(work, "MANIFEST")
+
+	sh := func(args ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		code := run(args, &stdout, &stderr)
+		return code, stdout.String() + stderr.String()
+	}
+
+	code, pub := sh("-genkey", key)
+	if code != 0 {
+		t.Fatalf("genkey: %s", pub)
+	}
+	pub = strings.TrimSpace(pub)
+	if code, msg := sh("-key", key, "-o", out, dir); code != 0 {
+		t.Fatalf("sign: %s", msg)
+	}
+	code, msg := sh("-verify", "-pub", pub, "-o", out, dir)
+	if code != 0 || msg != "app: OK\ndocs/README: OK\n" {
+		t.Fatalf("verify: exit %d\n%s", code, msg)
+	}
+
+	// A modified file fails the file check.
+	os.WriteFile(filepath.Join(dir, "app"), []byte("trojan"), 0o755)
+	if code, msg := sh("-verify", "-pub", pub, "-o", out, dir); code != 1 || !strings.Contains(msg, "app: FAILED") {
+		t.Errorf("modified file: exit %d\n%s", code, msg)
+	}
+
+	// A manifest updated to match fails the signature check.
+	manifestText, _ := os.ReadFile(out)
+	lines := strings.Split(string(manifestText), "\n")
+	lines[0] = strings.Repeat("0", 64) + lines[0][64:]
+	os.WriteFile(out, []byte(strings.Join(lines, "\n")), 0o644)
+	if code, msg := sh("-verify", "-pub", pub, "-o", out, dir); code != 1 || !strings.Contains(msg, "bad signature") {
+		t.Errorf("modified manifest: exit %d\n%s", code, msg)
+	}
+
+	// So does another key.
+	other := filepath.Join(work, "other")
+	_, otherPub := sh("-genkey", other)
+	sh("-key", key, "-o", out, dir)
+	if code, msg := sh("-verify", "-pub", strings.TrimSpace(otherPub), "-o", out, dir); code != 1 || !strings.Contains(msg, "bad signature") {
+		t.Errorf("other key: exit %d\n%s", code, msg)
+	}
+
+	if code, _ := sh("-o", out, dir); code != 1 {
+		t.Error("signed without a key")
+	}
+	if code, _ := sh("-verify", "-pub", "zz", "-o", out, dir); code != 1 {
+		t.Error("accepted a malformed public key")
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gtank/blake2s"
+)
+
+func TestVerifyDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("release artifact "), 1<<16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "artifact", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	d, _ := blake2s.NewDigest(nil, nil, nil, blake2s.MaxOutput)
+	d.Write(content)
+	good := fmt.Sprintf("%x", d.Sum(nil))
+	short, _ := blake2s.NewDigest(nil, nil, nil, 16)
+	short.Write(content)
+	bad := strings.Repeat("00", 32)
+
+	out := filepath.Join(t.TempDir(), "artifact")
+	for _, tc := range []struct {
+		name string
+		args []string
+		code int
+		kept bool
+	}{
+		{"mismatch", []string{"-sum", bad, "-o", out, srv.URL}, 1, false},
+		{"too large", []string{"-sum", good, "-o", out, "-max-bytes", "1000", srv.URL}, 1, false},
+		{"verify only", []string{"-sum", fmt.Sprintf("%x", short.Sum(nil)), srv.URL}, 0, false},
+		{"saved", []string{"-sum", good, "-o", out, srv.URL}, 0, true},
+		{"no sum", []string{srv.URL}, 2, true},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(tc.args, &stdout, &stderr); code != tc.code {
+			t.Errorf("%s: exit %d, stderr %q", tc.name, code, stderr.String())
+		}
+		saved, err := os.ReadFile(out)
+		if tc.kept != (err == nil) || (tc.kept && !bytes.Equal(saved, content)) {
+			t.Errorf("%s: output file kept=%v, err %v", tc.name, tc.kept, err)
+		}
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(out), ".verify-download-*"))
+	if len(leftovers) != 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+}
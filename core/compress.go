@@ -0,0 +1,225 @@
+package core
+
+// BlockSize is the size of a BLAKE2s message block in bytes.
+const BlockSize = 64
+
+// The BLAKE2s initialization vector.
+const (
+	IV0 uint32 = 0x6a09e667
+	IV1 uint32 = 0xbb67ae85
+	IV2 uint32 = 0x3c6ef372
+	IV3 uint32 = 0xa54ff53a
+	IV4 uint32 = 0x510e527f
+	IV5 uint32 = 0x9b05688c
+	IV6 uint32 = 0x1f83d9ab
+	IV7 uint32 = 0x5be0cd19
+)
+
+// Block is the BLAKE2s compression function. It mixes one block into the
+// chaining value h, using the counter t0/t1 and flags f0/f1. It uses the
+// first available entry of Implementations, or BlockGeneric if there is
+// none; build with the purego tag to use only the portable Go code.
+func Block(h *[8]uint32, buf *[BlockSize]byte, t0, t1, f0, f1 uint32) {
+	block(h, buf, t0, t1, f0, f1)
+}
+
+// An Implementation is one of the SIMD compression functions for this
+// architecture.
+type Implementation struct {
+	Name      string
+	Available bool // whether this CPU supports it
+	Block     func(h *[8]uint32, buf *[BlockSize]byte, t0, t1, f0, f1 uint32)
+}
+
+// Implementations returns the SIMD compression functions built for this
+// architecture, fastest first, including ones this CPU can't run. It is
+// empty with the purego tag and on architectures without assembly.
+func Implementations() []Implementation {
+	return append([]Implementation(nil), implementations...)
+}
+
+// BlockGeneric is the portable Go compression function.
+func BlockGeneric(h *[8]uint32, buf *[BlockSize]byte, t0, t1, f0, f1 uint32) {
+
+	// Create the internal round state. Copy the current hash state to the top,
+	// then the tweaked IVs to the bottom. Use local variables to avoid
+	// allocating another slice.
+	v0, v1, v2, v3 := h[0], h[1], h[2], h[3]
+	v4, v5, v6, v7 := h[4], h[5], h[6], h[7]
+	v8, v9, v10, v11 := IV0, IV1, IV2, IV3
+	v12 := IV4 ^ t0
+	v13 := IV5 ^ t1
+	v14 := IV6 ^ f0
+	v15 := IV7 ^ f1
+
+	// This round structure is several steps removed from the spec and
+	// reference implementation. We unrolled the loops and calculated the
+	// offsets from the permutation table entry for each round, then directly
+	// mapped it to the correct word of the input block. This is a tradeoff:
+	// the doubly-indirect lookups were horrible for performance, but it's not
+	// at all obvious what this code is doing anymore.
+	//
+	// We also split the message buffer into 16x32-bit words (m0..m15) as late
+	// as possible before they're needed. The small decrease in liveness scope
+	// matters ever-so-slightly.
+
+	// Round 0 w/ precomputed permutation offsets
+	m0 := u32LE(buf[0*4 : 0*4+4])
+	m1 := u32LE(buf[1*4 : 1*4+4])
+	v0, v4, v8, v12 = g(v0+v4+m0, v4, v8, v12, m1)
+	m2 := u32LE(buf[2*4 : 2*4+4])
+	m3 := u32LE(buf[3*4 : 3*4+4])
+	v1, v5, v9, v13 = g(v1+v5+m2, v5, v9, v13, m3)
+	m4 := u32LE(buf[4*4 : 4*4+4])
+	m5 := u32LE(buf[5*4 : 5*4+4])
+	v2, v6, v10, v14 = g(v2+v6+m4, v6, v10, v14, m5)
+	m6 := u32LE(buf[6*4 : 6*4+4])
+	m7 := u32LE(buf[7*4 : 7*4+4])
+	v3, v7, v11, v15 = g(v3+v7+m6, v7, v11, v15, m7)
+
+	m8 := u32LE(buf[8*4 : 8*4+4])
+	m9 := u32LE(buf[9*4 : 9*4+4])
+	v0, v5, v10, v15 = g(v0+v5+m8, v5, v10, v15, m9)
+	m10 := u32LE(buf[10*4 : 10*4+4])
+	m11 := u32LE(buf[11*4 : 11*4+4])
+	v1, v6, v11, v12 = g(v1+v6+m10, v6, v11, v12, m11)
+	m12 := u32LE(buf[12*4 : 12*4+4])
+	m13 := u32LE(buf[13*4 : 13*4+4])
+	v2, v7, v8, v13 = g(v2+v7+m12, v7, v8, v13, m13)
+	m14 := u32LE(buf[14*4 : 14*4+4])
+	m15 := u32LE(buf[15*4 : 15*4+4])
+	v3, v4, v9, v14 = g(v3+v4+m14, v4, v9, v14, m15)
+
+	// Round 1
+	v0, v4, v8, v12 = g(v0+v4+m14, v4, v8, v12, m10)
+	v1, v5, v9, v13 = g(v1+v5+m4, v5, v9, v13, m8)
+	v2, v6, v10, v14 = g(v2+v6+m9, v6, v10, v14, m15)
+	v3, v7, v11, v15 = g(v3+v7+m13, v7, v11, v15, m6)
+
+	v0, v5, v10, v15 = g(v0+v5+m1, v5, v10, v15, m12)
+	v1, v6, v11, v12 = g(v1+v6+m0, v6, v11, v12, m2)
+	v2, v7, v8, v13 = g(v2+v7+m11, v7, v8, v13, m7)
+	v3, v4, v9, v14 = g(v3+v4+m5, v4, v9, v14, m3)
+
+	// Round 2
+	v0, v4, v8, v12 = g(v0+v4+m11, v4, v8, v12, m8)
+	v1, v5, v9, v13 = g(v1+v5+m12, v5, v9, v13, m0)
+	v2, v6, v10, v14 = g(v2+v6+m5, v6, v10, v14, m2)
+	v3, v7, v11, v15 = g(v3+v7+m15, v7, v11, v15, m13)
+
+	v0, v5, v10, v15 = g(v0+v5+m10, v5, v10, v15, m14)
+	v1, v6, v11, v12 = g(v1+v6+m3, v6, v11, v12, m6)
+	v2, v7, v8, v13 = g(v2+v7+m7, v7, v8, v13, m1)
+	v3, v4, v9, v14 = g(v3+v4+m9, v4, v9, v14, m4)
+
+	// Round 3
+	v0, v4, v8, v12 = g(v0+v4+m7, v4, v8, v12, m9)
+	v1, v5, v9, v13 = g(v1+v5+m3, v5, v9, v13, m1)
+	v2, v6, v10, v14 = g(v2+v6+m13, v6, v10, v14, m12)
+	v3, v7, v11, v15 = g(v3+v7+m11, v7, v11, v15, m14)
+
+	v0, v5, v10, v15 = g(v0+v5+m2, v5, v10, v15, m6)
+	v1, v6, v11, v12 = g(v1+v6+m5, v6, v11, v12, m10)
+	v2, v7, v8, v13 = g(v2+v7+m4, v7, v8, v13, m0)
+	v3, v4, v9, v14 = g(v3+v4+m15, v4, v9, v14, m8)
+
+	// Round 4
+	v0, v4, v8, v12 = g(v0+v4+m9, v4, v8, v12, m0)
+	v1, v5, v9, v13 = g(v1+v5+m5, v5, v9, v13, m7)
+	v2, v6, v10, v14 = g(v2+v6+m2, v6, v10, v14, m4)
+	v3, v7, v11, v15 = g(v3+v7+m10, v7, v11, v15, m15)
+
+	v0, v5, v10, v15 = g(v0+v5+m14, v5, v10, v15, m1)
+	v1, v6, v11, v12 = g(v1+v6+m11, v6, v11, v12, m12)
+	v2, v7, v8, v13 = g(v2+v7+m6, v7, v8, v13, m8)
+	v3, v4, v9, v14 = g(v3+v4+m3, v4, v9, v14, m13)
+
+	// Round 5
+	v0, v4, v8, v12 = g(v0+v4+m2, v4, v8, v12, m12)
+	v1, v5, v9, v13 = g(v1+v5+m6, v5, v9, v13, m10)
+	v2, v6, v10, v14 = g(v2+v6+m0, v6, v10, v14, m11)
+	v3, v7, v11, v15 = g(v3+v7+m8, v7, v11, v15, m3)
+
+	v0, v5, v10, v15 = g(v0+v5+m4, v5, v10, v15, m13)
+	v1, v6, v11, v12 = g(v1+v6+m7, v6, v11, v12, m5)
+	v2, v7, v8, v13 = g(v2+v7+m15, v7, v8, v13, m14)
+	v3, v4, v9, v14 = g(v3+v4+m1, v4, v9, v14, m9)
+
+	// Round 6
+	v0, v4, v8, v12 = g(v0+v4+m12, v4, v8, v12, m5)
+	v1, v5, v9, v13 = g(v1+v5+m1, v5, v9, v13, m15)
+	v2, v6, v10, v14 = g(v2+v6+m14, v6, v10, v14, m13)
+	v3, v7, v11, v15 = g(v3+v7+m4, v7, v11, v15, m10)
+
+	v0, v5, v10, v15 = g(v0+v5+m0, v5, v10, v15, m7)
+	v1, v6, v11, v12 = g(v1+v6+m6, v6, v11, v12, m3)
+	v2, v7, v8, v13 = g(v2+v7+m9, v7, v8, v13, m2)
+	v3, v4, v9, v14 = g(v3+v4+m8, v4, v9, v14, m11)
+
+	// Round 7
+	v0, v4, v8, v12 = g(v0+v4+m13, v4, v8, v12, m11)
+	v1, v5, v9, v13 = g(v1+v5+m7, v5, v9, v13, m14)
+	v2, v6, v10, v14 = g(v2+v6+m12, v6, v10, v14, m1)
+	v3, v7, v11, v15 = g(v3+v7+m3, v7, v11, v15, m9)
+
+	v0, v5, v10, v15 = g(v0+v5+m5, v5, v10, v15, m0)
+	v1, v6, v11, v12 = g(v1+v6+m15, v6, v11, v12, m4)
+	v2, v7, v8, v13 = g(v2+v7+m8, v7, v8, v13, m6)
+	v3, v4, v9, v14 = g(v3+v4+m2, v4, v9, v14, m10)
+
+	// Round 8
+	v0, v4, v8, v12 = g(v0+v4+m6, v4, v8, v12, m15)
+	v1, v5, v9, v13 = g(v1+v5+m14, v5, v9, v13, m9)
+	v2, v6, v10, v14 = g(v2+v6+m11, v6, v10, v14, m3)
+	v3, v7, v11, v15 = g(v3+v7+m0, v7, v11, v15, m8)
+
+	v0, v5, v10, v15 = g(v0+v5+m12, v5, v10, v15, m2)
+	v1, v6, v11, v12 = g(v1+v6+m13, v6, v11, v12, m7)
+	v2, v7, v8, v13 = g(v2+v7+m1, v7, v8, v13, m4)
+	v3, v4, v9, v14 = g(v3+v4+m10, v4, v9, v14, m5)
+
+	// Round 9
+	v0, v4, v8, v12 = g(v0+v4+m10, v4, v8, v12, m2)
+	v1, v5, v9, v13 = g(v1+v5+m8, v5, v9, v13, m4)
+	v2, v6, v10, v14 = g(v2+v6+m7, v6, v10, v14, m6)
+	v3, v7, v11, v15 = g(v3+v7+m1, v7, v11, v15, m5)
+
+	v0, v5, v10, v15 = g(v0+v5+m15, v5, v10, v15, m11)
+	v1, v6, v11, v12 = g(v1+v6+m9, v6, v11, v12, m14)
+	v2, v7, v8, v13 = g(v2+v7+m3, v7, v8, v13, m12)
+	v3, v4, v9, v14 = g(v3+v4+m13, v4, v9, v14, m0)
+
+	h[0] = h[0] ^ v0 ^ v8
+	h[1] = h[1] ^ v1 ^ v9
+	h[2] = h[2] ^ v2 ^ v10
+	h[3] = h[3] ^ v3 ^ v11
+	h[4] = h[4] ^ v4 ^ v12
+	h[5] = h[5] ^ v5 ^ v13
+	h[6] = h[6] ^ v6 ^ v14
+	h[7] = h[7] ^ v7 ^ v15
+}
+
+// The internal BLAKE2s round function.
+func g(a, b, c, d, m1 uint32) (uint32, uint32, uint32, uint32) {
+	// We lift the table lookups and the initial triple addition into the
+	// caller so this function has a better chance of inlining. Similarly, the
+	// math/bits calls are themselves inlinable but seem to count against us in
+	// the AST budget anyway. TODO: file a bug for that
+
+	// a = a + b + m0
+	d = ((d ^ a) >> 16) | ((d ^ a) << (32 - 16))
+	c = c + d
+	b = ((b ^ c) >> 12) | ((b ^ c) << (32 - 12))
+	a = a + b + m1
+	d = ((d ^ a) >> 8) | ((d ^ a) << (32 - 8))
+	c = c + d
+	b = ((b ^ c) >> 7) | ((b ^ c) << (32 - 7))
+
+	// TODO does assigning into the parameters result in spills if the function isn't inlined?
+	return a, b, c, d
+}
+
+func u32LE(b []byte) uint32 {
+	_ = b[3] // bounds check hint to the compiler, see golang.org/issue/14808
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
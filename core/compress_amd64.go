@@ -0,0 +1,46 @@
+//go:build amd64 && !purego
+
+package core
+
+// SSE2 is part of the amd64 baseline. SSSE3 adds PSHUFB, which does the
+// 16- and 8-bit rotations in one instruction each. The whole state fits in
+// four 128-bit rows, so wider AVX2 registers would sit half empty.
+var useSSSE3 = hasSSSE3()
+
+var implementations = []Implementation{
+	{"ssse3", useSSSE3, blockSSSE3Args},
+	{"sse2", true, blockSSE2Args},
+}
+
+func block(h *[8]uint32, buf *[BlockSize]byte, t0, t1, f0, f1 uint32) {
+	tf := [4]uint32{t0, t1, f0, f1}
+	if useSSSE3 {
+		blockSSSE3(h, buf, &tf)
+	} else {
+		blockSSE2(h, buf, &tf)
+	}
+}
+
+func blockSSE2Args(h *[8]uint32, buf *[BlockSize]byte, t0, t1, f0, f1 uint32) {
+	blockSSE2(h, buf, &[4]uint32{t0, t1, f0, f1})
+}
+
+func blockSSSE3Args(h *[8]uint32, buf *[BlockSize]byte, t0, t1, f0, f1 uint32) {
+	blockSSSE3(h, buf, &[4]uint32{t0, t1, f0, f1})
+}
+
+// blockSSE2 and blockSSSE3 compress one block. tf holds the counter and
+// flags words t0, t1, f0 and f1.
+//
+//go:noescape
+func blockSSE2(h *[8]uint32, buf *[BlockSize]byte, tf *[4]uint32)
+
+//go:noescape
+func blockSSSE3(h *[8]uint32, buf *[BlockSize]byte, tf *[4]uint32)
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+func hasSSSE3() bool {
+	_, _, ecx, _ := cpuid(1, 0)
+	return ecx&(1<<9) != 0
+}
@@ -0,0 +1,191 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// The state is kept as four rows of four words: X0 = v0..v3, X1 = v4..v7,
+// X2 = v8..v11 and X3 = v12..v15. A G step mixes the four columns at once;
+// rotating rows 1-3 by one, two and three lanes lines up the diagonals for
+// the second step, and rotating them back restores the columns.
+
+DATA iv<>+0(SB)/4, $0x6a09e667
+DATA iv<>+4(SB)/4, $0xbb67ae85
+DATA iv<>+8(SB)/4, $0x3c6ef372
+DATA iv<>+12(SB)/4, $0xa54ff53a
+DATA iv<>+16(SB)/4, $0x510e527f
+DATA iv<>+20(SB)/4, $0x9b05688c
+DATA iv<>+24(SB)/4, $0x1f83d9ab
+DATA iv<>+28(SB)/4, $0x5be0cd19
+GLOBL iv<>(SB), (NOPTR+RODATA), $32
+
+// PSHUFB masks rotating each word right by 16 and by 8 bits.
+DATA rotr16<>+0(SB)/8, $0x0504070601000302
+DATA rotr16<>+8(SB)/8, $0x0d0c0f0e09080b0a
+GLOBL rotr16<>(SB), (NOPTR+RODATA), $16
+
+DATA rotr8<>+0(SB)/8, $0x0407060500030201
+DATA rotr8<>+8(SB)/8, $0x0c0f0e0d080b0a09
+GLOBL rotr8<>(SB), (NOPTR+RODATA), $16
+
+// LOAD_MSG gathers message words i0..i3 from SI into dst, using X7 and X8.
+#define LOAD_MSG(dst, i0, i1, i2, i3) \
+	MOVL i0*4(SI), dst; \
+	MOVL i1*4(SI), X7; \
+	PUNPCKLLQ X7, dst; \
+	MOVL i2*4(SI), X8; \
+	MOVL i3*4(SI), X7; \
+	PUNPCKLLQ X7, X8; \
+	PUNPCKLQDQ X8, dst
+
+// ROTR rotates each word of x right by n bits, using X6.
+#define ROTR(x, n, m) \
+	MOVO x, X6; \
+	PSRLL $n, x; \
+	PSLLL $m, X6; \
+	PXOR X6, x
+
+// PSHUFLW and PSHUFHW swap the halves of each word.
+#define ROTR16_SSE2(x) \
+	PSHUFLW $0xb1, x, x; \
+	PSHUFHW $0xb1, x, x
+
+#define ROTR16_SSSE3(x) PSHUFB X13, x
+#define ROTR8_SSE2(x) ROTR(x, 8, 24)
+#define ROTR8_SSSE3(x) PSHUFB X14, x
+
+#define DIAGONALIZE \
+	PSHUFD $0x39, X1, X1; \
+	PSHUFD $0x4e, X2, X2; \
+	PSHUFD $0x93, X3, X3
+
+#define UNDIAGONALIZE \
+	PSHUFD $0x93, X1, X1; \
+	PSHUFD $0x4e, X2, X2; \
+	PSHUFD $0x39, X3, X3
+
+// G_SSE2 and G_SSSE3 apply G to the four columns (or diagonals) of the state,
+// with message words m0 and m1.
+#define G_SSE2(m0, m1) \
+	PADDL m0, X0; \
+	PADDL X1, X0; \
+	PXOR X0, X3; \
+	ROTR16_SSE2(X3); \
+	PADDL X3, X2; \
+	PXOR X2, X1; \
+	ROTR(X1, 12, 20); \
+	PADDL m1, X0; \
+	PADDL X1, X0; \
+	PXOR X0, X3; \
+	ROTR8_SSE2(X3); \
+	PADDL X3, X2; \
+	PXOR X2, X1; \
+	ROTR(X1, 7, 25)
+
+#define G_SSSE3(m0, m1) \
+	PADDL m0, X0; \
+	PADDL X1, X0; \
+	PXOR X0, X3; \
+	ROTR16_SSSE3(X3); \
+	PADDL X3, X2; \
+	PXOR X2, X1; \
+	ROTR(X1, 12, 20); \
+	PADDL m1, X0; \
+	PADDL X1, X0; \
+	PXOR X0, X3; \
+	ROTR8_SSSE3(X3); \
+	PADDL X3, X2; \
+	PXOR X2, X1; \
+	ROTR(X1, 7, 25)
+
+// A round takes its message schedule, one row of sigma, as arguments.
+#define ROUND_SSE2(s0, s1, s2, s3, s4, s5, s6, s7, s8, s9, s10, s11, s12, s13, s14, s15) \
+	LOAD_MSG(X4, s0, s2, s4, s6); \
+	LOAD_MSG(X5, s1, s3, s5, s7); \
+	G_SSE2(X4, X5); \
+	DIAGONALIZE; \
+	LOAD_MSG(X4, s8, s10, s12, s14); \
+	LOAD_MSG(X5, s9, s11, s13, s15); \
+	G_SSE2(X4, X5); \
+	UNDIAGONALIZE
+
+#define ROUND_SSSE3(s0, s1, s2, s3, s4, s5, s6, s7, s8, s9, s10, s11, s12, s13, s14, s15) \
+	LOAD_MSG(X4, s0, s2, s4, s6); \
+	LOAD_MSG(X5, s1, s3, s5, s7); \
+	G_SSSE3(X4, X5); \
+	DIAGONALIZE; \
+	LOAD_MSG(X4, s8, s10, s12, s14); \
+	LOAD_MSG(X5, s9, s11, s13, s15); \
+	G_SSSE3(X4, X5); \
+	UNDIAGONALIZE
+
+// LOAD_STATE fills the rows from h (DI), the IV and tf (DX), keeping the
+// initial rows 0 and 1 in X9 and X10 for the feed-forward.
+#define LOAD_STATE \
+	MOVOU 0(DI), X0; \
+	MOVOU 16(DI), X1; \
+	MOVOU iv<>+0(SB), X2; \
+	MOVOU iv<>+16(SB), X3; \
+	MOVOU 0(DX), X4; \
+	PXOR X4, X3; \
+	MOVO X0, X9; \
+	MOVO X1, X10
+
+// STORE_STATE sets h[0..3] ^= v0..v3 ^ v8..v11 and h[4..7] ^= v4..v7 ^
+// v12..v15.
+#define STORE_STATE \
+	PXOR X2, X0; \
+	PXOR X9, X0; \
+	MOVOU X0, 0(DI); \
+	PXOR X3, X1; \
+	PXOR X10, X1; \
+	MOVOU X1, 16(DI)
+
+// func blockSSE2(h *[8]uint32, buf *[BlockSize]byte, tf *[4]uint32)
+TEXT ·blockSSE2(SB), NOSPLIT, $0-24
+	MOVQ h+0(FP), DI
+	MOVQ buf+8(FP), SI
+	MOVQ tf+16(FP), DX
+	LOAD_STATE
+	ROUND_SSE2(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15)
+	ROUND_SSE2(14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3)
+	ROUND_SSE2(11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4)
+	ROUND_SSE2(7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8)
+	ROUND_SSE2(9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13)
+	ROUND_SSE2(2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9)
+	ROUND_SSE2(12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11)
+	ROUND_SSE2(13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10)
+	ROUND_SSE2(6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5)
+	ROUND_SSE2(10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0)
+	STORE_STATE
+	RET
+
+// func blockSSSE3(h *[8]uint32, buf *[BlockSize]byte, tf *[4]uint32)
+TEXT ·blockSSSE3(SB), NOSPLIT, $0-24
+	MOVQ h+0(FP), DI
+	MOVQ buf+8(FP), SI
+	MOVQ tf+16(FP), DX
+	MOVOU rotr16<>(SB), X13
+	MOVOU rotr8<>(SB), X14
+	LOAD_STATE
+	ROUND_SSSE3(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15)
+	ROUND_SSSE3(14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3)
+	ROUND_SSSE3(11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4)
+	ROUND_SSSE3(7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8)
+	ROUND_SSSE3(9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13)
+	ROUND_SSSE3(2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9)
+	ROUND_SSSE3(12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11)
+	ROUND_SSSE3(13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10)
+	ROUND_SSSE3(6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5)
+	ROUND_SSSE3(10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0)
+	STORE_STATE
+	RET
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
@@ -0,0 +1,19 @@
+//go:build arm64 && !purego
+
+package core
+
+// Advanced SIMD (NEON) is part of the arm64 baseline, so there is nothing to
+// detect at run time.
+var implementations = []Implementation{
+	{"neon", true, block},
+}
+
+func block(h *[8]uint32, buf *[BlockSize]byte, t0, t1, f0, f1 uint32) {
+	blockNEON(h, buf, &[4]uint32{t0, t1, f0, f1})
+}
+
+// blockNEON compresses one block. tf holds the counter and flags words t0,
+// t1, f0 and f1.
+//
+//go:noescape
+func blockNEON(h *[8]uint32, buf *[BlockSize]byte, tf *[4]uint32)
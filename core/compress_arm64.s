@@ -0,0 +1,187 @@
+//go:build arm64 && !purego
+
+#include "textflag.h"
+
+// The state is kept as four rows of four words: V0 = v0..v3, V1 = v4..v7,
+// V2 = v8..v11 and V3 = v12..v15. A G step mixes the four columns at once;
+// rotating rows 1-3 by one, two and three lanes lines up the diagonals for
+// the second step, and rotating them back restores the columns. The message
+// block stays in V16-V19 and each round gathers its words from there.
+
+DATA iv<>+0(SB)/4, $0x6a09e667
+DATA iv<>+4(SB)/4, $0xbb67ae85
+DATA iv<>+8(SB)/4, $0x3c6ef372
+DATA iv<>+12(SB)/4, $0xa54ff53a
+DATA iv<>+16(SB)/4, $0x510e527f
+DATA iv<>+20(SB)/4, $0x9b05688c
+DATA iv<>+24(SB)/4, $0x1f83d9ab
+DATA iv<>+28(SB)/4, $0x5be0cd19
+GLOBL iv<>(SB), (NOPTR+RODATA), $32
+
+// VTBL indices rotating each word right by 8 bits.
+DATA rotr8<>+0(SB)/8, $0x0407060500030201
+DATA rotr8<>+8(SB)/8, $0x0c0f0e0d080b0a09
+GLOBL rotr8<>(SB), (NOPTR+RODATA), $16
+
+// LOAD_MSG copies message words (lane l0 of r0, ...) into the lanes of dst.
+// Word i of the block is lane i%4 of V16+i/4.
+#define LOAD_MSG(dst, r0, l0, r1, l1, r2, l2, r3, l3) \
+	VMOV r0.S[l0], dst.S[0]; \
+	VMOV r1.S[l1], dst.S[1]; \
+	VMOV r2.S[l2], dst.S[2]; \
+	VMOV r3.S[l3], dst.S[3]
+
+// G applies G to the four columns (or diagonals) of the state, with message
+// words m0 and m1. V7 holds the rotr8 indices and V6 is scratch.
+#define G(m0, m1) \
+	VADD m0.S4, V0.S4, V0.S4; \
+	VADD V1.S4, V0.S4, V0.S4; \
+	VEOR V0.B16, V3.B16, V3.B16; \
+	VREV32 V3.H8, V3.H8; \
+	VADD V3.S4, V2.S4, V2.S4; \
+	VEOR V2.B16, V1.B16, V6.B16; \
+	VUSHR $12, V6.S4, V1.S4; \
+	VSLI $20, V6.S4, V1.S4; \
+	VADD m1.S4, V0.S4, V0.S4; \
+	VADD V1.S4, V0.S4, V0.S4; \
+	VEOR V0.B16, V3.B16, V3.B16; \
+	VTBL V7.B16, [V3.B16], V3.B16; \
+	VADD V3.S4, V2.S4, V2.S4; \
+	VEOR V2.B16, V1.B16, V6.B16; \
+	VUSHR $7, V6.S4, V1.S4; \
+	VSLI $25, V6.S4, V1.S4
+
+#define DIAGONALIZE \
+	VEXT $4, V1.B16, V1.B16, V1.B16; \
+	VEXT $8, V2.B16, V2.B16, V2.B16; \
+	VEXT $12, V3.B16, V3.B16, V3.B16
+
+#define UNDIAGONALIZE \
+	VEXT $12, V1.B16, V1.B16, V1.B16; \
+	VEXT $8, V2.B16, V2.B16, V2.B16; \
+	VEXT $4, V3.B16, V3.B16, V3.B16
+
+// func blockNEON(h *[8]uint32, buf *[BlockSize]byte, tf *[4]uint32)
+TEXT ·blockNEON(SB), NOSPLIT, $0-24
+	MOVD h+0(FP), R0
+	MOVD buf+8(FP), R1
+	MOVD tf+16(FP), R2
+
+	VLD1 (R1), [V16.S4, V17.S4, V18.S4, V19.S4]
+	VLD1 (R0), [V0.S4, V1.S4]
+	MOVD $iv<>(SB), R3
+	VLD1 (R3), [V2.S4, V3.S4]
+	VLD1 (R2), [V4.S4]
+	VEOR V4.B16, V3.B16, V3.B16
+	MOVD $rotr8<>(SB), R3
+	VLD1 (R3), [V7.B16]
+	VMOV V0.B16, V20.B16
+	VMOV V1.B16, V21.B16
+
+	// Round 0
+	LOAD_MSG(V4, V16, 0, V16, 2, V17, 0, V17, 2)
+	LOAD_MSG(V5, V16, 1, V16, 3, V17, 1, V17, 3)
+	G(V4, V5)
+	DIAGONALIZE
+	LOAD_MSG(V4, V18, 0, V18, 2, V19, 0, V19, 2)
+	LOAD_MSG(V5, V18, 1, V18, 3, V19, 1, V19, 3)
+	G(V4, V5)
+	UNDIAGONALIZE
+
+	// Round 1
+	LOAD_MSG(V4, V19, 2, V17, 0, V18, 1, V19, 1)
+	LOAD_MSG(V5, V18, 2, V18, 0, V19, 3, V17, 2)
+	G(V4, V5)
+	DIAGONALIZE
+	LOAD_MSG(V4, V16, 1, V16, 0, V18, 3, V17, 1)
+	LOAD_MSG(V5, V19, 0, V16, 2, V17, 3, V16, 3)
+	G(V4, V5)
+	UNDIAGONALIZE
+
+	// Round 2
+	LOAD_MSG(V4, V18, 3, V19, 0, V17, 1, V19, 3)
+	LOAD_MSG(V5, V18, 0, V16, 0, V16, 2, V19, 1)
+	G(V4, V5)
+	DIAGONALIZE
+	LOAD_MSG(V4, V18, 2, V16, 3, V17, 3, V18, 1)
+	LOAD_MSG(V5, V19, 2, V17, 2, V16, 1, V17, 0)
+	G(V4, V5)
+	UNDIAGONALIZE
+
+	// Round 3
+	LOAD_MSG(V4, V17, 3, V16, 3, V19, 1, V18, 3)
+	LOAD_MSG(V5, V18, 1, V16, 1, V19, 0, V19, 2)
+	G(V4, V5)
+	DIAGONALIZE
+	LOAD_MSG(V4, V16, 2, V17, 1, V17, 0, V19, 3)
+	LOAD_MSG(V5, V17, 2, V18, 2, V16, 0, V18, 0)
+	G(V4, V5)
+	UNDIAGONALIZE
+
+	// Round 4
+	LOAD_MSG(V4, V18, 1, V17, 1, V16, 2, V18, 2)
+	LOAD_MSG(V5, V16, 0, V17, 3, V17, 0, V19, 3)
+	G(V4, V5)
+	DIAGONALIZE
+	LOAD_MSG(V4, V19, 2, V18, 3, V17, 2, V16, 3)
+	LOAD_MSG(V5, V16, 1, V19, 0, V18, 0, V19, 1)
+	G(V4, V5)
+	UNDIAGONALIZE
+
+	// Round 5
+	LOAD_MSG(V4, V16, 2, V17, 2, V16, 0, V18, 0)
+	LOAD_MSG(V5, V19, 0, V18, 2, V18, 3, V16, 3)
+	G(V4, V5)
+	DIAGONALIZE
+	LOAD_MSG(V4, V17, 0, V17, 3, V19, 3, V16, 1)
+	LOAD_MSG(V5, V19, 1, V17, 1, V19, 2, V18, 1)
+	G(V4, V5)
+	UNDIAGONALIZE
+
+	// Round 6
+	LOAD_MSG(V4, V19, 0, V16, 1, V19, 2, V17, 0)
+	LOAD_MSG(V5, V17, 1, V19, 3, V19, 1, V18, 2)
+	G(V4, V5)
+	DIAGONALIZE
+	LOAD_MSG(V4, V16, 0, V17, 2, V18, 1, V18, 0)
+	LOAD_MSG(V5, V17, 3, V16, 3, V16, 2, V18, 3)
+	G(V4, V5)
+	UNDIAGONALIZE
+
+	// Round 7
+	LOAD_MSG(V4, V19, 1, V17, 3, V19, 0, V16, 3)
+	LOAD_MSG(V5, V18, 3, V19, 2, V16, 1, V18, 1)
+	G(V4, V5)
+	DIAGONALIZE
+	LOAD_MSG(V4, V17, 1, V19, 3, V18, 0, V16, 2)
+	LOAD_MSG(V5, V16, 0, V17, 0, V17, 2, V18, 2)
+	G(V4, V5)
+	UNDIAGONALIZE
+
+	// Round 8
+	LOAD_MSG(V4, V17, 2, V19, 2, V18, 3, V16, 0)
+	LOAD_MSG(V5, V19, 3, V18, 1, V16, 3, V18, 0)
+	G(V4, V5)
+	DIAGONALIZE
+	LOAD_MSG(V4, V19, 0, V19, 1, V16, 1, V18, 2)
+	LOAD_MSG(V5, V16, 2, V17, 3, V17, 0, V17, 1)
+	G(V4, V5)
+	UNDIAGONALIZE
+
+	// Round 9
+	LOAD_MSG(V4, V18, 2, V18, 0, V17, 3, V16, 1)
+	LOAD_MSG(V5, V16, 2, V17, 0, V17, 2, V17, 1)
+	G(V4, V5)
+	DIAGONALIZE
+	LOAD_MSG(V4, V19, 3, V18, 1, V16, 3, V19, 1)
+	LOAD_MSG(V5, V18, 3, V19, 2, V19, 0, V16, 0)
+	G(V4, V5)
+	UNDIAGONALIZE
+
+	// h[0..3] ^= v0..v3 ^ v8..v11 and h[4..7] ^= v4..v7 ^ v12..v15.
+	VEOR V2.B16, V0.B16, V0.B16
+	VEOR V20.B16, V0.B16, V0.B16
+	VEOR V3.B16, V1.B16, V1.B16
+	VEOR V21.B16, V1.B16, V1.B16
+	VST1 [V0.S4, V1.S4], (R0)
+	RET
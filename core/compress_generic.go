@@ -0,0 +1,9 @@
+//go:build (!amd64 && !arm64) || purego
+
+package core
+
+var implementations []Implementation
+
+func block(h *[8]uint32, buf *[BlockSize]byte, t0, t1, f0, f1 uint32) {
+	BlockGeneric(h, buf, t0, t1, f0, f1)
+}
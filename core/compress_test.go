@@ -0,0 +1,60 @@
+package core
+
+import "testing"
+
+// TestImplementations checks every SIMD compression function this CPU can
+// run against the portable one, over a range of counters and flags.
+func TestImplementations(t *testing.T) {
+	if len(implementations) == 0 {
+		t.Skip("no SIMD implementations on this architecture")
+	}
+	var buf [BlockSize]byte
+	seed := [8]uint32{IV0, IV1, IV2, IV3, IV4, IV5, IV6, IV7}
+	for _, impl := range implementations {
+		if !impl.Available {
+			t.Logf("%s not supported by this CPU", impl.Name)
+			continue
+		}
+		want, got := seed, seed
+		for i := range 1000 {
+			for j := range buf {
+				buf[j] = byte(i*7 + j*13)
+			}
+			t0, t1 := uint32(i*BlockSize), uint32(i>>3)
+			var f0, f1 uint32
+			if i%5 == 0 {
+				f0 = 0xFFFFFFFF
+			}
+			if i%15 == 0 {
+				f1 = 0xFFFFFFFF
+			}
+			BlockGeneric(&want, &buf, t0, t1, f0, f1)
+			impl.Block(&got, &buf, t0, t1, f0, f1)
+			if got != want {
+				t.Fatalf("%s: block %d: got %08x, want %08x", impl.Name, i, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkBlock(b *testing.B) {
+	var h [8]uint32
+	var buf [BlockSize]byte
+	b.Run("generic", func(b *testing.B) {
+		b.SetBytes(BlockSize)
+		for range b.N {
+			BlockGeneric(&h, &buf, 0, 0, 0, 0)
+		}
+	})
+	for _, impl := range implementations {
+		if !impl.Available {
+			continue
+		}
+		b.Run(impl.Name, func(b *testing.B) {
+			b.SetBytes(BlockSize)
+			for range b.N {
+				impl.Block(&h, &buf, 0, 0, 0, 0)
+			}
+		})
+	}
+}
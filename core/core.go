@@ -0,0 +1,223 @@
+// Package core is the BLAKE2s primitive alone: the parameter block, the
+// compression function and a streaming Digest. It imports nothing but the
+// standard library's errors package, so auditors can review it in isolation
+// and embedders can vendor it without the rest of this module.
+//
+// The parent package builds on core and adds options, backends, limits and
+// the many helpers around them; the minimal package uses only its
+// compression function. Digests from all three are identical.
+package core
+
+import "errors"
+
+const (
+	// MaxSize is the largest digest size in bytes.
+	MaxSize = 32
+	// KeySize is the largest key size in bytes.
+	KeySize = 32
+	// SaltSize is the size of the salt field in bytes.
+	SaltSize = 8
+	// PersonalizationSize is the size of the personalization field in bytes.
+	PersonalizationSize = 8
+)
+
+// A ParameterBlock holds the fields of the BLAKE2s parameter block, which is
+// XORed into the IV to start a hash. Sequential hashing uses Fanout and
+// Depth 1 and zero tree fields. Fields are in the order they are laid out.
+type ParameterBlock struct {
+	DigestSize  byte   // 0: output length in bytes
+	KeyLength   byte   // 1: key length in bytes
+	Fanout      byte   // 2: 1 in sequential mode, 0 for unlimited
+	Depth       byte   // 3: 1 in sequential mode, 255 for unlimited
+	LeafLength  uint32 // 4-7: 0 for unlimited
+	NodeOffset  uint32 // 8-11: low 32 bits of the node offset
+	XOFLength   uint16 // 12-13: BLAKE2X output length, or the top 16 bits of the node offset
+	NodeDepth   byte   // 14: 0 for leaves
+	InnerLength byte   // 15: inner digest length in tree modes
+
+	Salt            [SaltSize]byte            // 16-23
+	Personalization [PersonalizationSize]byte // 24-31
+}
+
+// Marshal returns the 32-byte little-endian encoding of p.
+func (p *ParameterBlock) Marshal() [32]byte {
+	var b [32]byte
+	b[0] = p.DigestSize
+	b[1] = p.KeyLength
+	b[2] = p.Fanout
+	b[3] = p.Depth
+	putU32LE(b[4:], p.LeafLength)
+	putU32LE(b[8:], p.NodeOffset)
+	b[12] = byte(p.XOFLength)
+	b[13] = byte(p.XOFLength >> 8)
+	b[14] = p.NodeDepth
+	b[15] = p.InnerLength
+	copy(b[16:], p.Salt[:])
+	copy(b[24:], p.Personalization[:])
+	return b
+}
+
+// Unmarshal decodes a 32-byte parameter block into p.
+func (p *ParameterBlock) Unmarshal(b []byte) error {
+	if len(b) != 32 {
+		return errors.New("core: parameter block must be 32 bytes")
+	}
+	p.DigestSize = b[0]
+	p.KeyLength = b[1]
+	p.Fanout = b[2]
+	p.Depth = b[3]
+	p.LeafLength = u32LE(b[4:8])
+	p.NodeOffset = u32LE(b[8:12])
+	p.XOFLength = uint16(b[12]) | uint16(b[13])<<8
+	p.NodeDepth = b[14]
+	p.InnerLength = b[15]
+	copy(p.Salt[:], b[16:24])
+	copy(p.Personalization[:], b[24:32])
+	return nil
+}
+
+// InitialState returns the chaining value a hash with these parameters
+// starts from: the IV XORed with the parameter block.
+func (p *ParameterBlock) InitialState() [8]uint32 {
+	b := p.Marshal()
+	iv := [8]uint32{IV0, IV1, IV2, IV3, IV4, IV5, IV6, IV7}
+	for i := range iv {
+		iv[i] ^= u32LE(b[i*4 : i*4+4])
+	}
+	return iv
+}
+
+// A Digest is a BLAKE2s hash in progress. It implements hash.Hash.
+type Digest struct {
+	h      [8]uint32
+	t0, t1 uint32
+	buf    [BlockSize]byte
+	offset int
+	size   int
+
+	// lastNode sets the second finalization flag, for the last node of a
+	// level in tree hashing.
+	lastNode bool
+
+	// init is the state after the parameter block and key block, which
+	// Reset returns to.
+	init *Digest
+}
+
+// New returns a Digest for the parameters p, keyed with key if it is not
+// empty. p.KeyLength must equal len(key), except that a nil key leaves the
+// key length as given without absorbing a key block, as the root of
+// BLAKE2sp does.
+func New(p *ParameterBlock, key []byte) (*Digest, error) {
+	if p.DigestSize < 1 || p.DigestSize > MaxSize {
+		return nil, errors.New("core: digest size must be 1 to 32 bytes")
+	}
+	if len(key) > KeySize {
+		return nil, errors.New("core: key too large")
+	}
+	if key != nil && int(p.KeyLength) != len(key) {
+		return nil, errors.New("core: key length differs from the parameter block")
+	}
+	d := &Digest{h: p.InitialState(), size: int(p.DigestSize)}
+	if len(key) > 0 {
+		copy(d.buf[:], key)
+		d.offset = BlockSize
+	}
+	init := *d
+	d.init = &init
+	return d, nil
+}
+
+// NewSequential returns a sequential-mode Digest producing size bytes, with
+// the given key, salt and personalization, any of which may be empty.
+func NewSequential(size int, key, salt, personalization []byte) (*Digest, error) {
+	if size < 1 || size > MaxSize {
+		return nil, errors.New("core: digest size must be 1 to 32 bytes")
+	}
+	if len(salt) > SaltSize {
+		return nil, errors.New("core: salt too large")
+	}
+	if len(personalization) > PersonalizationSize {
+		return nil, errors.New("core: personalization too large")
+	}
+	if len(key) > KeySize {
+		return nil, errors.New("core: key too large")
+	}
+	p := &ParameterBlock{DigestSize: byte(size), KeyLength: byte(len(key)), Fanout: 1, Depth: 1}
+	copy(p.Salt[:], salt)
+	copy(p.Personalization[:], personalization)
+	return New(p, key)
+}
+
+// SetLastNode marks the Digest as the last node of its level, for tree
+// hashing. It must be called before Sum, and survives Reset.
+func (d *Digest) SetLastNode() {
+	d.lastNode = true
+	d.init.lastNode = true
+}
+
+// Write adds data to the running hash. It never returns an error.
+func (d *Digest) Write(data []byte) (int, error) {
+	n := len(data)
+	for len(data) > 0 {
+		// Keep the last block buffered until Sum, since it has to be
+		// compressed with the final-block flag.
+		if d.offset == BlockSize {
+			d.t0 += BlockSize
+			if d.t0 < BlockSize {
+				d.t1++
+			}
+			Block(&d.h, &d.buf, d.t0, d.t1, 0, 0)
+			d.offset = 0
+		}
+		c := copy(d.buf[d.offset:], data)
+		d.offset += c
+		data = data[c:]
+	}
+	return n, nil
+}
+
+// Sum appends the digest to b without changing the hash state.
+func (d *Digest) Sum(b []byte) []byte {
+	final := *d
+	for i := final.offset; i < BlockSize; i++ {
+		final.buf[i] = 0
+	}
+	final.t0 += uint32(final.offset)
+	if final.t0 < uint32(final.offset) {
+		final.t1++
+	}
+	var f1 uint32
+	if final.lastNode {
+		f1 = 0xFFFFFFFF
+	}
+	Block(&final.h, &final.buf, final.t0, final.t1, 0xFFFFFFFF, f1)
+
+	var out [MaxSize]byte
+	for i, w := range final.h {
+		putU32LE(out[i*4:], w)
+	}
+	return append(b, out[:d.size]...)
+}
+
+// Reset returns the Digest to its state just after New, keeping its key and
+// parameters.
+func (d *Digest) Reset() {
+	init := d.init
+	*d = *init
+	d.init = init
+}
+
+// Size returns the digest size in bytes.
+func (d *Digest) Size() int { return d.size }
+
+// BlockSize returns the block size in bytes.
+func (d *Digest) BlockSize() int { return BlockSize }
+
+func putU32LE(b []byte, n uint32) {
+	_ = b[3] // bounds check hint to the compiler, see golang.org/issue/14808
+	b[0] = byte(n)
+	b[1] = byte(n >> 8)
+	b[2] = byte(n >> 16)
+	b[3] = byte(n >> 24)
+}
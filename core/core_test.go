@@ -0,0 +1,121 @@
+package core
+
+import (
+	"encoding/hex"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Expected values are from Python's hashlib.blake2s.
+func TestDigest(t *testing.T) {
+	data := make([]byte, 200)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	key32 := data[:32]
+	tree := &ParameterBlock{DigestSize: 32, Fanout: 2, Depth: 2, LeafLength: 4096, NodeOffset: 1, InnerLength: 32}
+
+	for _, tc := range []struct {
+		name string
+		new  func() (*Digest, error)
+		in   []byte
+		last bool
+		want string
+	}{
+		{"empty", func() (*Digest, error) { return NewSequential(32, nil, nil, nil) }, nil, false,
+			"69217a3079908094e11121d042354a7c1f55b6482ca1a51e1b250dfd1ed0eef9"},
+		{"unkeyed", func() (*Digest, error) { return NewSequential(32, nil, nil, nil) }, data, false,
+			"6d244e1a06ce4ef578dd0f63aff0936706735119ca9c8d22d86c801414ab9741"},
+		{"keyed, salted, personalized", func() (*Digest, error) {
+			return NewSequential(20, []byte("key"), []byte("salt"), []byte("persona"))
+		}, data, false, "2367a03c38e7f03601abd628cd7ff8f867a10d99"},
+		{"full key, one block", func() (*Digest, error) { return NewSequential(32, key32, nil, nil) }, data[:64], false,
+			"8975b0577fd35566d750b362b0897a26c399136df07bababbde6203ff2954ed4"},
+		{"tree node", func() (*Digest, error) { return New(tree, nil) }, data, true,
+			"d2811044fa86fdd6aa9d4b7b5a7feeab24f9df7dd3f92fc03ecce6ed19e458c4"},
+	} {
+		d, err := tc.new()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.last {
+			d.SetLastNode()
+		}
+		// Write in uneven pieces to cross block boundaries.
+		for in := tc.in; len(in) > 0; {
+			n := min(len(in), 7)
+			d.Write(in[:n])
+			in = in[n:]
+		}
+		if got := hex.EncodeToString(d.Sum(nil)); got != tc.want {
+			t.Errorf("%s: got %s", tc.name, got)
+		}
+		d.Reset()
+		d.Write(tc.in)
+		if got := hex.EncodeToString(d.Sum(nil)); got != tc.want {
+			t.Errorf("%s after Reset: got %s", tc.name, got)
+		}
+	}
+
+	for _, bad := range []func() (*Digest, error){
+		func() (*Digest, error) { return NewSequential(0, nil, nil, nil) },
+		func() (*Digest, error) { return NewSequential(33, nil, nil, nil) },
+		func() (*Digest, error) { return NewSequential(32, make([]byte, 33), nil, nil) },
+		func() (*Digest, error) { return NewSequential(32, nil, make([]byte, 9), nil) },
+		func() (*Digest, error) { return NewSequential(32, nil, nil, make([]byte, 9)) },
+		func() (*Digest, error) { return New(&ParameterBlock{DigestSize: 32, KeyLength: 3}, []byte("four")) },
+	} {
+		if _, err := bad(); err == nil {
+			t.Error("accepted invalid parameters")
+		}
+	}
+}
+
+func TestParameterBlock(t *testing.T) {
+	p := ParameterBlock{
+		DigestSize: 32, KeyLength: 5, Fanout: 2, Depth: 3,
+		LeafLength: 0x01020304, NodeOffset: 0x05060708, XOFLength: 0x090a,
+		NodeDepth: 1, InnerLength: 32,
+		Salt:            [8]byte{'s', 'a', 'l', 't'},
+		Personalization: [8]byte{'p', 'e', 'r', 's'},
+	}
+	b := p.Marshal()
+	want := "2005020304030201080706050a090120" + hex.EncodeToString([]byte("salt\x00\x00\x00\x00pers\x00\x00\x00\x00"))
+	if hex.EncodeToString(b[:]) != want {
+		t.Errorf("marshaled %x", b)
+	}
+	var q ParameterBlock
+	if err := q.Unmarshal(b[:]); err != nil || q != p {
+		t.Errorf("round trip gave %+v, %v", q, err)
+	}
+	if err := q.Unmarshal(b[:31]); err == nil {
+		t.Error("accepted a short block")
+	}
+}
+
+// TestImports keeps the package vendorable on its own.
+func TestImports(t *testing.T) {
+	files, _ := filepath.Glob("*.go")
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), name, src, parser.ImportsOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, imp := range f.Imports {
+			if imp.Path.Value != `"errors"` {
+				t.Errorf("%s imports %s", name, imp.Path.Value)
+			}
+		}
+	}
+}
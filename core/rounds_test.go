@@ -0,0 +1,26 @@
+package core
+
+import "testing"
+
+func TestBlockRounds(t *testing.T) {
+	var buf [BlockSize]byte
+	for i := range buf {
+		buf[i] = byte(i * 7)
+	}
+	seed := [8]uint32{IV0, IV1, IV2, IV3, IV4, IV5, IV6, IV7}
+	full := seed
+	BlockGeneric(&full, &buf, 64, 0, 0xFFFFFFFF, 0)
+
+	seen := map[[8]uint32]int{}
+	for rounds := 0; rounds <= MaxRounds; rounds++ {
+		h := seed
+		BlockRounds(&h, &buf, 64, 0, 0xFFFFFFFF, 0, rounds)
+		if (h == full) != (rounds == MaxRounds) {
+			t.Errorf("%d rounds: matches Block: %v", rounds, h == full)
+		}
+		if r, dup := seen[h]; dup {
+			t.Errorf("%d and %d rounds give the same state", r, rounds)
+		}
+		seen[h] = rounds
+	}
+}
@@ -0,0 +1,56 @@
+package blake2s
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// StateSnapshot is a copy of a Digest's internal state, intended for
+// debugging interop issues where two implementations diverge partway through
+// a stream. It contains key material for keyed instances: the chaining value
+// and, before any message bytes are written, the buffered key block.
+type StateSnapshot struct {
+	H        [8]uint32
+	T0, T1   uint32
+	F0, F1   uint32
+	Buffered []byte
+}
+
+// DumpState returns a snapshot of the current internal state.
+func (d *Digest) DumpState() StateSnapshot {
+	buffered := make([]byte, d.offset)
+	copy(buffered, d.buf[:d.offset])
+	return StateSnapshot{
+		H:        d.h,
+		T0:       d.t0,
+		T1:       d.t1,
+		F0:       d.f0,
+		F1:       d.f1,
+		Buffered: buffered,
+	}
+}
+
+func (s StateSnapshot) String() string {
+	return fmt.Sprintf("h=%08x t=%08x:%08x f=%08x:%08x buf=%x", s.H, s.T1, s.T0, s.F1, s.F0, s.Buffered)
+}
+
+// Diff describes each field that differs between two snapshots, one line per
+// field. It returns nil if the snapshots are identical.
+func (s StateSnapshot) Diff(other StateSnapshot) []string {
+	var diffs []string
+	for i := range s.H {
+		if s.H[i] != other.H[i] {
+			diffs = append(diffs, fmt.Sprintf("h[%d]: %08x != %08x", i, s.H[i], other.H[i]))
+		}
+	}
+	if s.T0 != other.T0 || s.T1 != other.T1 {
+		diffs = append(diffs, fmt.Sprintf("t: %08x:%08x != %08x:%08x", s.T1, s.T0, other.T1, other.T0))
+	}
+	if s.F0 != other.F0 || s.F1 != other.F1 {
+		diffs = append(diffs, fmt.Sprintf("f: %08x:%08x != %08x:%08x", s.F1, s.F0, other.F1, other.F0))
+	}
+	if !bytes.Equal(s.Buffered, other.Buffered) {
+		diffs = append(diffs, fmt.Sprintf("buf: %x != %x", s.Buffered, other.Buffered))
+	}
+	return diffs
+}
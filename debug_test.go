@@ -0,0 +1,44 @@
+package blake2s
+
+import (
+	"testing"
+)
+
+func TestStateDiff(t *testing.T) {
+	a, _ := NewDigest(nil, nil, nil, 32)
+	b, _ := NewDigest(nil, nil, nil, 32)
+
+	a.Write(make([]byte, 70))
+	b.Write(make([]byte, 70))
+	if diffs := a.DumpState().Diff(b.DumpState()); diffs != nil {
+		t.Errorf("identical streams differ: %v", diffs)
+	}
+
+	a.Write([]byte{1})
+	b.Write([]byte{2})
+	diffs := a.DumpState().Diff(b.DumpState())
+	if len(diffs) != 1 {
+		t.Fatalf("expected only the buffer to differ, got %v", diffs)
+	}
+
+	// Push both over a block boundary so the chaining values diverge.
+	a.Write(make([]byte, 64))
+	b.Write(make([]byte, 64))
+	diffs = a.DumpState().Diff(b.DumpState())
+	if len(diffs) != 8 {
+		t.Errorf("expected all eight chaining words to differ, got %v", diffs)
+	}
+}
+
+func TestDumpStateCopiesBuffer(t *testing.T) {
+	d, _ := NewDigest(nil, nil, nil, 32)
+	d.Write([]byte{1, 2, 3})
+	s := d.DumpState()
+	s.Buffered[0] = 0xFF
+	if d.buf[0] != 1 {
+		t.Error("snapshot aliases the digest buffer")
+	}
+	if s.T0 != 0 || len(s.Buffered) != 3 {
+		t.Errorf("unexpected snapshot %v", s)
+	}
+}
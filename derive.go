@@ -0,0 +1,122 @@
+package blake2s
+
+import (
+	"errors"
+	"io"
+)
+
+// Clone returns an independent copy of the Digest, including any pending
+// input, so one common prefix can be continued several ways. Writes to the
+// copy don't affect the original or the other way round. Options carry over:
+// a Guard set with WithGuard is shared, and charged by both.
+func (d *Digest) Clone() *Digest {
+	c := *d
+	return &c
+}
+
+// ReSalt returns a fresh Digest sharing this instance's key,
+// personalization, output size, tree parameters and options, but using the
+// provided salt. The state of the receiver is not consulted or modified.
+func (d *Digest) ReSalt(salt []byte) (*Digest, error) {
+	if len(salt) > SaltLength {
+		return nil, errors.New("blake2s: salt too large")
+	}
+	return d.derive(d.keyOrNil(), salt), nil
+}
+
+// ReKey returns a fresh Digest sharing this instance's salt,
+// personalization, output size, tree parameters and options, keyed with a
+// subkey derived from the current key and the provided label. The subkey is
+// the full-length keyed BLAKE2s hash of the label under the current key, so
+// repeated calls with the same label yield the same subkey and the old key
+// cannot be recovered from the new one. ReKey fails on an unkeyed Digest,
+// whose subkey anyone could compute.
+func (d *Digest) ReKey(label []byte) (*Digest, error) {
+	if d.keyLen == 0 {
+		return nil, errors.New("blake2s: ReKey requires a keyed Digest")
+	}
+	kdf, err := NewDigest(d.keyOrNil(), nil, nil, KeyLength)
+	if err != nil {
+		return nil, err
+	}
+	defer kdf.Wipe()
+	kdf.Write(label)
+
+	var subkey [KeyLength]byte
+	kdf.Sum(subkey[:0])
+	defer clear(subkey[:])
+
+	return d.derive(subkey[:], d.salt[:]), nil
+}
+
+// derive builds a Digest from this instance's parameter block, recovered
+// from its initial chaining value, with the key and salt replaced, and
+// carries over its options.
+func (d *Digest) derive(key, salt []byte) *Digest {
+	var b [32]byte
+	iv := [8]uint32{IV0, IV1, IV2, IV3, IV4, IV5, IV6, IV7}
+	for i := range iv {
+		putU32LE(b[i*4:], d.init[i]^iv[i])
+	}
+	var p ParameterBlock
+	p.Unmarshal(b[:])
+	p.KeyLength = byte(len(key))
+	p.Salt = [SaltLength]byte{}
+	copy(p.Salt[:], salt)
+
+	n := fromParams(&p, key)
+	n.backend = d.backend
+	n.hooks = d.hooks
+	n.maxInput = d.maxInput
+	n.guard = d.guard
+	n.lastNode = d.lastNode
+	return n
+}
+
+func (d *Digest) keyOrNil() []byte {
+	if d.keyLen == 0 {
+		return nil
+	}
+	return d.key[:d.keyLen]
+}
+
+// The two stages of DeriveKey are separated from each other by their
+// personalizations.
+var (
+	deriveContextPersona = []byte("kdfctx01")
+	deriveKeyPersona     = []byte("kdfkey01")
+)
+
+// DeriveKey derives a size-byte subkey from keyMaterial for the purpose
+// named by context, after BLAKE3's key derivation mode. The context is
+// hashed to a context key, and keyMaterial is then hashed under that key;
+// each stage has its own personalization, so derived keys can't collide
+// with plain or keyed BLAKE2s hashes, nor with each other across contexts.
+//
+// The context should be a hardcoded, globally unique string naming the
+// application, the date and the purpose, such as
+// "example.com 2026-10-15 session tokens v1", and must not depend on
+// secrets or user input; the secret goes in keyMaterial. An empty context
+// is rejected, as is a size outside 1 to MaxOutput.
+func DeriveKey(context string, keyMaterial []byte, size int) ([]byte, error) {
+	if context == "" {
+		return nil, errors.New("blake2s: DeriveKey context must not be empty")
+	}
+	ctx, err := NewDigest(nil, nil, deriveContextPersona, KeyLength)
+	if err != nil {
+		return nil, err
+	}
+	io.WriteString(ctx, context)
+
+	var contextKey [KeyLength]byte
+	ctx.Sum(contextKey[:0])
+	defer clear(contextKey[:])
+
+	kdf, err := NewDigest(contextKey[:], nil, deriveKeyPersona, size)
+	if err != nil {
+		return nil, err
+	}
+	defer kdf.Wipe()
+	kdf.Write(keyMaterial)
+	return kdf.Sum(nil), nil
+}
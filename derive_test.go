@@ -0,0 +1,163 @@
+package blake2s
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/gtank/blake2s/testutil"
+)
+
+func TestClone(t *testing.T) {
+	prefix := bytes.Repeat([]byte("common prefix "), 10) // ends mid-block
+	d, _ := NewDigest([]byte("key"), nil, nil, 32)
+	d.Write(prefix)
+
+	c := d.Clone()
+	c.Write([]byte("left"))
+	d.Write([]byte("right"))
+
+	for _, tc := range []struct {
+		d      *Digest
+		suffix string
+	}{{c, "left"}, {d, "right"}} {
+		want, _ := NewDigest([]byte("key"), nil, nil, 32)
+		want.Write(prefix)
+		want.Write([]byte(tc.suffix))
+		if !bytes.Equal(tc.d.Sum(nil), want.Sum(nil)) {
+			t.Errorf("%s continuation differs", tc.suffix)
+		}
+	}
+
+	c.Reset()
+	d.Reset()
+	if !bytes.Equal(c.Sum(nil), d.Sum(nil)) {
+		t.Error("clone does not Reset to the same state")
+	}
+}
+
+func TestReSalt(t *testing.T) {
+	key := []byte("a thirty-two byte key for tests!")
+	d, err := NewDigest(key, []byte("oldsalt"), []byte("persona"), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Write([]byte("ignored"))
+
+	derived, err := d.ReSalt([]byte("newsalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := NewDigest(key, []byte("newsalt"), []byte("persona"), 16)
+
+	derived.Write([]byte("message"))
+	expected.Write([]byte("message"))
+	if !bytes.Equal(expected.Sum(nil), derived.Sum(nil)) {
+		t.Error("ReSalt did not match a directly constructed digest")
+	}
+}
+
+func TestReKey(t *testing.T) {
+	key := []byte("a thirty-two byte key for tests!")
+	d, err := NewDigest(key, []byte("salt"), []byte("persona"), 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kdf, _ := NewDigest(key, nil, nil, KeyLength)
+	kdf.Write([]byte("rotation-1"))
+	expected, _ := NewDigest(kdf.Sum(nil), []byte("salt"), []byte("persona"), 32)
+
+	derived, err := d.ReKey([]byte("rotation-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expected.Sum(nil), derived.Sum(nil)) {
+		t.Error("ReKey did not match the documented derivation")
+	}
+
+	other, _ := d.ReKey([]byte("rotation-2"))
+	if bytes.Equal(derived.Sum(nil), other.Sum(nil)) {
+		t.Error("different labels derived the same key")
+	}
+}
+
+func TestReKeyUnkeyed(t *testing.T) {
+	d, _ := NewDigest(nil, []byte("salt"), nil, 32)
+	if _, err := d.ReKey([]byte("rotation-1")); err == nil {
+		t.Error("ReKey derived a subkey from an unkeyed Digest")
+	}
+}
+
+func TestDeriveKeepsOptions(t *testing.T) {
+	key := []byte("a thirty-two byte key for tests!")
+	tree := TreeParams{Fanout: 2, MaxDepth: 2, LeafLength: 64, NodeOffset: 1, InnerLength: 32, LastNode: true}
+	opts := func(key, salt []byte) []Option {
+		return []Option{WithKey(key), WithSalt(salt), WithSize(16), WithTree(tree), WithMaxInput(10)}
+	}
+	d, err := New(opts(key, []byte("oldsalt"))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resalted, err := d.ReSalt([]byte("newsalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := New(opts(key, []byte("newsalt"))...)
+	resalted.Write([]byte("message"))
+	want.Write([]byte("message"))
+	if !bytes.Equal(resalted.Sum(nil), want.Sum(nil)) {
+		t.Error("ReSalt dropped the tree parameters")
+	}
+
+	rekeyed, err := d.ReKey([]byte("rotation-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, n := range map[string]*Digest{"ReSalt": resalted, "ReKey": rekeyed} {
+		if _, err := n.Write(make([]byte, 11)); err != ErrMaxInput {
+			t.Errorf("%s: got %v, want ErrMaxInput", name, err)
+		}
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	// Computed with Python's hashlib.blake2s, hashing the context with
+	// person=b"kdfctx01" and the key material under the result with
+	// person=b"kdfkey01".
+	for _, v := range []struct {
+		context  string
+		material []byte
+		size     int
+		want     string
+	}{
+		{"example.com 2026-10-15 session tokens v1", []byte("input key material"), 32, "6e22f96eb3e7b043cb73495db8fefdd6a70272478faeefe64c79bfd5ccdd3256"},
+		{"example.com 2026-10-15 file encryption v1", testutil.Input(100), 16, "51f75fcec1f77bd9263cb2a9a6ac6edb"},
+	} {
+		got, err := DeriveKey(v.context, v.material, v.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(got) != v.want {
+			t.Errorf("%q: got %x, want %s", v.context, got, v.want)
+		}
+	}
+
+	a, _ := DeriveKey("context a", []byte("secret"), 32)
+	b, _ := DeriveKey("context b", []byte("secret"), 32)
+	plain, _ := NewDigest(nil, nil, nil, 32)
+	plain.Write([]byte("secret"))
+	if bytes.Equal(a, b) || bytes.Equal(a, plain.Sum(nil)) {
+		t.Error("derived keys are not domain separated")
+	}
+
+	if _, err := DeriveKey("", []byte("secret"), 32); err == nil {
+		t.Error("empty context accepted")
+	}
+	for _, size := range []int{0, MaxOutput + 1} {
+		if _, err := DeriveKey("context", []byte("secret"), size); err == nil {
+			t.Errorf("size %d accepted", size)
+		}
+	}
+}
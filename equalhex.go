@@ -0,0 +1,32 @@
+package blake2s
+
+// EqualHex reports whether expectedHex is the hex encoding of digest, in
+// either case. The comparison takes time that depends only on the lengths
+// of its inputs, not on their contents, and does not allocate, so it is
+// suitable for checking digests and MACs against values from configuration
+// files or the command line. Malformed hex never matches.
+func EqualHex(expectedHex string, digest []byte) bool {
+	if len(expectedHex) != 2*len(digest) {
+		return false
+	}
+	var diff, bad int32
+	for i, b := range digest {
+		hi, hiOK := hexValue(expectedHex[2*i])
+		lo, loOK := hexValue(expectedHex[2*i+1])
+		diff |= (hi<<4 | lo) ^ int32(b)
+		bad |= ^(hiOK & loOK)
+	}
+	return diff|bad&1 == 0
+}
+
+// hexValue decodes a hex digit without branching on it. ok is -1 for a
+// valid digit and 0 otherwise, in which case v is 0.
+func hexValue(c byte) (v, ok int32) {
+	x := int32(c)
+	// (lo-1-x) & (x-hi-1) is negative exactly when lo <= x <= hi.
+	digit := (('0' - 1 - x) & (x - '9' - 1)) >> 31
+	lower := (('a' - 1 - x) & (x - 'f' - 1)) >> 31
+	upper := (('A' - 1 - x) & (x - 'F' - 1)) >> 31
+	v = digit&(x-'0') | lower&(x-'a'+10) | upper&(x-'A'+10)
+	return v, digit | lower | upper
+}
@@ -0,0 +1,53 @@
+package blake2s
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestEqualHex(t *testing.T) {
+	digest := []byte{0x00, 0x19, 0xaf, 0xf0, 0xff}
+	for _, tc := range []struct {
+		hex  string
+		want bool
+	}{
+		{"0019aff0ff", true},
+		{"0019AFF0FF", true},
+		{"0019aF0ff", false},
+		{"0019aff0fe", false},
+		{"1019aff0ff", false},
+		{"0019aff0f", false},
+		{"0019aff0ff00", false},
+		{"0019agf0ff", false},
+		{"0019a\x00f0ff", false},
+		{"", false},
+	} {
+		if got := EqualHex(tc.hex, digest); got != tc.want {
+			t.Errorf("EqualHex(%q) = %v", tc.hex, got)
+		}
+	}
+	if !EqualHex("", nil) {
+		t.Error("empty hex does not match an empty digest")
+	}
+
+	// Every byte value decodes the way encoding/hex does.
+	for c := 0; c < 256; c++ {
+		v, ok := hexValue(byte(c))
+		want, err := hex.DecodeString(string([]byte{'0', byte(c)}))
+		if (ok == -1) != (err == nil) {
+			t.Errorf("%#x: valid %v, encoding/hex says %v", c, ok, err)
+		}
+		if err == nil && byte(v) != want[0] {
+			t.Errorf("%#x decodes to %d, want %d", c, v, want[0])
+		}
+	}
+}
+
+func TestEqualHexAllocs(t *testing.T) {
+	d, _ := NewDigest(nil, nil, nil, 32)
+	sum := d.Sum(nil)
+	expected := hex.EncodeToString(sum)
+	if n := testing.AllocsPerRun(100, func() { EqualHex(expected, sum) }); n != 0 {
+		t.Errorf("EqualHex allocates %v times", n)
+	}
+}
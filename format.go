@@ -0,0 +1,80 @@
+package blake2s
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// FormatOptions controls how FormatDigest displays a digest.
+type FormatOptions struct {
+	// Upper selects upper-case hex digits.
+	Upper bool
+
+	// Group, if positive, inserts Separator after every Group bytes.
+	Group     int
+	Separator string
+
+	// Truncate, if positive, shows only the first Truncate bytes followed
+	// by "...", when the digest is longer than that.
+	Truncate int
+}
+
+// FormatDigest returns the hex encoding of d as configured by opts. The zero
+// FormatOptions gives plain lower-case hex, as fmt's %x does. For example,
+// FormatOptions{Upper: true, Group: 2, Separator: ":", Truncate: 8} displays
+// a 32-byte digest as "1A2B:3C4D:5E6F:7A8B...".
+func FormatDigest(d []byte, opts FormatOptions) string {
+	digits := "0123456789abcdef"
+	if opts.Upper {
+		digits = "0123456789ABCDEF"
+	}
+	truncated := opts.Truncate > 0 && len(d) > opts.Truncate
+	if truncated {
+		d = d[:opts.Truncate]
+	}
+
+	var b strings.Builder
+	b.Grow(2*len(d) + 3)
+	for i, c := range d {
+		if opts.Group > 0 && i > 0 && i%opts.Group == 0 {
+			b.WriteString(opts.Separator)
+		}
+		b.WriteByte(digits[c>>4])
+		b.WriteByte(digits[c&0xf])
+	}
+	if truncated {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+// SumHex returns the current hash in lower-case hex. Like Sum, it does not
+// change the underlying hash state. The raw digest stays on the stack, so the
+// string is the only allocation.
+func (d *Digest) SumHex() string {
+	var buf [2 * MaxOutput]byte
+	return string(d.AppendHex(buf[:0]))
+}
+
+// SumBase64 returns the current hash in standard, padded base64, the
+// encoding used by Subresource Integrity and many package lockfiles. As with
+// SumHex, the string is the only allocation.
+func (d *Digest) SumBase64() string {
+	var buf [(MaxOutput + 2) / 3 * 4]byte
+	return string(d.AppendBase64(buf[:0]))
+}
+
+// AppendHex appends the current hash in lower-case hex to dst and returns the
+// resulting slice. If dst has room, it doesn't allocate.
+func (d *Digest) AppendHex(dst []byte) []byte {
+	var sum [MaxOutput]byte
+	return hex.AppendEncode(dst, d.Sum(sum[:0]))
+}
+
+// AppendBase64 appends the current hash in standard, padded base64 to dst and
+// returns the resulting slice. If dst has room, it doesn't allocate.
+func (d *Digest) AppendBase64(dst []byte) []byte {
+	var sum [MaxOutput]byte
+	return base64.StdEncoding.AppendEncode(dst, d.Sum(sum[:0]))
+}
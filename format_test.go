@@ -0,0 +1,61 @@
+package blake2s
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+func TestFormatDigest(t *testing.T) {
+	d := []byte{0x1a, 0x2b, 0x3c, 0x4d, 0x5e, 0x6f}
+	for _, tc := range []struct {
+		opts FormatOptions
+		want string
+	}{
+		{FormatOptions{}, fmt.Sprintf("%x", d)},
+		{FormatOptions{Upper: true}, "1A2B3C4D5E6F"},
+		{FormatOptions{Group: 1, Separator: ":"}, "1a:2b:3c:4d:5e:6f"},
+		{FormatOptions{Group: 4, Separator: " "}, "1a2b3c4d 5e6f"},
+		{FormatOptions{Truncate: 4}, "1a2b3c4d..."},
+		{FormatOptions{Truncate: 6}, "1a2b3c4d5e6f"},
+		{FormatOptions{Upper: true, Group: 2, Separator: ":", Truncate: 4}, "1A2B:3C4D..."},
+	} {
+		if got := FormatDigest(d, tc.opts); got != tc.want {
+			t.Errorf("%+v: got %q, want %q", tc.opts, got, tc.want)
+		}
+	}
+	if got := FormatDigest(nil, FormatOptions{Truncate: 4}); got != "" {
+		t.Errorf("empty digest formatted as %q", got)
+	}
+}
+
+func TestEncodedSums(t *testing.T) {
+	d, _ := NewDigest(nil, nil, nil, 20)
+	d.Write([]byte("abc"))
+	sum := d.Sum(nil)
+
+	if got, want := d.SumHex(), hex.EncodeToString(sum); got != want {
+		t.Errorf("SumHex: got %s, want %s", got, want)
+	}
+	if got, want := d.SumBase64(), base64.StdEncoding.EncodeToString(sum); got != want {
+		t.Errorf("SumBase64: got %s, want %s", got, want)
+	}
+	if got, want := string(d.AppendHex([]byte("h:"))), "h:"+hex.EncodeToString(sum); got != want {
+		t.Errorf("AppendHex: got %s, want %s", got, want)
+	}
+	if got, want := string(d.AppendBase64([]byte("b:"))), "b:"+base64.StdEncoding.EncodeToString(sum); got != want {
+		t.Errorf("AppendBase64: got %s, want %s", got, want)
+	}
+
+	buf := make([]byte, 0, 2*MaxOutput)
+	if n := testing.AllocsPerRun(100, func() { d.AppendHex(buf[:0]) }); n != 0 {
+		t.Errorf("AppendHex into a sized buffer allocated %v times", n)
+	}
+	if n := testing.AllocsPerRun(100, func() { _ = d.SumHex() }); n > 1 {
+		t.Errorf("SumHex allocated %v times, want at most 1", n)
+	}
+	if n := testing.AllocsPerRun(100, func() { _ = d.SumBase64() }); n > 1 {
+		t.Errorf("SumBase64 allocated %v times, want at most 1", n)
+	}
+}
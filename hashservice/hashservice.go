@@ -0,0 +1,186 @@
+// Package hashservice implements one-shot, streaming and keyed hashing
+// calls backed by this package, for environments in many languages that
+// want to share one BLAKE2s implementation behind an RPC boundary.
+//
+// It is independent of any RPC framework, and this repository ships no
+// schema or generated code, since it has no third-party dependencies. A
+// server adapts its own request and response messages to the types below
+// and calls Service. Errors caused by the request wrap ErrInvalidArgument,
+// those caused by the Service's limits wrap ErrResourceExhausted, and if the
+// caller's context ends its error is returned, so each can be mapped to a
+// status code with errors.Is; for gRPC, to codes.InvalidArgument,
+// codes.ResourceExhausted, and codes.Canceled or codes.DeadlineExceeded.
+// Errors receiving from a ChunkStream are returned as they are.
+package hashservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gtank/blake2s"
+)
+
+// Params are the parameters of a BLAKE2s instance. Unset fields take the
+// BLAKE2s defaults; a Size of zero means 32 bytes.
+type Params struct {
+	Key             []byte
+	Salt            []byte
+	Personalization []byte
+	Size            uint32
+}
+
+// HashRequest is a single message to hash.
+type HashRequest struct {
+	Params *Params
+	Data   []byte
+}
+
+// HashChunk is one piece of a streamed message. Params are read from the
+// first chunk only.
+type HashChunk struct {
+	Params *Params
+	Data   []byte
+}
+
+// HashResponse is a digest and the number of message bytes hashed.
+type HashResponse struct {
+	Digest []byte
+	Length uint64
+}
+
+// ChunkStream is the receiving half of a client stream, such as a gRPC
+// server stream wrapped to return HashChunks.
+type ChunkStream interface {
+	Recv() (*HashChunk, error)
+}
+
+var (
+	// ErrInvalidArgument is wrapped by errors caused by the request, such
+	// as bad parameters.
+	ErrInvalidArgument = errors.New("hashservice: invalid argument")
+
+	// ErrResourceExhausted is wrapped by errors caused by the Service's
+	// limits, such as MaxInput.
+	ErrResourceExhausted = errors.New("hashservice: resource limit exceeded")
+
+	// ErrKeyRequired is returned by MAC when the request has no key.
+	ErrKeyRequired = fmt.Errorf("%w: MAC requires a key", ErrInvalidArgument)
+)
+
+// Service implements the Hasher RPCs.
+type Service struct {
+	// MaxInput, if nonzero, limits the bytes hashed per call, so clients
+	// can't tie up the server with unbounded streams.
+	MaxInput uint64
+
+	// Timeout, if nonzero, limits the time spent hashing per call, in
+	// addition to any deadline the caller set.
+	Timeout time.Duration
+}
+
+// guard returns the budget for one call, and a function releasing it.
+func (s *Service) guard(ctx context.Context) (*blake2s.Guard, context.CancelFunc) {
+	cancel := context.CancelFunc(func() {})
+	if s.Timeout != 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+	}
+	return blake2s.NewGuard(ctx, s.MaxInput), cancel
+}
+
+func (s *Service) newDigest(g *blake2s.Guard, p *Params) (*blake2s.Digest, error) {
+	if p == nil {
+		p = &Params{}
+	}
+	size := int(p.Size)
+	if size == 0 {
+		size = blake2s.MaxOutput
+	}
+	opts := []blake2s.Option{
+		blake2s.WithKey(p.Key),
+		blake2s.WithSalt(p.Salt),
+		blake2s.WithPersonalization(p.Personalization),
+		blake2s.WithSize(size),
+		blake2s.WithGuard(g),
+	}
+	d, err := blake2s.New(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgument, err)
+	}
+	return d, nil
+}
+
+// write hashes data, classifying a rejected write as exhausting the
+// Service's limits unless the caller's context ended.
+func write(ctx context.Context, d *blake2s.Digest, data []byte) error {
+	if _, err := d.Write(data); err != nil {
+		if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrResourceExhausted, err)
+	}
+	return nil
+}
+
+func response(d *blake2s.Digest) *HashResponse {
+	return &HashResponse{Digest: d.Sum(nil), Length: d.BytesWritten()}
+}
+
+// Hash implements the Hash RPC, a one-shot hash of a single message.
+func (s *Service) Hash(ctx context.Context, req *HashRequest) (*HashResponse, error) {
+	g, cancel := s.guard(ctx)
+	defer cancel()
+	d, err := s.newDigest(g, req.Params)
+	if err != nil {
+		return nil, err
+	}
+	if err := write(ctx, d, req.Data); err != nil {
+		return nil, err
+	}
+	return response(d), nil
+}
+
+// MAC implements the MAC RPC, a keyed hash. It fails if no key is given.
+func (s *Service) MAC(ctx context.Context, req *HashRequest) (*HashResponse, error) {
+	if req.Params == nil || len(req.Params.Key) == 0 {
+		return nil, ErrKeyRequired
+	}
+	return s.Hash(ctx, req)
+}
+
+// HashStream implements the HashStream RPC, hashing a message sent as a
+// stream of chunks.
+func (s *Service) HashStream(ctx context.Context, stream ChunkStream) (*HashResponse, error) {
+	g, cancel := s.guard(ctx)
+	defer cancel()
+	var d *blake2s.Digest
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if d == nil {
+			if d, err = s.newDigest(g, chunk.Params); err != nil {
+				return nil, err
+			}
+		}
+		if err := write(ctx, d, chunk.Data); err != nil {
+			return nil, err
+		}
+	}
+	if d == nil {
+		var err error
+		if d, err = s.newDigest(g, nil); err != nil {
+			return nil, err
+		}
+	}
+	return response(d), nil
+}
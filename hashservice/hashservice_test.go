@@ -0,0 +1,105 @@
+package hashservice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gtank/blake2s"
+)
+
+type sliceStream []*HashChunk
+
+func (s *sliceStream) Recv() (*HashChunk, error) {
+	if len(*s) == 0 {
+		return nil, io.EOF
+	}
+	c := (*s)[0]
+	*s = (*s)[1:]
+	return c, nil
+}
+
+func TestHash(t *testing.T) {
+	s := &Service{}
+	ctx := context.Background()
+	params := &Params{Salt: []byte("salt"), Size: 16}
+
+	one, err := s.Hash(ctx, &HashRequest{Params: params, Data: []byte("hello world")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := blake2s.NewDigest(nil, []byte("salt"), nil, 16)
+	expected.Write([]byte("hello world"))
+	if !bytes.Equal(one.Digest, expected.Sum(nil)) || one.Length != 11 {
+		t.Errorf("unexpected response %+v", one)
+	}
+
+	stream := &sliceStream{{Params: params, Data: []byte("hello")}, {Data: []byte(" world")}}
+	streamed, err := s.HashStream(ctx, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(streamed.Digest, one.Digest) {
+		t.Error("streamed and one-shot hashes differ")
+	}
+}
+
+func TestMAC(t *testing.T) {
+	s := &Service{}
+	if _, err := s.MAC(context.Background(), &HashRequest{Data: []byte("x")}); err != ErrKeyRequired {
+		t.Errorf("expected ErrKeyRequired, got %v", err)
+	}
+	resp, err := s.MAC(context.Background(), &HashRequest{Params: &Params{Key: []byte("key")}, Data: []byte("x")})
+	if err != nil || len(resp.Digest) != blake2s.MaxOutput {
+		t.Errorf("unexpected MAC response %+v, %v", resp, err)
+	}
+}
+
+func TestMaxInput(t *testing.T) {
+	s := &Service{MaxInput: 4}
+	stream := &sliceStream{{Data: []byte("abc")}, {Data: []byte("de")}}
+	_, err := s.HashStream(context.Background(), stream)
+	if !errors.Is(err, blake2s.ErrMaxInput) || !errors.Is(err, ErrResourceExhausted) {
+		t.Errorf("expected ErrMaxInput and ErrResourceExhausted, got %v", err)
+	}
+}
+
+func TestInvalidArgument(t *testing.T) {
+	s := &Service{}
+	for _, req := range []*HashRequest{
+		{Params: &Params{Size: 33}},
+		{Params: &Params{Key: make([]byte, 33)}},
+	} {
+		if _, err := s.Hash(context.Background(), req); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("%+v: expected ErrInvalidArgument, got %v", req.Params, err)
+		}
+	}
+	if _, err := s.MAC(context.Background(), &HashRequest{}); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("MAC without a key: expected ErrInvalidArgument, got %v", err)
+	}
+}
+
+type endlessStream struct{}
+
+func (endlessStream) Recv() (*HashChunk, error) {
+	time.Sleep(time.Millisecond)
+	return &HashChunk{Data: make([]byte, 1024)}, nil
+}
+
+func TestTimeout(t *testing.T) {
+	s := &Service{Timeout: 20 * time.Millisecond}
+	_, err := s.HashStream(context.Background(), endlessStream{})
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrResourceExhausted) {
+		t.Errorf("expected DeadlineExceeded and ErrResourceExhausted, got %v", err)
+	}
+
+	// The caller's own deadline is reported as is.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := (&Service{}).HashStream(ctx, endlessStream{}); err != context.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package blake2s
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompressHooks(t *testing.T) {
+	var before, after int
+	d, err := New(WithKey([]byte("key")), WithCompressHooks(CompressHooks{
+		Before: func() { before++ },
+		After: func() {
+			if after != before-1 {
+				t.Error("hooks out of order")
+			}
+			after++
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d.Write(make([]byte, 130))
+	sum := d.Sum(nil)
+	// The key block and two message blocks during Write, then the final
+	// block in Sum.
+	if before != 4 || after != 4 {
+		t.Errorf("expected 4 compressions, got %d/%d", before, after)
+	}
+
+	plain, _ := New(WithKey([]byte("key")), WithCompressHooks(CompressHooks{}))
+	plain.Write(make([]byte, 130))
+	if !bytes.Equal(sum, plain.Sum(nil)) {
+		t.Error("hooks changed the digest")
+	}
+}
@@ -0,0 +1,162 @@
+// Package httpdigest verifies BLAKE2s digests of HTTP response bodies as
+// they are read.
+//
+// Digests are carried in the Content-Digest and Repr-Digest fields defined by
+// RFC 9530, under the algorithm key "blake2s-256". BLAKE2s is not in the IANA
+// registry for those fields, so both ends have to agree to use it.
+package httpdigest
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gtank/blake2s"
+)
+
+// Algorithm is the key used for BLAKE2s in Content-Digest and Repr-Digest.
+const Algorithm = "blake2s-256"
+
+var (
+	// ErrMismatch is returned from the final Read of a response body whose
+	// digest doesn't match.
+	ErrMismatch = errors.New("httpdigest: response body digest mismatch")
+
+	// ErrNoDigest is returned by RoundTrip when Require is set and there is
+	// no digest to verify the response against.
+	ErrNoDigest = errors.New("httpdigest: no BLAKE2s digest for response")
+)
+
+// FormatField returns a Content-Digest or Repr-Digest field value carrying a
+// BLAKE2s digest.
+func FormatField(digest []byte) string {
+	return Algorithm + "=:" + base64.StdEncoding.EncodeToString(digest) + ":"
+}
+
+// ParseField extracts the BLAKE2s digest from a Content-Digest or Repr-Digest
+// field value. It returns nil, nil if the field has no BLAKE2s member.
+func ParseField(value string) ([]byte, error) {
+	for _, member := range strings.Split(value, ",") {
+		key, v, ok := strings.Cut(strings.TrimSpace(member), "=")
+		if !ok || key != Algorithm {
+			continue
+		}
+		if len(v) < 2 || v[0] != ':' || v[len(v)-1] != ':' {
+			return nil, fmt.Errorf("httpdigest: malformed %s value %q", Algorithm, v)
+		}
+		digest, err := base64.StdEncoding.DecodeString(v[1 : len(v)-1])
+		if err != nil {
+			return nil, fmt.Errorf("httpdigest: malformed %s value: %v", Algorithm, err)
+		}
+		if len(digest) != blake2s.MaxOutput {
+			return nil, fmt.Errorf("httpdigest: %s digest has length %d", Algorithm, len(digest))
+		}
+		return digest, nil
+	}
+	return nil, nil
+}
+
+// Transport is an http.RoundTripper that verifies response bodies against a
+// BLAKE2s digest. The body is checked as it streams: the Read that would
+// return io.EOF returns ErrMismatch instead if the digest is wrong, so callers
+// must treat a body as untrusted until they have read it to the end.
+type Transport struct {
+	// Base performs the actual request. If nil, http.DefaultTransport is
+	// used.
+	Base http.RoundTripper
+
+	// Expected, if set, supplies an out-of-band digest for a request. A
+	// non-nil result takes precedence over any digest in the response.
+	Expected func(req *http.Request) []byte
+
+	// Require makes RoundTrip fail with ErrNoDigest for responses that can't
+	// be verified.
+	Require bool
+
+	// Options configure the Digest that checks each body, such as
+	// blake2s.WithGuard or blake2s.WithMaxInput to bound the work an
+	// untrusted server can cause. Once a limit is hit, Read returns its
+	// error. The digest size always matches the expected digest.
+	Options []blake2s.Option
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := t.expectedDigest(req, resp)
+	if err == nil && expected == nil && t.Require {
+		err = ErrNoDigest
+	}
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if expected == nil {
+		return resp, nil
+	}
+
+	opts := append(append([]blake2s.Option(nil), t.Options...), blake2s.WithSize(len(expected)))
+	d, err := blake2s.New(opts...)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	resp.Body = &verifyingBody{body: resp.Body, digest: d, expected: expected}
+	return resp, nil
+}
+
+func (t *Transport) expectedDigest(req *http.Request, resp *http.Response) ([]byte, error) {
+	if t.Expected != nil {
+		if digest := t.Expected(req); digest != nil {
+			return digest, nil
+		}
+	}
+	// If the transport transparently decompressed the body, neither field
+	// describes the bytes the caller will read.
+	if resp.Uncompressed {
+		return nil, nil
+	}
+	digest, err := ParseField(resp.Header.Get("Content-Digest"))
+	if digest != nil || err != nil {
+		return digest, err
+	}
+	// Repr-Digest covers the whole representation, which a partial response
+	// doesn't carry.
+	if resp.StatusCode == http.StatusPartialContent {
+		return nil, nil
+	}
+	return ParseField(resp.Header.Get("Repr-Digest"))
+}
+
+type verifyingBody struct {
+	body     io.ReadCloser
+	digest   *blake2s.Digest
+	expected []byte
+}
+
+func (b *verifyingBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if _, werr := b.digest.Write(p[:n]); werr != nil {
+		return n, werr
+	}
+	if err == io.EOF && subtle.ConstantTimeCompare(b.digest.Sum(nil), b.expected) != 1 {
+		err = ErrMismatch
+	}
+	return n, err
+}
+
+func (b *verifyingBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,87 @@
+package httpdigest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gtank/blake2s"
+)
+
+func sum(data []byte) []byte {
+	d, _ := blake2s.NewDigest(nil, nil, nil, blake2s.MaxOutput)
+	d.Write(data)
+	return d.Sum(nil)
+}
+
+func TestParseField(t *testing.T) {
+	digest := sum([]byte("hello"))
+	field := "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:, " + FormatField(digest)
+	parsed, err := ParseField(field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(parsed) != string(digest) {
+		t.Error("digest did not round-trip")
+	}
+
+	if d, err := ParseField("sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"); d != nil || err != nil {
+		t.Error("found a digest in a field without one")
+	}
+	for _, bad := range []string{"blake2s-256=abc", "blake2s-256=:!!:", "blake2s-256=:AAAA:"} {
+		if _, err := ParseField(bad); err == nil {
+			t.Errorf("accepted %q", bad)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	body := []byte("the quick brown fox")
+	var field string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if field != "" {
+			w.Header().Set("Content-Digest", field)
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		field     string
+		transport *Transport
+		readErr   error
+		tripErr   error
+	}{
+		{FormatField(sum(body)), &Transport{}, nil, nil},
+		{FormatField(sum([]byte("other"))), &Transport{}, ErrMismatch, nil},
+		{"", &Transport{}, nil, nil},
+		{"", &Transport{Require: true}, nil, ErrNoDigest},
+		{"", &Transport{Expected: func(*http.Request) []byte { return sum(body) }}, nil, nil},
+		{FormatField(sum(body)), &Transport{Expected: func(*http.Request) []byte { return sum(nil) }}, ErrMismatch, nil},
+		{FormatField(sum(body)), &Transport{Options: []blake2s.Option{blake2s.WithMaxInput(5)}}, blake2s.ErrMaxInput, nil},
+		{FormatField(sum(body)), &Transport{Options: []blake2s.Option{blake2s.WithGuard(blake2s.NewGuard(context.Background(), 5))}}, blake2s.ErrMaxInput, nil},
+		{FormatField(sum(body)), &Transport{Options: []blake2s.Option{blake2s.WithMaxInput(100), blake2s.WithSize(16)}}, nil, nil},
+	}
+	for i, test := range tests {
+		field = test.field
+		client := &http.Client{Transport: test.transport}
+		resp, err := client.Get(server.URL)
+		if test.tripErr != nil {
+			if err == nil {
+				resp.Body.Close()
+				t.Errorf("test %d: expected %v", i, test.tripErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != test.readErr {
+			t.Errorf("test %d: read returned %v, expected %v", i, err, test.readErr)
+		}
+	}
+}
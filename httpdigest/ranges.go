@@ -0,0 +1,224 @@
+package httpdigest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrChanged is returned by RangeHasher.Copy if the object's ETag changes
+// between ranges.
+var ErrChanged = errors.New("httpdigest: object changed while being fetched")
+
+// RangeHasher streams a remote object into a hash by issuing ranged GETs,
+// several at a time, and writing the ranges out in order. Only Parallel
+// ranges are held in memory at once, so objects of any size can be verified
+// without staging them on disk.
+type RangeHasher struct {
+	// Client performs the requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+
+	// ChunkSize is the length of each range. If zero, 4 MiB is used.
+	ChunkSize int64
+
+	// Parallel is the number of ranges fetched concurrently. If zero, 4 is
+	// used.
+	Parallel int
+
+	// Retries is the number of extra attempts made for a range whose
+	// request fails or returns a 5xx status.
+	Retries int
+}
+
+// Copy fetches url range by range and writes the object's contents to w,
+// typically a *blake2s.Digest, in order. It returns the number of bytes
+// written. Servers that ignore the Range header are handled by streaming the
+// whole response instead. If the server sends an ETag, later ranges are
+// requested with If-Match so that a concurrent update fails with ErrChanged
+// rather than producing a digest of mixed contents.
+func (h *RangeHasher) Copy(ctx context.Context, url string, w io.Writer) (int64, error) {
+	chunk := h.ChunkSize
+	if chunk <= 0 {
+		chunk = 4 << 20
+	}
+	parallel := h.Parallel
+	if parallel <= 0 {
+		parallel = 4
+	}
+
+	first, err := h.fetch(ctx, url, 0, chunk, "")
+	if err != nil {
+		return 0, err
+	}
+	if first.whole != nil {
+		defer first.whole.Close()
+		return io.Copy(w, first.whole)
+	}
+	written, err := w.Write(first.data)
+	if err != nil {
+		return int64(written), err
+	}
+
+	// Weak validators never match If-Match, so only strong ones are used.
+	etag := first.etag
+	if strings.HasPrefix(etag, "W/") {
+		etag = ""
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The launcher starts one fetch per range and queues its result
+	// channel; the queue's capacity bounds how many ranges are in flight or
+	// waiting to be written.
+	type result struct {
+		data []byte
+		err  error
+	}
+	queue := make(chan chan result, parallel)
+	go func() {
+		defer close(queue)
+		for start := chunk; start < first.total; start += chunk {
+			done := make(chan result, 1)
+			select {
+			case queue <- done:
+			case <-ctx.Done():
+				return
+			}
+			go func(start int64) {
+				r, err := h.fetch(ctx, url, start, chunk, etag)
+				if err == nil && r.whole != nil {
+					r.whole.Close()
+					err = fmt.Errorf("httpdigest: server stopped honoring ranges at offset %d", start)
+				}
+				done <- result{r.data, err}
+			}(start)
+		}
+	}()
+
+	total := int64(written)
+	for done := range queue {
+		r := <-done
+		if r.err != nil {
+			return total, r.err
+		}
+		n, err := w.Write(r.data)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return total, err
+	}
+	if total != first.total {
+		return total, fmt.Errorf("httpdigest: fetched %d bytes of a %d byte object", total, first.total)
+	}
+	return total, nil
+}
+
+type rangeResponse struct {
+	data  []byte
+	total int64  // size of the whole object
+	etag  string // as sent by the server, if any
+	whole io.ReadCloser
+}
+
+// fetch requests up to length bytes at start, retrying transient failures.
+func (h *RangeHasher) fetch(ctx context.Context, url string, start, length int64, etag string) (rangeResponse, error) {
+	var err error
+	for attempt := 0; attempt <= h.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * 100 * time.Millisecond):
+			case <-ctx.Done():
+				return rangeResponse{}, ctx.Err()
+			}
+		}
+		var r rangeResponse
+		var retry bool
+		r, retry, err = h.fetchOnce(ctx, url, start, length, etag)
+		if err == nil || !retry {
+			return r, err
+		}
+	}
+	return rangeResponse{}, err
+}
+
+func (h *RangeHasher) fetchOnce(ctx context.Context, url string, start, length int64, etag string) (r rangeResponse, retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return r, false, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, start+length-1))
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return r, ctx.Err() == nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK && start == 0:
+		r.whole = resp.Body
+		return r, false, nil
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && start == 0:
+		// Only an empty object has no byte 0.
+		resp.Body.Close()
+		return r, false, nil
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		resp.Body.Close()
+		return r, false, ErrChanged
+	case resp.StatusCode != http.StatusPartialContent:
+		resp.Body.Close()
+		return r, resp.StatusCode >= 500, fmt.Errorf("httpdigest: range request for %s: %s", url, resp.Status)
+	}
+	defer resp.Body.Close()
+
+	first, last, total, err := parseContentRange(resp.Header.Get("Content-Range"))
+	if err != nil {
+		return r, false, err
+	}
+	if first != start || last >= start+length {
+		return r, false, fmt.Errorf("httpdigest: asked for bytes from %d, got %d-%d", start, first, last)
+	}
+	r.data = make([]byte, last-first+1)
+	if _, err := io.ReadFull(resp.Body, r.data); err != nil {
+		return rangeResponse{}, ctx.Err() == nil, err
+	}
+	r.total = total
+	r.etag = resp.Header.Get("ETag")
+	if etag != "" && r.etag != "" && r.etag != etag {
+		return rangeResponse{}, false, ErrChanged
+	}
+	return r, false, nil
+}
+
+// parseContentRange parses a "bytes first-last/total" Content-Range value.
+// An unknown total ("*") is rejected, since the object's end couldn't be
+// found.
+func parseContentRange(v string) (first, last, total int64, err error) {
+	spec, ok := strings.CutPrefix(v, "bytes ")
+	rng, size, ok2 := strings.Cut(spec, "/")
+	from, to, ok3 := strings.Cut(rng, "-")
+	if !ok || !ok2 || !ok3 {
+		return 0, 0, 0, fmt.Errorf("httpdigest: malformed Content-Range %q", v)
+	}
+	first, err1 := strconv.ParseInt(from, 10, 64)
+	last, err2 := strconv.ParseInt(to, 10, 64)
+	total, err3 := strconv.ParseInt(size, 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || first < 0 || last < first || last >= total {
+		return 0, 0, 0, fmt.Errorf("httpdigest: malformed Content-Range %q", v)
+	}
+	return first, last, total, nil
+}
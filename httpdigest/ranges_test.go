@@ -0,0 +1,135 @@
+package httpdigest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gtank/blake2s"
+)
+
+func object(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i * 31)
+	}
+	return data
+}
+
+func serve(data []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "object", time.Time{}, bytes.NewReader(data))
+	}
+}
+
+func rangeSum(t *testing.T, h *RangeHasher, url string) ([]byte, int64, error) {
+	t.Helper()
+	d, _ := blake2s.NewDigest(nil, nil, nil, blake2s.MaxOutput)
+	n, err := h.Copy(context.Background(), url, d)
+	return d.Sum(nil), n, err
+}
+
+func TestRangeHasher(t *testing.T) {
+	for _, size := range []int{0, 1, 99, 100, 101, 1000, 1001} {
+		data := object(size)
+		var requests int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			serve(data)(w, r)
+		}))
+
+		got, n, err := rangeSum(t, &RangeHasher{ChunkSize: 100, Parallel: 3}, srv.URL)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("%d bytes: %v", size, err)
+		}
+		if n != int64(size) || !bytes.Equal(got, sum(data)) {
+			t.Errorf("%d bytes: wrong digest or length %d", size, n)
+		}
+		if want := int32((size + 99) / 100); size > 0 && requests != want {
+			t.Errorf("%d bytes: %d requests, expected %d", size, requests, want)
+		}
+	}
+}
+
+func TestRangeHasherRetries(t *testing.T) {
+	data := object(1000)
+	var mu sync.Mutex
+	failed := make(map[string]bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first attempt at each range.
+		mu.Lock()
+		first := !failed[r.Header.Get("Range")]
+		failed[r.Header.Get("Range")] = true
+		mu.Unlock()
+		if first {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		serve(data)(w, r)
+	}))
+	defer srv.Close()
+
+	if _, _, err := rangeSum(t, &RangeHasher{ChunkSize: 100}, srv.URL); err == nil {
+		t.Error("succeeded without retries")
+	}
+	got, _, err := rangeSum(t, &RangeHasher{ChunkSize: 100, Retries: 1}, srv.URL)
+	if err != nil || !bytes.Equal(got, sum(data)) {
+		t.Errorf("with retries: %v", err)
+	}
+}
+
+func TestRangeHasherNoRanges(t *testing.T) {
+	data := object(1000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	got, n, err := rangeSum(t, &RangeHasher{ChunkSize: 100}, srv.URL)
+	if err != nil || n != 1000 || !bytes.Equal(got, sum(data)) {
+		t.Errorf("server without range support: %d bytes, %v", n, err)
+	}
+}
+
+func TestRangeHasherChanged(t *testing.T) {
+	data := object(1000)
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"v1"`
+		if atomic.AddInt32(&calls, 1) > 1 {
+			etag = `"v2"`
+		}
+		if m := r.Header.Get("If-Match"); m != "" && !strings.Contains(m, etag) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "object", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	if _, _, err := rangeSum(t, &RangeHasher{ChunkSize: 100}, srv.URL); !errors.Is(err, ErrChanged) {
+		t.Errorf("expected ErrChanged, got %v", err)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	first, last, total, err := parseContentRange("bytes 100-199/1000")
+	if err != nil || first != 100 || last != 199 || total != 1000 {
+		t.Errorf("got %d-%d/%d, %v", first, last, total, err)
+	}
+	for _, bad := range []string{"", "bytes */1000", "bytes 0-99/*", "bytes 5-4/10", "bytes 0-10/10", "items 0-1/2"} {
+		if _, _, _, err := parseContentRange(bad); err == nil {
+			t.Errorf("accepted %q", bad)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package blake2s
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// InspectParameterBlock decodes a marshaled 32-byte BLAKE2s parameter block
+// and returns a field-by-field description of it, along with warnings about
+// values that are invalid or unusual. It is meant for debugging
+// interoperability with other BLAKE2 implementations; the block does not
+// need to be one this package would accept.
+func InspectParameterBlock(block []byte) (report string, warnings []string, err error) {
+	if len(block) != 32 {
+		return "", nil, errors.New("blake2s: parameter block must be 32 bytes")
+	}
+	var p ParameterBlock
+	p.Unmarshal(block)
+
+	var b strings.Builder
+	field := func(name string, format string, args ...interface{}) {
+		fmt.Fprintf(&b, "%-16s %s\n", name, fmt.Sprintf(format, args...))
+	}
+	field("digest length", "%d", p.DigestSize)
+	field("key length", "%d", p.KeyLength)
+	field("fanout", "%d", p.Fanout)
+	field("depth", "%d", p.Depth)
+	field("leaf length", "%d", p.LeafLength)
+	field("node offset", "%d", p.NodeOffset)
+	field("xof length", "%d", p.XOFLength)
+	field("node depth", "%d", p.NodeDepth)
+	field("inner length", "%d", p.InnerLength)
+	field("salt", "%s", describeBytes(p.Salt[:]))
+	field("personalization", "%s", describeBytes(p.Personalization[:]))
+
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+	switch {
+	case p.DigestSize == 0 || p.DigestSize > MaxOutput:
+		warn("digest length %d is outside 1-%d", p.DigestSize, MaxOutput)
+	case p.DigestSize < 16:
+		warn("digest length %d gives under 64 bits of collision resistance", p.DigestSize)
+	}
+	if p.KeyLength > KeyLength {
+		warn("key length %d is over the maximum of %d", p.KeyLength, KeyLength)
+	}
+	// BLAKE2X output nodes are the one legitimate use of depth 0.
+	xofNode := p.XOFLength != 0 && p.Depth == 0
+	if xofNode && (p.Fanout != 0 || p.InnerLength != MaxOutput) {
+		warn("BLAKE2X output nodes use fanout 0 and inner length %d", MaxOutput)
+	}
+	if p.Depth == 0 && !xofNode {
+		warn("depth 0 is invalid; sequential mode uses 1")
+	}
+	if p.Depth == 1 {
+		if p.Fanout != 1 {
+			warn("fanout %d with depth 1; sequential mode uses fanout 1", p.Fanout)
+		}
+		if p.LeafLength != 0 || p.NodeOffset != 0 || p.NodeDepth != 0 || p.InnerLength != 0 {
+			warn("tree fields are set but depth is 1; sequential mode leaves them zero")
+		}
+	}
+	if p.Depth > 1 {
+		if p.NodeDepth >= p.Depth {
+			warn("node depth %d is not below the tree depth %d", p.NodeDepth, p.Depth)
+		}
+		if p.InnerLength == 0 || p.InnerLength > MaxOutput {
+			warn("inner length %d is outside 1-%d for a tree", p.InnerLength, MaxOutput)
+		}
+	}
+	return b.String(), warnings, nil
+}
+
+// describeBytes formats a salt or personalization field as hex, followed by
+// its text if it looks like zero-padded printable ASCII.
+func describeBytes(b []byte) string {
+	s := hex.EncodeToString(b)
+	text := strings.TrimRight(string(b), "\x00")
+	if text == "" {
+		return s
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] < 0x20 || text[i] > 0x7e {
+			return s
+		}
+	}
+	return fmt.Sprintf("%s (%q)", s, text)
+}
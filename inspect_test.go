@@ -0,0 +1,74 @@
+package blake2s
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInspectParameterBlock(t *testing.T) {
+	p := &ParameterBlock{
+		DigestSize: 32,
+		KeyLength:  0,
+		Fanout:     1,
+		Depth:      1,
+	}
+	copy(p.Personalization[:], "persona")
+	block := p.Marshal()
+	report, warnings, err := InspectParameterBlock(block[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("warnings for a plain sequential block: %v", warnings)
+	}
+	for _, line := range []string{
+		"digest length    32\n",
+		"fanout           1\n",
+		"salt             0000000000000000\n",
+		"personalization  706572736f6e6100 (\"persona\")\n",
+	} {
+		if !strings.Contains(report, line) {
+			t.Errorf("report lacks %q:\n%s", line, report)
+		}
+	}
+
+	var roundTrip ParameterBlock
+	if err := roundTrip.Unmarshal(block[:]); err != nil || roundTrip != *p {
+		t.Error("unmarshal is not the inverse of marshal")
+	}
+
+	for _, tc := range []struct {
+		p    ParameterBlock
+		warn string
+	}{
+		{ParameterBlock{DigestSize: 33, Fanout: 1, Depth: 1}, "digest length 33"},
+		{ParameterBlock{DigestSize: 8, Fanout: 1, Depth: 1}, "collision resistance"},
+		{ParameterBlock{DigestSize: 32, KeyLength: 40, Fanout: 1, Depth: 1}, "key length 40"},
+		{ParameterBlock{DigestSize: 32, Fanout: 1}, "depth 0"},
+		{ParameterBlock{DigestSize: 32, Fanout: 2, Depth: 1}, "fanout 2 with depth 1"},
+		{ParameterBlock{DigestSize: 32, Fanout: 1, Depth: 1, NodeOffset: 3}, "tree fields"},
+		{ParameterBlock{DigestSize: 32, Fanout: 2, Depth: 2, NodeDepth: 2, InnerLength: 32}, "node depth 2"},
+		{ParameterBlock{DigestSize: 32, Fanout: 2, Depth: 2}, "inner length 0"},
+		{ParameterBlock{DigestSize: 32, Fanout: 1, XOFLength: 100, InnerLength: 32}, "BLAKE2X"},
+	} {
+		block := tc.p.Marshal()
+		_, warnings, err := InspectParameterBlock(block[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(strings.Join(warnings, "\n"), tc.warn) {
+			t.Errorf("%+v: expected a warning about %q, got %v", tc.p, tc.warn, warnings)
+		}
+	}
+
+	// A BLAKE2X output node is not flagged for its depth of 0.
+	node := ParameterBlock{DigestSize: 32, LeafLength: 32, XOFLength: 100, InnerLength: 32}
+	block = node.Marshal()
+	if _, warnings, _ := InspectParameterBlock(block[:]); len(warnings) != 0 {
+		t.Errorf("warnings for a BLAKE2X output node: %v", warnings)
+	}
+
+	if _, _, err := InspectParameterBlock(make([]byte, 31)); err == nil {
+		t.Error("accepted a short block")
+	}
+}
@@ -0,0 +1,223 @@
+// Package journal persists digest records in an append-only file.
+//
+// Each record is framed with its length and a BLAKE2s integrity tag, so a
+// record torn by a crash mid-write is detected on the next Open and cut off,
+// leaving every record before it intact. The tag protects against torn
+// writes and bit rot, not against someone who can rewrite the file.
+//
+// On disk, each record is:
+//
+//	length  uint32, little endian, of the payload
+//	payload uvarint name length, name, uvarint digest length, digest
+//	tag     16-byte BLAKE2s of length and payload
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/gtank/blake2s"
+)
+
+const (
+	tagSize    = 16
+	maxPayload = 1 << 20
+)
+
+var persona = []byte("journal1")
+
+// A Record associates a name with a digest.
+type Record struct {
+	Name   string
+	Digest []byte
+}
+
+// SyncPolicy controls when a Journal calls fsync.
+type SyncPolicy int
+
+const (
+	// SyncOnClose syncs only when the journal is closed. A crash may lose
+	// recent records but never corrupts earlier ones.
+	SyncOnClose SyncPolicy = iota
+	// SyncEveryRecord syncs after each Append, so an Append that returns
+	// successfully survives a crash.
+	SyncEveryRecord
+	// SyncNever leaves flushing entirely to the operating system.
+	SyncNever
+)
+
+func tag(frame []byte) []byte {
+	d, err := blake2s.NewDigest(nil, nil, persona, tagSize)
+	if err != nil {
+		panic(err)
+	}
+	d.Write(frame)
+	return d.Sum(nil)
+}
+
+func encode(r Record) ([]byte, error) {
+	if len(r.Name)+len(r.Digest)+2*binary.MaxVarintLen64 > maxPayload {
+		return nil, errors.New("journal: record too large")
+	}
+	frame := make([]byte, 4, 4+len(r.Name)+len(r.Digest)+2*binary.MaxVarintLen64+tagSize)
+	frame = binary.AppendUvarint(frame, uint64(len(r.Name)))
+	frame = append(frame, r.Name...)
+	frame = binary.AppendUvarint(frame, uint64(len(r.Digest)))
+	frame = append(frame, r.Digest...)
+	binary.LittleEndian.PutUint32(frame, uint32(len(frame)-4))
+	return append(frame, tag(frame)...), nil
+}
+
+func decodePayload(payload []byte) (Record, bool) {
+	nameLen, n := binary.Uvarint(payload)
+	if n <= 0 || nameLen > uint64(len(payload)-n) {
+		return Record{}, false
+	}
+	payload = payload[n:]
+	name := string(payload[:nameLen])
+	payload = payload[nameLen:]
+
+	digestLen, n := binary.Uvarint(payload)
+	if n <= 0 || digestLen != uint64(len(payload)-n) {
+		return Record{}, false
+	}
+	return Record{Name: name, Digest: bytes.Clone(payload[n:])}, true
+}
+
+// Replay reads records from r until the end of the stream or the first
+// damaged record. It returns the intact records and the number of bytes they
+// occupy, which is where the next record should be written. Damage is not an
+// error; only a failure to read is.
+func Replay(r io.Reader) ([]Record, int64, error) {
+	br := bufio.NewReader(r)
+	var records []Record
+	var good int64
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return records, good, nil
+			}
+			return nil, 0, err
+		}
+		length := binary.LittleEndian.Uint32(header[:])
+		if length > maxPayload {
+			return records, good, nil
+		}
+
+		frame := make([]byte, 4+int(length)+tagSize)
+		copy(frame, header[:])
+		if _, err := io.ReadFull(br, frame[4:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return records, good, nil
+			}
+			return nil, 0, err
+		}
+
+		body, stored := frame[:4+length], frame[4+length:]
+		if subtle.ConstantTimeCompare(tag(body), stored) != 1 {
+			return records, good, nil
+		}
+		record, ok := decodePayload(body[4:])
+		if !ok {
+			return records, good, nil
+		}
+		records = append(records, record)
+		good += int64(len(frame))
+	}
+}
+
+// A Journal is an open journal file.
+type Journal struct {
+	f      *os.File
+	policy SyncPolicy
+}
+
+// Open opens or creates the journal at path, replays it, and cuts off any
+// damaged tail so new records follow the last intact one. It returns the
+// intact records. A new journal is readable only by its owner, since
+// records may describe private files.
+//
+// The file is opened for appending and each record is written with a single
+// write, so several processes may append to the same journal as long as no
+// Open runs concurrently with an Append; cutting off a damaged tail could
+// otherwise remove a record still being written.
+func Open(path string, policy SyncPolicy) (*Journal, []Record, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, good, err := Replay(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if err := f.Truncate(good); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return &Journal{f: f, policy: policy}, records, nil
+}
+
+// Append writes a record in a single write call.
+func (j *Journal) Append(r Record) error {
+	frame, err := encode(r)
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(frame); err != nil {
+		return err
+	}
+	if j.policy == SyncEveryRecord {
+		return j.f.Sync()
+	}
+	return nil
+}
+
+// Compact rewrites the journal to hold only records, typically the latest
+// record for each name. The file is rewritten in place with a single write,
+// so handles other processes have open stay valid, but like Open it must not
+// run concurrently with an Append. A crash part way through can lose
+// records, so it suits journals, such as caches, whose records can be
+// recreated.
+func (j *Journal) Compact(records []Record) error {
+	var buf []byte
+	for _, r := range records {
+		frame, err := encode(r)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, frame...)
+	}
+	if err := j.f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := j.f.Write(buf); err != nil {
+		return err
+	}
+	if j.policy != SyncNever {
+		return j.f.Sync()
+	}
+	return nil
+}
+
+// Sync flushes appended records to stable storage regardless of policy.
+func (j *Journal) Sync() error {
+	return j.f.Sync()
+}
+
+// Close syncs the journal, unless the policy is SyncNever, and closes it.
+func (j *Journal) Close() error {
+	if j.policy != SyncNever {
+		if err := j.f.Sync(); err != nil {
+			j.f.Close()
+			return err
+		}
+	}
+	return j.f.Close()
+}
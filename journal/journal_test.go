@@ -0,0 +1,127 @@
+package journal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testRecords = []Record{
+	{Name: "a.txt", Digest: bytes.Repeat([]byte{1}, 32)},
+	{Name: "", Digest: nil},
+	{Name: "dir/b.txt", Digest: []byte{2, 3}},
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j, records, err := Open(path, SyncEveryRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Fatalf("new journal has %d records", len(records))
+	}
+	for _, r := range testRecords {
+		if err := j.Append(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	j, records, err = Open(path, SyncOnClose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	if len(records) != len(testRecords) {
+		t.Fatalf("expected %d records, got %d", len(testRecords), len(records))
+	}
+	for i := range records {
+		if records[i].Name != testRecords[i].Name || !bytes.Equal(records[i].Digest, testRecords[i].Digest) {
+			t.Errorf("record %d: got %v, want %v", i, records[i], testRecords[i])
+		}
+	}
+}
+
+func TestTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j, _, _ := Open(path, SyncNever)
+	for _, r := range testRecords {
+		j.Append(r)
+	}
+	j.Close()
+
+	full, _ := os.ReadFile(path)
+	for cut := 1; cut < 8; cut++ {
+		if err := os.WriteFile(path, full[:len(full)-cut], 0644); err != nil {
+			t.Fatal(err)
+		}
+		j, records, err := Open(path, SyncNever)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != len(testRecords)-1 {
+			t.Errorf("cut %d: expected %d records, got %d", cut, len(testRecords)-1, len(records))
+		}
+		// New records must follow the last intact one.
+		j.Append(Record{Name: "after crash"})
+		j.Close()
+		_, records, _ = Open(path, SyncNever)
+		if len(records) != len(testRecords) || records[len(records)-1].Name != "after crash" {
+			t.Errorf("cut %d: append after recovery failed: %v", cut, records)
+		}
+	}
+}
+
+func TestCorruptRecord(t *testing.T) {
+	var buf bytes.Buffer
+	for _, r := range testRecords {
+		frame, _ := encode(r)
+		buf.Write(frame)
+	}
+	data := buf.Bytes()
+	data[6] ^= 0xFF
+
+	records, good, err := Replay(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 || good != 0 {
+		t.Errorf("corrupt first record replayed: %v", records)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j, _, err := Open(path, SyncOnClose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range testRecords {
+		j.Append(r)
+	}
+	if err := j.Compact(testRecords[2:]); err != nil {
+		t.Fatal(err)
+	}
+	// Appends continue after the compacted records.
+	if err := j.Append(testRecords[0]); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("journal mode %v, %v; want 0600", info.Mode(), err)
+	}
+
+	j, records, err := Open(path, SyncOnClose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	if len(records) != 2 || records[0].Name != "dir/b.txt" || records[1].Name != "a.txt" {
+		t.Errorf("unexpected records after Compact %v", records)
+	}
+}
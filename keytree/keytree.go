@@ -0,0 +1,172 @@
+// Package keytree derives a hierarchy of purpose-specific keys from a single
+// master key.
+//
+// Each node's key is the keyed BLAKE2s hash of the child's label under its
+// parent's key, so a key can be handed to a component without exposing its
+// parent or siblings, and the whole tree can be rebuilt from the master key
+// and the labels. The labels alone are the tree's public structure: it can be
+// saved as JSON and reloaded without any key ever being serialized.
+package keytree
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+
+	"github.com/gtank/blake2s"
+)
+
+var persona = []byte("keytree1")
+
+// KeySize is the length of every derived key.
+const KeySize = blake2s.KeyLength
+
+// Derive returns the key at path under master without building a Tree.
+func Derive(master []byte, path ...string) ([]byte, error) {
+	if err := checkMaster(master); err != nil {
+		return nil, err
+	}
+	key := append([]byte(nil), master...)
+	for _, label := range path {
+		key = deriveChild(key, label)
+	}
+	return key, nil
+}
+
+func checkMaster(master []byte) error {
+	if len(master) == 0 || len(master) > blake2s.KeyLength {
+		return errors.New("keytree: master key must be 1 to 32 bytes")
+	}
+	return nil
+}
+
+// deriveChild hashes the length-prefixed label under the parent key. The
+// prefix keeps labels from running into each other; each level being a
+// separate keyed hash keeps paths from doing so.
+func deriveChild(parent []byte, label string) []byte {
+	d, err := blake2s.NewDigest(parent, nil, persona, KeySize)
+	if err != nil {
+		panic(err) // parent is always a valid key
+	}
+	var n [binary.MaxVarintLen64]byte
+	d.Write(n[:binary.PutUvarint(n[:], uint64(len(label)))])
+	d.Write([]byte(label))
+	return d.Sum(nil)
+}
+
+// A Tree is a master key and the nodes derived from it so far.
+type Tree struct {
+	root Node
+}
+
+// A Node is a position in a Tree, with its derived key.
+type Node struct {
+	label    string
+	key      []byte
+	parent   *Node
+	children []*Node
+}
+
+// New returns a Tree with only a root, whose key is master.
+func New(master []byte) (*Tree, error) {
+	if err := checkMaster(master); err != nil {
+		return nil, err
+	}
+	return &Tree{root: Node{key: append([]byte(nil), master...)}}, nil
+}
+
+// Root returns the root of the tree.
+func (t *Tree) Root() *Node {
+	return &t.root
+}
+
+// Lookup returns the node at path, creating any missing nodes on the way.
+func (t *Tree) Lookup(path ...string) *Node {
+	n := &t.root
+	for _, label := range path {
+		n = n.Child(label)
+	}
+	return n
+}
+
+// Child returns the child of n with the given label, creating it if needed.
+func (n *Node) Child(label string) *Node {
+	for _, c := range n.children {
+		if c.label == label {
+			return c
+		}
+	}
+	c := &Node{label: label, key: deriveChild(n.key, label), parent: n}
+	n.children = append(n.children, c)
+	return c
+}
+
+// Children returns the children of n in the order they were created.
+func (n *Node) Children() []*Node {
+	return append([]*Node(nil), n.children...)
+}
+
+// Label returns the node's label, or "" for the root.
+func (n *Node) Label() string {
+	return n.label
+}
+
+// Path returns the labels from the root to n.
+func (n *Node) Path() []string {
+	var path []string
+	for ; n.parent != nil; n = n.parent {
+		path = append([]string{n.label}, path...)
+	}
+	return path
+}
+
+// Key returns a copy of the node's key.
+func (n *Node) Key() []byte {
+	return append([]byte(nil), n.key...)
+}
+
+// structure is the serialized form of a node: labels only.
+type structure struct {
+	Label    string       `json:"label,omitempty"`
+	Children []*structure `json:"children,omitempty"`
+}
+
+func (n *Node) structure() *structure {
+	s := &structure{Label: n.label}
+	for _, c := range n.children {
+		s.Children = append(s.Children, c.structure())
+	}
+	return s
+}
+
+// MarshalJSON encodes the labels of every node in the tree. No keys are
+// included.
+func (t *Tree) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.root.structure())
+}
+
+// Load rebuilds a tree from master and a structure produced by
+// Tree.MarshalJSON, rederiving every key.
+func Load(master []byte, data []byte) (*Tree, error) {
+	var s structure
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if s.Label != "" {
+		return nil, errors.New("keytree: root of structure has a label")
+	}
+	t, err := New(master)
+	if err != nil {
+		return nil, err
+	}
+	var build func(n *Node, s *structure)
+	build = func(n *Node, s *structure) {
+		for _, c := range s.Children {
+			if c != nil {
+				build(n.Child(c.Label), c)
+			}
+		}
+	}
+	build(&t.root, &s)
+	return t, nil
+}
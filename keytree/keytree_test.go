@@ -0,0 +1,95 @@
+package keytree
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var master = []byte("master key for the keytree tests")
+
+func TestDerive(t *testing.T) {
+	tree, err := New(master)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac := tree.Lookup("service", "mac")
+	if got := mac.Path(); !reflect.DeepEqual(got, []string{"service", "mac"}) {
+		t.Errorf("path %v", got)
+	}
+	direct, err := Derive(master, "service", "mac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(mac.Key(), direct) || len(direct) != KeySize {
+		t.Error("tree and Derive disagree")
+	}
+	if !bytes.Equal(tree.Root().Key(), master) {
+		t.Error("root key is not the master key")
+	}
+	if tree.Lookup("service") != mac.parent || len(tree.Root().Children()) != 1 {
+		t.Error("Lookup created duplicate nodes")
+	}
+
+	// Distinct paths give distinct keys, including ones that concatenate
+	// to the same string.
+	seen := make(map[string][]string)
+	for _, path := range [][]string{
+		{}, {"a"}, {"b"}, {"ab"}, {"a", "b"}, {"b", "a"}, {"", "ab"}, {"ab", ""}, {"a", "b", "c"},
+	} {
+		key, _ := Derive(master, path...)
+		if prev, ok := seen[string(key)]; ok {
+			t.Errorf("paths %q and %q share a key", prev, path)
+		}
+		seen[string(key)] = path
+	}
+
+	other, _ := Derive([]byte("another master key"), "service", "mac")
+	if bytes.Equal(other, direct) {
+		t.Error("different masters give the same key")
+	}
+
+	for _, bad := range [][]byte{nil, make([]byte, 33)} {
+		if _, err := New(bad); err == nil {
+			t.Errorf("accepted a %d byte master key", len(bad))
+		}
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	tree, _ := New(master)
+	tree.Lookup("db", "primary")
+	tree.Lookup("db", "replica")
+	tree.Lookup("webhooks")
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []*Node{tree.Root(), tree.Lookup("db", "primary")} {
+		if bytes.Contains(data, n.Key()) || strings.Contains(string(data), string(n.Key())) {
+			t.Fatal("serialized structure contains a key")
+		}
+	}
+
+	loaded, err := Load(master, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := json.Marshal(loaded)
+	if string(again) != string(data) {
+		t.Errorf("structure changed on reload:\n%s\n%s", data, again)
+	}
+	if !bytes.Equal(loaded.Lookup("db", "replica").Key(), tree.Lookup("db", "replica").Key()) {
+		t.Error("reloaded tree derives different keys")
+	}
+
+	if _, err := Load(master, []byte(`{"label":"x"}`)); err == nil {
+		t.Error("accepted a labeled root")
+	}
+	if _, err := Load(master, []byte(`{`)); err == nil {
+		t.Error("accepted malformed JSON")
+	}
+}
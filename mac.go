@@ -0,0 +1,76 @@
+package blake2s
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+// NewMACWithNonce constructs a keyed BLAKE2s instance producing a full-length
+// tag, with the nonce placed in the salt field of the parameter block. This
+// gives each message its own randomized MAC without any extra framing.
+//
+// The nonce MUST be unique for every message authenticated under the same
+// key. It need not be secret, but the verifier has to learn it somehow, so it
+// is typically sent alongside the tag. Repeating a nonce does not leak the
+// key, but it does remove the per-message randomization this function exists
+// to provide.
+func NewMACWithNonce(key []byte, nonce [SaltLength]byte) (*Digest, error) {
+	if len(key) == 0 {
+		return nil, errors.New("blake2s: MAC requires a key")
+	}
+	return NewDigest(key, nonce[:], nil, MaxOutput)
+}
+
+// SumShortMAC returns an n-byte keyed BLAKE2s tag for data, where n is 8, 12
+// or 16. It is meant for packet formats with tight tag budgets.
+//
+// The tag length is part of the BLAKE2s parameter block, so a short tag is
+// not a prefix of the full-length tag for the same key and message, and tags
+// of different lengths can't be traded for one another. Prefer this to
+// truncating a longer tag.
+//
+// Short tags are weaker. An attacker guessing at random forges a given
+// message with probability 2^-(8n) per attempt: 2^-64 for 8-byte tags, which
+// is only acceptable where verification failures are rate limited or the
+// key is short-lived. Use 16 bytes unless the format really can't afford it.
+func SumShortMAC(key, data []byte, n int) ([]byte, error) {
+	if n != 8 && n != 12 && n != 16 {
+		return nil, errors.New("blake2s: short MAC length must be 8, 12 or 16")
+	}
+	if len(key) == 0 {
+		return nil, errors.New("blake2s: MAC requires a key")
+	}
+	d, err := NewDigest(key, nil, nil, n)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Wipe()
+	d.Write(data)
+	return d.Sum(nil), nil
+}
+
+// VerifyShortMAC reports whether tag is the valid SumShortMAC tag for data,
+// with the tag length taken from len(tag). The comparison is constant time.
+func VerifyShortMAC(key, data, tag []byte) bool {
+	expected, err := SumShortMAC(key, data, len(tag))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(expected, tag) == 1
+}
+
+// VerifyMAC reports whether tag is the valid NewMAC(key, len(tag)) tag for
+// message. The comparison is constant time, and the expected tag and the
+// hash state, which hold key material, are zeroed before returning.
+func VerifyMAC(key, message, tag []byte) bool {
+	d, err := NewMAC(key, len(tag))
+	if err != nil {
+		return false
+	}
+	defer d.Wipe()
+	d.Write(message)
+
+	var expected [MaxOutput]byte
+	defer clear(expected[:])
+	return subtle.ConstantTimeCompare(d.Sum(expected[:0]), tag) == 1
+}
@@ -0,0 +1,110 @@
+package blake2s
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestMACWithNonce(t *testing.T) {
+	key, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	nonce := [SaltLength]byte{0, 1, 2, 3, 4, 5, 6, 7}
+	// Same as the 8-byte salt entry in testdata/blake2s-extras.json
+	expected, _ := hex.DecodeString("01b2226fac3b75d54baeaadacfd69596ee7f0702baebdfc3b03a5f6782ec9dc6")
+
+	d, err := NewMACWithNonce(key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expected, d.Sum(nil)) {
+		t.Errorf("nonce MAC mismatch: %x", d.Sum(nil))
+	}
+
+	nonce[0] = 0xFF
+	d, err = NewMACWithNonce(key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(expected, d.Sum(nil)) {
+		t.Error("different nonces produced the same tag")
+	}
+}
+
+func TestMACWithNonceRequiresKey(t *testing.T) {
+	_, err := NewMACWithNonce(nil, [SaltLength]byte{})
+	if err == nil {
+		t.Error("accepted a MAC with no key")
+	}
+}
+
+func TestShortMAC(t *testing.T) {
+	key := []byte("packet key")
+	data := []byte("packet")
+	for _, n := range []int{8, 12, 16} {
+		tag, err := SumShortMAC(key, data, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tag) != n {
+			t.Errorf("expected %d-byte tag, got %d", n, len(tag))
+		}
+		if !VerifyShortMAC(key, data, tag) {
+			t.Errorf("%d-byte tag did not verify", n)
+		}
+		tag[0] ^= 1
+		if VerifyShortMAC(key, data, tag) {
+			t.Errorf("altered %d-byte tag verified", n)
+		}
+	}
+
+	// Different lengths are independent, not truncations of each other.
+	short, _ := SumShortMAC(key, data, 8)
+	long, _ := SumShortMAC(key, data, 16)
+	if bytes.Equal(short, long[:8]) || VerifyShortMAC(key, data, long[:8]) {
+		t.Error("short tag is a truncation of a longer one")
+	}
+
+	for _, n := range []int{0, 4, 32} {
+		if _, err := SumShortMAC(key, data, n); err == nil {
+			t.Errorf("accepted length %d", n)
+		}
+	}
+	if _, err := SumShortMAC(nil, data, 16); err == nil {
+		t.Error("accepted an empty key")
+	}
+}
+
+func TestVerifyMAC(t *testing.T) {
+	key := []byte("mac key")
+	msg := []byte("message")
+	for _, size := range []int{1, 16, MaxOutput} {
+		d, err := NewMAC(key, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Write(msg)
+		tag := d.Sum(nil)
+		if !VerifyMAC(key, msg, tag) {
+			t.Errorf("%d-byte tag rejected", size)
+		}
+		tag[len(tag)-1] ^= 1
+		if VerifyMAC(key, msg, tag) {
+			t.Errorf("corrupted %d-byte tag accepted", size)
+		}
+	}
+
+	d, _ := NewMAC(key, 16)
+	d.Write(msg)
+	tag := d.Sum(nil)
+	for name, ok := range map[string]bool{
+		"wrong key":     VerifyMAC([]byte("other key"), msg, tag),
+		"wrong message": VerifyMAC(key, []byte("massage"), tag),
+		"no key":        VerifyMAC(nil, msg, tag),
+		"empty tag":     VerifyMAC(key, msg, nil),
+		"long tag":      VerifyMAC(key, msg, make([]byte, MaxOutput+1)),
+	} {
+		if ok {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
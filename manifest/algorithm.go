@@ -0,0 +1,98 @@
+package manifest
+
+import (
+	"fmt"
+	"hash"
+	"sync"
+
+	"github.com/gtank/blake2s"
+	"github.com/gtank/blake2s/blake2b"
+)
+
+// An Algorithm is a hash function manifests can name. Entries carry the
+// name of the algorithm that produced them, so manifests written with
+// different algorithms, or ones added later, stay verifiable.
+type Algorithm struct {
+	// Name identifies the algorithm in tagged manifest lines, as in
+	// "BLAKE2b-256 (path) = <hex>".
+	Name string
+
+	// MaxSize is the longest digest the algorithm produces, in bytes.
+	MaxSize int
+
+	// New returns an unkeyed hash producing size bytes, 1 <= size <=
+	// MaxSize.
+	New func(size int) (hash.Hash, error)
+
+	// NewKeyed, if set, returns a hash keyed with key producing size bytes,
+	// for MAC envelopes. Algorithms without it can't seal or verify
+	// envelopes.
+	NewKeyed func(key []byte, size int) (hash.Hash, error)
+}
+
+// DefaultAlgorithm is the algorithm of entries with an empty Algorithm
+// field, which includes every untagged manifest line.
+const DefaultAlgorithm = "BLAKE2s"
+
+var algorithms = struct {
+	sync.Mutex
+	byName map[string]Algorithm
+}{
+	byName: map[string]Algorithm{
+		"BLAKE2s": {
+			Name:    "BLAKE2s",
+			MaxSize: blake2s.MaxOutput,
+			New: func(size int) (hash.Hash, error) {
+				return blake2s.NewDigest(nil, nil, nil, size)
+			},
+			NewKeyed: func(key []byte, size int) (hash.Hash, error) {
+				return blake2s.NewDigest(key, nil, nil, size)
+			},
+		},
+		"BLAKE2b": {
+			Name:    "BLAKE2b",
+			MaxSize: blake2b.MaxOutput,
+			New: func(size int) (hash.Hash, error) {
+				return blake2b.NewDigest(nil, nil, nil, size)
+			},
+			NewKeyed: func(key []byte, size int) (hash.Hash, error) {
+				return blake2b.NewDigest(key, nil, nil, size)
+			},
+		},
+	},
+}
+
+// RegisterAlgorithm makes an algorithm available to Parse and the
+// verification functions. It panics if the name is already registered, and
+// is meant to be called from an init function.
+func RegisterAlgorithm(a Algorithm) {
+	algorithms.Lock()
+	defer algorithms.Unlock()
+	if _, dup := algorithms.byName[a.Name]; dup {
+		panic("manifest: algorithm " + a.Name + " registered twice")
+	}
+	algorithms.byName[a.Name] = a
+}
+
+// LookupAlgorithm returns the registered algorithm with the given name. The
+// empty name refers to DefaultAlgorithm.
+func LookupAlgorithm(name string) (Algorithm, bool) {
+	if name == "" {
+		name = DefaultAlgorithm
+	}
+	algorithms.Lock()
+	defer algorithms.Unlock()
+	a, ok := algorithms.byName[name]
+	return a, ok
+}
+
+func newHash(algorithm string, size int) (hash.Hash, error) {
+	a, ok := LookupAlgorithm(algorithm)
+	if !ok {
+		return nil, fmt.Errorf("manifest: unknown algorithm %q", algorithm)
+	}
+	if size < 1 || size > a.MaxSize {
+		return nil, fmt.Errorf("manifest: %s can't produce a %d byte digest", a.Name, size)
+	}
+	return a.New(size)
+}
@@ -0,0 +1,95 @@
+package manifest
+
+import (
+	"bytes"
+	"context"
+	"hash"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gtank/blake2s/blake2b"
+)
+
+func TestTaggedLines(t *testing.T) {
+	fsys := testFS()
+	b2b, _ := blake2b.NewDigest(nil, nil, nil, blake2b.MaxOutput)
+	b2b.Write([]byte("alpha"))
+	full := b2b.Sum(nil)
+	short, _ := blake2b.NewDigest(nil, nil, nil, 32)
+	short.Write([]byte("bravo"))
+	b2s, _ := HashFile(fsys, "odd\\name", 32)
+
+	m := &Manifest{Entries: []Entry{
+		{Path: "a.txt", Digest: full, Algorithm: "BLAKE2b"},
+		{Path: "dir/b.txt", Digest: short.Sum(nil), Algorithm: "BLAKE2b"},
+		{Path: "odd\\name", Digest: b2s, Algorithm: "BLAKE2s"},
+		{Path: "odd\\name", Digest: b2s},
+	}}
+	var buf bytes.Buffer
+	m.WriteTo(&buf)
+	lines := strings.Split(buf.String(), "\n")
+	for i, prefix := range []string{"BLAKE2b (a.txt) = ", "BLAKE2b-256 (dir/b.txt) = ", "\\BLAKE2s (odd\\\\name) = ", "\\" + FormatLine(Entry{Digest: b2s})} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d is %q, expected prefix %q", i, lines[i], prefix)
+		}
+	}
+
+	parsed, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, e := range parsed.Entries {
+		want := m.Entries[i]
+		if e.Path != want.Path || e.Algorithm != want.Algorithm || !bytes.Equal(e.Digest, want.Digest) {
+			t.Errorf("entry %d: got %+v, want %+v", i, e, want)
+		}
+	}
+
+	r, err := VerifyParallel(context.Background(), fsys, parsed, 2)
+	if err != nil || !r.Passed() {
+		t.Errorf("verification failed: %+v, %v", r, err)
+	}
+	fsys["a.txt"] = &fstest.MapFile{Data: []byte("changed")}
+	if r, _ := VerifyParallel(context.Background(), fsys, parsed, 2); r.Mismatched != 1 {
+		t.Errorf("BLAKE2b mismatch not detected: %+v", r)
+	}
+
+	for _, bad := range []string{
+		"MD5 (x) = d41d8cd98f00b204e9800998ecf8427e\n",
+		"BLAKE2b-7 (x) = 00\n",
+		"BLAKE2b-1024 (x) = 00\n",
+		"BLAKE2b-16 (x) = 00\n",
+		"BLAKE2s (x) = 00\n",
+		"BLAKE2s (x) 00\n",
+		" (x) = 00\n",
+		"BLAKE2s-8 () = 00\n",
+	} {
+		if _, err := Parse(strings.NewReader(bad)); err == nil {
+			t.Errorf("accepted %q", bad)
+		}
+	}
+}
+
+func TestRegisterAlgorithm(t *testing.T) {
+	RegisterAlgorithm(Algorithm{
+		Name:    "TEST-ALG",
+		MaxSize: 8,
+		New: func(size int) (hash.Hash, error) {
+			return blake2b.NewDigest(nil, nil, []byte("test"), size)
+		},
+	})
+	if _, err := Parse(strings.NewReader("TEST-ALG (x) = 0001020304050607\nTEST-ALG-32 (y) = 00010203\n")); err != nil {
+		t.Error(err)
+	}
+	if _, ok := LookupAlgorithm(""); !ok {
+		t.Error("no default algorithm")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate registration did not panic")
+		}
+	}()
+	RegisterAlgorithm(Algorithm{Name: "BLAKE2s"})
+}
@@ -0,0 +1,302 @@
+package manifest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/gtank/blake2s"
+)
+
+// ArchiveEntryName is the name of the manifest entry embedded in archives.
+const ArchiveEntryName = ".blake2s-manifest"
+
+var (
+	// ErrNoManifest is returned when verifying an archive that has no
+	// embedded manifest.
+	ErrNoManifest = errors.New("manifest: archive has no embedded manifest")
+
+	// ErrNotInManifest is reported for archive files the embedded manifest
+	// doesn't cover.
+	ErrNotInManifest = errors.New("manifest: file not listed in manifest")
+
+	// ErrDuplicateEntry is wrapped by the error for an archive with two
+	// entries of the same name, including two embedded manifests.
+	// Extraction tools disagree on which one wins, so the file that was
+	// verified might not be the one extracted.
+	ErrDuplicateEntry = errors.New("manifest: duplicate archive entry")
+)
+
+// entryHasher accumulates a manifest for files as they stream past.
+type entryHasher struct {
+	m       Manifest
+	name    string
+	current *blake2s.Digest
+	names   map[string]bool
+}
+
+// add records an entry name, failing if the archive already has one that
+// names the same path.
+func (h *entryHasher) add(name string) error {
+	if h.names == nil {
+		h.names = make(map[string]bool)
+	}
+	p := path.Clean(name)
+	if h.names[p] {
+		return fmt.Errorf("%s: %w", name, ErrDuplicateEntry)
+	}
+	h.names[p] = true
+	return nil
+}
+
+func (h *entryHasher) begin(name string) {
+	h.end()
+	h.name = name
+	h.current, _ = blake2s.NewDigest(nil, nil, nil, blake2s.MaxOutput)
+}
+
+func (h *entryHasher) Write(p []byte) (int, error) {
+	if h.current != nil {
+		h.current.Write(p)
+	}
+	return len(p), nil
+}
+
+func (h *entryHasher) end() {
+	if h.current != nil {
+		h.m.Entries = append(h.m.Entries, Entry{Path: h.name, Digest: h.current.Sum(nil)})
+		h.current = nil
+	}
+}
+
+func (h *entryHasher) manifestBytes() []byte {
+	h.end()
+	var buf bytes.Buffer
+	h.m.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// TarWriter writes a tar archive, hashing each regular file as it is
+// written and appending a manifest of them as the final entry on Close.
+type TarWriter struct {
+	tw *tar.Writer
+	h  entryHasher
+}
+
+// NewTarWriter returns a TarWriter writing to w.
+func NewTarWriter(w io.Writer) *TarWriter {
+	return &TarWriter{tw: tar.NewWriter(w)}
+}
+
+// WriteHeader behaves like tar.Writer.WriteHeader.
+func (w *TarWriter) WriteHeader(hdr *tar.Header) error {
+	if hdr.Name == ArchiveEntryName {
+		return fmt.Errorf("manifest: %s is reserved", ArchiveEntryName)
+	}
+	if err := w.h.add(hdr.Name); err != nil {
+		return err
+	}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	w.h.end()
+	if hdr.Typeflag == tar.TypeReg {
+		w.h.begin(hdr.Name)
+	}
+	return nil
+}
+
+// Write behaves like tar.Writer.Write.
+func (w *TarWriter) Write(p []byte) (int, error) {
+	n, err := w.tw.Write(p)
+	w.h.Write(p[:n])
+	return n, err
+}
+
+// Close writes the manifest entry and closes the archive. It does not close
+// the underlying writer.
+func (w *TarWriter) Close() error {
+	data := w.h.manifestBytes()
+	err := w.tw.WriteHeader(&tar.Header{
+		Name:     ArchiveEntryName,
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     int64(len(data)),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := w.tw.Write(data); err != nil {
+		return err
+	}
+	return w.tw.Close()
+}
+
+// VerifyTar reads a tar archive written by TarWriter in a single pass,
+// hashing each regular file as it streams past, and checks the results
+// against the embedded manifest at the end.
+func VerifyTar(r io.Reader) (*Result, error) {
+	tr := tar.NewReader(r)
+	var h entryHasher
+	var embedded *Manifest
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		h.end()
+		if err := h.add(hdr.Name); err != nil {
+			return nil, err
+		}
+		if hdr.Name == ArchiveEntryName {
+			if embedded, err = Parse(tr); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		h.begin(hdr.Name)
+		if _, err := io.Copy(&h, tr); err != nil {
+			return nil, err
+		}
+	}
+	h.end()
+	if embedded == nil {
+		return nil, ErrNoManifest
+	}
+	return compareManifests(embedded, &h.m), nil
+}
+
+// ZipWriter writes a zip archive, hashing each file as it is written and
+// adding a manifest of them as the final entry on Close.
+type ZipWriter struct {
+	zw *zip.Writer
+	h  entryHasher
+}
+
+// NewZipWriter returns a ZipWriter writing to w.
+func NewZipWriter(w io.Writer) *ZipWriter {
+	return &ZipWriter{zw: zip.NewWriter(w)}
+}
+
+// Create behaves like zip.Writer.Create.
+func (w *ZipWriter) Create(name string) (io.Writer, error) {
+	return w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+}
+
+// CreateHeader behaves like zip.Writer.CreateHeader. Directory entries are
+// not hashed.
+func (w *ZipWriter) CreateHeader(fh *zip.FileHeader) (io.Writer, error) {
+	if fh.Name == ArchiveEntryName {
+		return nil, fmt.Errorf("manifest: %s is reserved", ArchiveEntryName)
+	}
+	if err := w.h.add(fh.Name); err != nil {
+		return nil, err
+	}
+	fw, err := w.zw.CreateHeader(fh)
+	if err != nil {
+		return nil, err
+	}
+	w.h.end()
+	if fh.Mode().IsDir() {
+		return fw, nil
+	}
+	w.h.begin(fh.Name)
+	return io.MultiWriter(fw, &w.h), nil
+}
+
+// Close writes the manifest entry and closes the archive. It does not close
+// the underlying writer.
+func (w *ZipWriter) Close() error {
+	fw, err := w.zw.Create(ArchiveEntryName)
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(w.h.manifestBytes()); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// VerifyZip checks every file in a zip archive against its embedded
+// manifest, streaming each file through the hash.
+func VerifyZip(r io.ReaderAt, size int64) (*Result, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var h entryHasher
+	var embedded *Manifest
+	for _, f := range zr.File {
+		if err := h.add(f.Name); err != nil {
+			return nil, err
+		}
+		if f.Name != ArchiveEntryName && f.Mode().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		if f.Name == ArchiveEntryName {
+			embedded, err = Parse(rc)
+		} else {
+			h.begin(f.Name)
+			_, err = io.Copy(&h, rc)
+		}
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	h.end()
+	if embedded == nil {
+		return nil, ErrNoManifest
+	}
+	return compareManifests(embedded, &h.m), nil
+}
+
+// compareManifests checks the digests actually found against the expected
+// ones. Results follow the expected manifest's order, followed by any files
+// it doesn't list.
+func compareManifests(expected, found *Manifest) *Result {
+	byPath := make(map[string][]byte, len(found.Entries))
+	for _, e := range found.Entries {
+		byPath[e.Path] = e.Digest
+	}
+
+	var files []FileResult
+	listed := make(map[string]bool, len(expected.Entries))
+	for _, e := range expected.Entries {
+		listed[e.Path] = true
+		digest, ok := byPath[e.Path]
+		switch {
+		case !ok:
+			files = append(files, FileResult{Path: e.Path, Status: StatusMissing, Err: errors.New("not in archive")})
+		case e.Algorithm != "" && e.Algorithm != DefaultAlgorithm:
+			// Archive contents are only ever hashed with BLAKE2s.
+			files = append(files, FileResult{Path: e.Path, Status: StatusError, Err: fmt.Errorf("unsupported algorithm %s", e.Algorithm)})
+		case subtle.ConstantTimeCompare(digest, e.Digest) != 1:
+			files = append(files, FileResult{Path: e.Path, Status: StatusMismatch})
+		default:
+			files = append(files, FileResult{Path: e.Path, Status: StatusOK})
+		}
+	}
+	for _, e := range found.Entries {
+		if !listed[e.Path] {
+			files = append(files, FileResult{Path: e.Path, Status: StatusError, Err: ErrNotInManifest})
+		}
+	}
+	return summarize(files)
+}
@@ -0,0 +1,170 @@
+package manifest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+var archiveFiles = []struct {
+	name, body string
+}{
+	{"a.txt", "alpha"},
+	{"dir/b.txt", "bravo"},
+	{"empty", ""},
+}
+
+func writeTar(t *testing.T) []byte {
+	var buf bytes.Buffer
+	w := NewTarWriter(&buf)
+	w.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755})
+	for _, f := range archiveFiles {
+		if err := w.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(f.body))}); err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, f.body)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestTarRoundTrip(t *testing.T) {
+	r, err := VerifyTar(bytes.NewReader(writeTar(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Passed() || r.OK != len(archiveFiles) {
+		t.Errorf("unexpected result %+v", r)
+	}
+}
+
+func TestTarTampered(t *testing.T) {
+	data := writeTar(t)
+	i := bytes.Index(data, []byte("bravo"))
+	data[i] = 'B'
+
+	r, err := VerifyTar(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Mismatched != 1 || r.Files[1].Status != StatusMismatch {
+		t.Errorf("tampering not detected: %+v", r)
+	}
+}
+
+func TestTarWithoutManifest(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "x", Typeflag: tar.TypeReg, Size: 1})
+	tw.Write([]byte("x"))
+	tw.Close()
+	if _, err := VerifyTar(&buf); err != ErrNoManifest {
+		t.Errorf("expected ErrNoManifest, got %v", err)
+	}
+
+	w := NewTarWriter(io.Discard)
+	if err := w.WriteHeader(&tar.Header{Name: ArchiveEntryName}); err == nil {
+		t.Error("allowed writing the reserved name")
+	}
+}
+
+func TestZipRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewZipWriter(&buf)
+	for _, f := range archiveFiles {
+		fw, err := w.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(fw, f.body)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := VerifyZip(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Passed() || r.OK != len(archiveFiles) {
+		t.Errorf("unexpected result %+v", r)
+	}
+}
+
+func TestZipExtraFile(t *testing.T) {
+	// An archive whose manifest doesn't cover all of its files must fail.
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	fw, _ := zw.Create(ArchiveEntryName)
+	io.WriteString(fw, FormatLine(Entry{Path: "listed", Digest: make([]byte, 32)})+"\n")
+	fw, _ = zw.Create("unlisted")
+	io.WriteString(fw, "surprise")
+	zw.Close()
+
+	r, err := VerifyZip(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Missing != 1 || r.Errors != 1 || !errors.Is(r.Files[1].Err, ErrNotInManifest) {
+		t.Errorf("unexpected result %+v", r)
+	}
+}
+
+func TestDuplicateEntries(t *testing.T) {
+	entry := FormatLine(Entry{Path: "a.txt", Digest: make([]byte, 32)}) + "\n"
+	tarOf := func(files ...[2]string) []byte {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, f := range files {
+			tw.WriteHeader(&tar.Header{Name: f[0], Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(f[1]))})
+			io.WriteString(tw, f[1])
+		}
+		tw.Close()
+		return buf.Bytes()
+	}
+	zipOf := func(files ...[2]string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, f := range files {
+			fw, _ := zw.Create(f[0])
+			io.WriteString(fw, f[1])
+		}
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	for _, tc := range []struct {
+		name, path string
+		files      [][2]string
+	}{
+		{"file", "./a.txt", [][2]string{{"a.txt", "alpha"}, {"./a.txt", "evil"}, {ArchiveEntryName, entry}}},
+		{"manifest", ArchiveEntryName, [][2]string{{ArchiveEntryName, entry}, {"a.txt", "alpha"}, {ArchiveEntryName, ""}}},
+	} {
+		_, err := VerifyTar(bytes.NewReader(tarOf(tc.files...)))
+		if !errors.Is(err, ErrDuplicateEntry) || !strings.HasPrefix(err.Error(), tc.path+":") {
+			t.Errorf("tar with duplicate %s: got %v", tc.name, err)
+		}
+		data := zipOf(tc.files...)
+		_, err = VerifyZip(bytes.NewReader(data), int64(len(data)))
+		if !errors.Is(err, ErrDuplicateEntry) || !strings.HasPrefix(err.Error(), tc.path+":") {
+			t.Errorf("zip with duplicate %s: got %v", tc.name, err)
+		}
+	}
+
+	tw := NewTarWriter(io.Discard)
+	tw.WriteHeader(&tar.Header{Name: "a.txt", Typeflag: tar.TypeReg})
+	if err := tw.WriteHeader(&tar.Header{Name: "a.txt", Typeflag: tar.TypeReg}); !errors.Is(err, ErrDuplicateEntry) {
+		t.Errorf("TarWriter: got %v", err)
+	}
+	zw := NewZipWriter(io.Discard)
+	zw.Create("a.txt")
+	if _, err := zw.Create("a.txt"); !errors.Is(err, ErrDuplicateEntry) {
+		t.Errorf("ZipWriter: got %v", err)
+	}
+}
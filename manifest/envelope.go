@@ -0,0 +1,166 @@
+package manifest
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrTagMismatch is returned by Envelope.Verify when the tag is wrong
+	// and the envelope has no content digest to say why.
+	ErrTagMismatch = errors.New("manifest: envelope tag mismatch")
+
+	// ErrCorrupted is returned by Envelope.Verify when the data no longer
+	// matches the content digest it was sealed with.
+	ErrCorrupted = errors.New("manifest: data corrupted")
+
+	// ErrWrongKey is returned by Envelope.Verify when the data matches its
+	// content digest but not its tag, so the key differs from the one it
+	// was sealed with (or the tag itself was damaged).
+	ErrWrongKey = errors.New("manifest: tag mismatch on intact data, wrong key")
+)
+
+// An Envelope carries a MAC tag for some data and, optionally, an unkeyed
+// digest of the same data. The tag alone says only whether verification
+// succeeded; the content digest lets a failure be attributed to damaged data
+// or to a wrong key, which call for very different responses.
+//
+// The content digest is not secret: anyone holding the envelope can confirm
+// a guess of the data. Leave it out when the data is guessable and must stay
+// confidential.
+type Envelope struct {
+	Algorithm string // registered algorithm name
+	Tag       []byte // keyed digest of the data
+	Content   []byte // unkeyed digest of the data, as long as Tag, or nil
+}
+
+// SealEnvelope computes the envelope for data under key with the named
+// algorithm, or DefaultAlgorithm if algorithm is empty, at its longest
+// digest. It includes a content digest if withContent is set.
+func SealEnvelope(algorithm string, key, data []byte, withContent bool) (*Envelope, error) {
+	a, err := keyedAlgorithm(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	e := &Envelope{Algorithm: a.Name}
+	if e.Tag, err = mac(a, key, data, a.MaxSize); err != nil {
+		return nil, err
+	}
+	if withContent {
+		h, _ := a.New(a.MaxSize)
+		h.Write(data)
+		e.Content = h.Sum(nil)
+	}
+	return e, nil
+}
+
+// Verify checks data against the envelope under key. It returns nil if the
+// tag is valid, and otherwise ErrCorrupted, ErrWrongKey or, without a content
+// digest, ErrTagMismatch.
+func (e *Envelope) Verify(key, data []byte) error {
+	a, err := keyedAlgorithm(e.Algorithm)
+	if err != nil {
+		return err
+	}
+	tag, err := mac(a, key, data, len(e.Tag))
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(tag, e.Tag) == 1 {
+		return nil
+	}
+	if e.Content == nil {
+		return ErrTagMismatch
+	}
+	h, err := newHash(a.Name, len(e.Content))
+	if err != nil {
+		return err
+	}
+	h.Write(data)
+	if subtle.ConstantTimeCompare(h.Sum(nil), e.Content) != 1 {
+		return ErrCorrupted
+	}
+	return ErrWrongKey
+}
+
+// String encodes the envelope as its algorithm and tag length, such as
+// "BLAKE2s-256", then a colon and the hex tag, followed by a colon and the
+// hex content digest if there is one.
+func (e *Envelope) String() string {
+	name := e.Algorithm
+	if name == "" {
+		name = DefaultAlgorithm
+	}
+	s := name + "-" + strconv.Itoa(8*len(e.Tag)) + ":" + hex.EncodeToString(e.Tag)
+	if e.Content != nil {
+		s += ":" + hex.EncodeToString(e.Content)
+	}
+	return s
+}
+
+// ParseEnvelope decodes the output of Envelope.String. The algorithm must be
+// registered and support keys.
+func ParseEnvelope(s string) (*Envelope, error) {
+	id, rest, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, errors.New("manifest: malformed envelope")
+	}
+	a, size, err := lookupTag(id)
+	if err != nil {
+		return nil, fmt.Errorf("manifest: envelope: %v", err)
+	}
+	if a.NewKeyed == nil {
+		return nil, fmt.Errorf("manifest: envelope: %s has no keyed mode", a.Name)
+	}
+
+	tagHex, contentHex, hasContent := strings.Cut(rest, ":")
+	e := &Envelope{Algorithm: a.Name}
+	if e.Tag, err = decodeDigest(tagHex, size); err != nil {
+		return nil, err
+	}
+	if hasContent {
+		if e.Content, err = decodeDigest(contentHex, size); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+func decodeDigest(s string, size int) ([]byte, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != size {
+		return nil, errors.New("manifest: malformed envelope")
+	}
+	return b, nil
+}
+
+// keyedAlgorithm looks up an algorithm that can compute envelope tags.
+func keyedAlgorithm(name string) (Algorithm, error) {
+	a, ok := LookupAlgorithm(name)
+	if !ok {
+		return Algorithm{}, fmt.Errorf("manifest: unknown algorithm %q", name)
+	}
+	if a.NewKeyed == nil {
+		return Algorithm{}, fmt.Errorf("manifest: %s has no keyed mode", a.Name)
+	}
+	return a, nil
+}
+
+func mac(a Algorithm, key, data []byte, size int) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errors.New("manifest: MAC requires a key")
+	}
+	if size < 1 || size > a.MaxSize {
+		return nil, fmt.Errorf("manifest: %s can't produce a %d byte digest", a.Name, size)
+	}
+	h, err := a.NewKeyed(key, size)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(data)
+	return h.Sum(nil), nil
+}
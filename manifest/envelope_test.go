@@ -0,0 +1,88 @@
+package manifest
+
+import (
+	"bytes"
+	"hash"
+	"strings"
+	"testing"
+)
+
+func TestEnvelope(t *testing.T) {
+	key := []byte("the right key")
+	data := []byte("some data worth protecting")
+
+	for _, algorithm := range []string{"", "BLAKE2b"} {
+		e, err := SealEnvelope(algorithm, key, data, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err = ParseEnvelope(e.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		damaged := append([]byte(nil), data...)
+		damaged[3] ^= 1
+		for _, tc := range []struct {
+			name string
+			key  []byte
+			data []byte
+			want error
+		}{
+			{"valid", key, data, nil},
+			{"corrupted", key, damaged, ErrCorrupted},
+			{"wrong key", []byte("the wrong key"), data, ErrWrongKey},
+			{"both", []byte("the wrong key"), damaged, ErrCorrupted},
+		} {
+			if err := e.Verify(tc.key, tc.data); err != tc.want {
+				t.Errorf("%s %s: got %v, want %v", e.Algorithm, tc.name, err, tc.want)
+			}
+		}
+	}
+
+	bare, _ := SealEnvelope("", key, data, false)
+	if s := bare.String(); !strings.HasPrefix(s, "BLAKE2s-256:") || strings.Count(s, ":") != 1 {
+		t.Errorf("envelope without content digest: %s", s)
+	}
+	if err := bare.Verify([]byte("the wrong key"), data); err != ErrTagMismatch {
+		t.Errorf("got %v, want ErrTagMismatch", err)
+	}
+
+	s := bare.String()
+	tagHex := s[len("BLAKE2s-256:"):]
+	for _, bad := range []string{
+		"", "zz", tagHex, s[:len(s)-2], s + ":", s + ":00",
+		"MD5-256:" + tagHex,
+		"BLAKE2s-128:" + tagHex,
+		"BLAKE2s-512:" + tagHex + tagHex,
+	} {
+		if _, err := ParseEnvelope(bad); err == nil {
+			t.Errorf("ParseEnvelope(%q) succeeded", bad)
+		}
+	}
+
+	if _, err := SealEnvelope("BLAKE2s", nil, data, false); err == nil {
+		t.Error("sealed without a key")
+	}
+	if _, err := SealEnvelope("MD5", key, data, false); err == nil {
+		t.Error("sealed with an unknown algorithm")
+	}
+}
+
+func TestEnvelopeUnkeyedAlgorithm(t *testing.T) {
+	RegisterAlgorithm(Algorithm{
+		Name:    "test-unkeyed",
+		MaxSize: 32,
+		New:     func(size int) (hash.Hash, error) { return newHash("", size) },
+	})
+	if _, err := SealEnvelope("test-unkeyed", []byte("key"), nil, false); err == nil {
+		t.Error("sealed with an algorithm that has no keyed mode")
+	}
+	if _, err := ParseEnvelope("test-unkeyed:" + strings.Repeat("00", 32)); err == nil {
+		t.Error("parsed an envelope for an algorithm that has no keyed mode")
+	}
+	e := &Envelope{Algorithm: "BLAKE2s", Tag: bytes.Repeat([]byte{0}, 16)}
+	if _, err := ParseEnvelope(e.String()); err != nil {
+		t.Errorf("truncated tag: %v", err)
+	}
+}
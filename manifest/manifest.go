@@ -0,0 +1,305 @@
+// Package manifest reads, writes and verifies lists of BLAKE2s file digests.
+//
+// The text format is the one produced by coreutils-style *sum tools: one
+// "<hex digest>  <path>" line per file. Paths containing a newline or a
+// backslash are written with those characters escaped and the line prefixed
+// by a single backslash, as coreutils does.
+//
+// Untagged lines are BLAKE2s digests. Digests from other registered
+// algorithms use the tagged form, "<algorithm>[-<bits>] (<path>) = <hex
+// digest>", where the bit length is given only for truncated digests, again
+// as coreutils does.
+package manifest
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/gtank/blake2s"
+)
+
+// An Entry is the expected digest of a single file.
+type Entry struct {
+	Path   string // slash-separated, relative to the root of the file system
+	Digest []byte
+
+	// Algorithm names the registered Algorithm that produced Digest. It is
+	// empty for untagged lines, which use DefaultAlgorithm.
+	Algorithm string
+}
+
+// A Manifest is an ordered list of file digests.
+type Manifest struct {
+	Entries []Entry
+}
+
+// Parse reads a manifest in text form. Blank lines are ignored.
+func Parse(r io.Reader) (*Manifest, error) {
+	return ParseAlgorithm(r, DefaultAlgorithm)
+}
+
+// ParseAlgorithm is Parse for manifests whose untagged lines hold digests by
+// the named registered algorithm rather than DefaultAlgorithm, such as those
+// written by "b2sum -a blake2b". Entries read from untagged lines have their
+// Algorithm set to it, unless it is DefaultAlgorithm.
+func ParseAlgorithm(r io.Reader, algorithm string) (*Manifest, error) {
+	untagged, ok := LookupAlgorithm(algorithm)
+	if !ok {
+		return nil, fmt.Errorf("manifest: unknown algorithm %q", algorithm)
+	}
+	if untagged.Name == DefaultAlgorithm {
+		untagged.Name = ""
+	}
+
+	m := &Manifest{}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		entry, err := parseLine(line, untagged)
+		if err != nil {
+			return nil, fmt.Errorf("manifest: line %d: %v", lineNo, err)
+		}
+		m.Entries = append(m.Entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// parseLine parses one line. Untagged lines are digests by the untagged
+// algorithm, whose Name is empty for DefaultAlgorithm.
+func parseLine(line string, untagged Algorithm) (Entry, error) {
+	escaped := strings.HasPrefix(line, "\\")
+	if escaped {
+		line = line[1:]
+	}
+
+	// The untagged form is matched first, since a path may itself contain
+	// " (". Only a line that doesn't start with a digest and separator is
+	// tagged, and its tag must name a known algorithm.
+	sep := strings.IndexByte(line, ' ')
+	untaggedForm := sep > 0 && sep+1 < len(line) && isHex(line[:sep]) &&
+		(line[sep+1] == ' ' || line[sep+1] == '*')
+	if tag, rest, ok := strings.Cut(line, " ("); ok && !untaggedForm {
+		return parseTagged(tag, rest, escaped)
+	}
+
+	if sep < 0 || sep+2 > len(line) {
+		return Entry{}, errors.New("expected \"<digest>  <path>\"")
+	}
+	// The second separator character is ' ' for text mode and '*' for
+	// binary mode. BLAKE2s doesn't care which.
+	if line[sep+1] != ' ' && line[sep+1] != '*' {
+		return Entry{}, errors.New("expected two characters between digest and path")
+	}
+
+	digest, err := hex.DecodeString(line[:sep])
+	if err != nil {
+		return Entry{}, fmt.Errorf("bad digest: %v", err)
+	}
+	if len(digest) == 0 || len(digest) > untagged.MaxSize {
+		return Entry{}, fmt.Errorf("bad digest length %d", len(digest))
+	}
+
+	path := line[sep+2:]
+	if escaped {
+		path, err = unescape(path)
+		if err != nil {
+			return Entry{}, err
+		}
+	}
+	if path == "" {
+		return Entry{}, errors.New("empty path")
+	}
+
+	return Entry{Path: path, Digest: digest, Algorithm: untagged.Name}, nil
+}
+
+// parseTagged parses the "(<path>) = <hex digest>" that follows the
+// algorithm tag in a tagged line.
+func parseTagged(tag, rest string, escaped bool) (Entry, error) {
+	i := strings.LastIndex(rest, ") = ")
+	if i < 0 {
+		return Entry{}, errors.New("expected \"<algorithm> (<path>) = <digest>\"")
+	}
+	path, digestHex := rest[:i], rest[i+len(") = "):]
+
+	a, size, err := lookupTag(tag)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	digest, err := hex.DecodeString(digestHex)
+	if err != nil {
+		return Entry{}, fmt.Errorf("bad digest: %v", err)
+	}
+	if len(digest) != size {
+		return Entry{}, fmt.Errorf("%s digest has length %d, expected %d", tag, len(digest), size)
+	}
+
+	if escaped {
+		path, err = unescape(path)
+		if err != nil {
+			return Entry{}, err
+		}
+	}
+	if path == "" {
+		return Entry{}, errors.New("empty path")
+	}
+
+	return Entry{Path: path, Digest: digest, Algorithm: a.Name}, nil
+}
+
+// lookupTag resolves an algorithm tag, a registered name optionally followed
+// by "-" and a digest length in bits, to the algorithm and the digest length
+// in bytes. Without a length, the tag means the algorithm's longest digest.
+func lookupTag(tag string) (Algorithm, int, error) {
+	if a, ok := LookupAlgorithm(tag); tag != "" && ok {
+		return a, a.MaxSize, nil
+	}
+	cut := strings.LastIndexByte(tag, '-')
+	if cut <= 0 {
+		return Algorithm{}, 0, fmt.Errorf("unknown algorithm %q", tag)
+	}
+	name, bits := tag[:cut], tag[cut+1:]
+	a, ok := LookupAlgorithm(name)
+	if !ok {
+		return Algorithm{}, 0, fmt.Errorf("unknown algorithm %q", tag)
+	}
+	n, err := strconv.Atoi(bits)
+	if err != nil || n < 8 || n%8 != 0 || n > 8*a.MaxSize {
+		return Algorithm{}, 0, fmt.Errorf("bad digest length in %q", tag)
+	}
+	return a, n / 8, nil
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func unescape(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", errors.New("trailing backslash in path")
+		}
+		switch s[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			return "", fmt.Errorf("unknown escape \\%c in path", s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+var escaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r")
+
+// FormatLine returns the text form of a single entry, without a newline.
+// Entries with an Algorithm are written as tagged lines.
+func FormatLine(e Entry) string {
+	prefix, path := "", e.Path
+	if strings.ContainsAny(path, "\\\n\r") {
+		prefix, path = "\\", escaper.Replace(path)
+	}
+	if e.Algorithm == "" {
+		return prefix + hex.EncodeToString(e.Digest) + "  " + path
+	}
+	tag := e.Algorithm
+	if a, ok := LookupAlgorithm(e.Algorithm); !ok || len(e.Digest) != a.MaxSize {
+		tag += "-" + strconv.Itoa(8*len(e.Digest))
+	}
+	return prefix + tag + " (" + path + ") = " + hex.EncodeToString(e.Digest)
+}
+
+// WriteTo writes the manifest in text form.
+func (m *Manifest) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, e := range m.Entries {
+		n, err := io.WriteString(w, FormatLine(e)+"\n")
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// HashFile returns the unkeyed BLAKE2s digest, of the given size, of the named
+// file in fsys.
+func HashFile(fsys fs.FS, name string, size int) ([]byte, error) {
+	return hashFile(fsys, name, "", size, nil)
+}
+
+// hashFile is HashFile using the named algorithm, with the file's contents
+// optionally passed through wrap on the way to the hash.
+func hashFile(fsys fs.FS, name, algorithm string, size int, wrap func(io.Reader) io.Reader) ([]byte, error) {
+	d, err := newHash(algorithm, size)
+	if err != nil {
+		return nil, err
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if wrap != nil {
+		r = wrap(f)
+	}
+	if _, err := io.Copy(d, r); err != nil {
+		return nil, err
+	}
+	return d.Sum(nil), nil
+}
+
+// Generate builds a manifest of every regular file under root in fsys, in
+// lexical order, with full-length digests.
+func Generate(fsys fs.FS, root string) (*Manifest, error) {
+	m := &Manifest{}
+	err := fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		digest, err := HashFile(fsys, path, blake2s.MaxOutput)
+		if err != nil {
+			return err
+		}
+		m.Entries = append(m.Entries, Entry{Path: path, Digest: digest})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
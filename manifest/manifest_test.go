@@ -0,0 +1,194 @@
+package manifest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"a.txt":         {Data: []byte("alpha")},
+		"dir/b.txt":     {Data: []byte("bravo")},
+		"dir/sub/c.txt": {Data: []byte("")},
+		"odd\\name":     {Data: []byte("escaped")},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	fsys := testFS()
+	fsys["report (1).txt"] = &fstest.MapFile{Data: []byte("copy")}
+	m, err := Generate(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != 5 {
+		t.Fatalf("expected 5 entries, got %d", len(m.Entries))
+	}
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\\") {
+		t.Error("backslash in path was not escaped")
+	}
+
+	parsed, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range m.Entries {
+		if parsed.Entries[i].Path != m.Entries[i].Path || !bytes.Equal(parsed.Entries[i].Digest, m.Entries[i].Digest) {
+			t.Errorf("entry %d changed: %v != %v", i, parsed.Entries[i], m.Entries[i])
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	input := "69217a3079908094e11121d042354a7c1f55b6482ca1a51e1b250dfd1ed0eef9  empty\r\n" +
+		"\n" +
+		"69217a3079908094e11121d042354a7c1f55b6482ca1a51e1b250dfd1ed0eef9 *binary name\n" +
+		"\\69217a3079908094e11121d042354a7c1f55b6482ca1a51e1b250dfd1ed0eef9  new\\nline\n"
+	m, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{"empty", "binary name", "new\nline"}
+	for i, p := range paths {
+		if m.Entries[i].Path != p {
+			t.Errorf("entry %d: expected %q, got %q", i, p, m.Entries[i].Path)
+		}
+	}
+
+	// Parenthesized paths are untagged, not tags that fail to parse.
+	input = "69217a3079908094e11121d042354a7c1f55b6482ca1a51e1b250dfd1ed0eef9  Copy (1).txt\n" +
+		"69217a3079908094e11121d042354a7c1f55b6482ca1a51e1b250dfd1ed0eef9 *a (b) = c\n"
+	m, err = Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range []string{"Copy (1).txt", "a (b) = c"} {
+		if m.Entries[i].Path != p || m.Entries[i].Algorithm != "" {
+			t.Errorf("entry %d: expected untagged %q, got %+v", i, p, m.Entries[i])
+		}
+	}
+
+	bad := []string{
+		"nothex  file\n",
+		"MD5 (file) = 00\n",
+		"00  \n",
+		"0000\n",
+		"00 -file\n",
+		"\\00  trailing\\\n",
+		"\\00  bad\\escape\n",
+		strings.Repeat("00", 33) + "  toolong\n",
+	}
+	for _, line := range bad {
+		if _, err := Parse(strings.NewReader(line)); err == nil {
+			t.Errorf("accepted %q", line)
+		}
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	input := strings.Repeat("ab", 64) + "  long\n" +
+		"BLAKE2s (tagged) = " + strings.Repeat("cd", 32) + "\n"
+	if _, err := Parse(strings.NewReader(input)); err == nil {
+		t.Error("Parse accepted a 64-byte untagged digest")
+	}
+	m, err := ParseAlgorithm(strings.NewReader(input), "BLAKE2b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Entries[0].Algorithm != "BLAKE2b" || len(m.Entries[0].Digest) != 64 {
+		t.Errorf("untagged entry %+v", m.Entries[0])
+	}
+	if m.Entries[1].Algorithm != "BLAKE2s" {
+		t.Errorf("tagged entry has algorithm %q", m.Entries[1].Algorithm)
+	}
+
+	m, err = ParseAlgorithm(strings.NewReader("00  short\n"), DefaultAlgorithm)
+	if err != nil || m.Entries[0].Algorithm != "" {
+		t.Errorf("default algorithm entry %+v, %v", m.Entries, err)
+	}
+	if _, err := ParseAlgorithm(strings.NewReader(""), "MD5"); err == nil {
+		t.Error("accepted an unknown algorithm")
+	}
+}
+
+func TestVerifyParallel(t *testing.T) {
+	fsys := testFS()
+	m, err := Generate(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Entries = append(m.Entries, Entry{Path: "gone.txt", Digest: []byte{0}})
+	fsys["dir/b.txt"] = &fstest.MapFile{Data: []byte("tampered")}
+
+	r, err := VerifyParallel(context.Background(), fsys, m, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.OK != 3 || r.Mismatched != 1 || r.Missing != 1 || r.Passed() {
+		t.Errorf("unexpected counts %+v", r)
+	}
+	if r.Files[1].Status != StatusMismatch || r.Files[1].Path != "dir/b.txt" {
+		t.Errorf("results out of order: %v", r.Files)
+	}
+	if !errors.Is(r.FirstErr, ErrMismatch) {
+		t.Errorf("unexpected first error %v", r.FirstErr)
+	}
+}
+
+func TestVerifyParallelCancelled(t *testing.T) {
+	fsys := testFS()
+	m, _ := Generate(fsys, ".")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r, err := VerifyParallel(ctx, fsys, m, 2)
+	if err != context.Canceled {
+		t.Errorf("expected cancellation, got %v", err)
+	}
+	if r.OK+r.Skipped != len(m.Entries) || r.Passed() {
+		t.Errorf("unexpected counts %+v", r)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add("69217a3079908094e11121d042354a7c1f55b6482ca1a51e1b250dfd1ed0eef9  empty\r\n")
+	f.Add("69217A3079908094E11121D042354A7C1F55B6482CA1A51E1B250DFD1ED0EEF9 *binary name\n\n")
+	f.Add("\\00  new\\nline\\\\and\\rreturn\n")
+	f.Add("\\00  bad\\escape\n")
+	f.Add("0  odd\n00 -x\n")
+	f.Add("00  Copy (1).txt\n")
+	f.Add("BLAKE2b-16 (a (b) = c) = 0000\n\\BLAKE2s (x\\ny) = " + strings.Repeat("00", 32) + "\n")
+	f.Add("00  " + strings.Repeat("x", 70000) + "\n")
+	f.Fuzz(func(t *testing.T, input string) {
+		m, err := Parse(strings.NewReader(input))
+		if err != nil {
+			return
+		}
+		// Anything accepted must survive a write and re-read unchanged.
+		var buf bytes.Buffer
+		if _, err := m.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		again, err := Parse(&buf)
+		if err != nil {
+			t.Fatalf("re-parsing %q: %v", buf.String(), err)
+		}
+		if len(again.Entries) != len(m.Entries) {
+			t.Fatalf("%d entries became %d", len(m.Entries), len(again.Entries))
+		}
+		for i, e := range m.Entries {
+			if again.Entries[i].Path != e.Path || !bytes.Equal(again.Entries[i].Digest, e.Digest) {
+				t.Fatalf("entry %d changed: %v != %v", i, again.Entries[i], e)
+			}
+		}
+	})
+}
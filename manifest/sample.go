@@ -0,0 +1,63 @@
+package manifest
+
+import (
+	"context"
+	"io/fs"
+	"math/rand"
+)
+
+// VerifySample checks a pseudo-random selection of roughly percent% of the
+// entries of m, so that the cost of scrubbing a large tree can be spread over
+// many runs. Each entry is chosen independently by a generator seeded with
+// seed, so the same seed always selects the same entries; vary it between
+// runs and use a Coverage to see how much of the tree has been checked.
+// Entries not chosen are reported as StatusSkipped. Otherwise it behaves like
+// VerifyParallel.
+func VerifySample(ctx context.Context, fsys fs.FS, m *Manifest, percent float64, seed int64, workers int) (*Result, error) {
+	chosen := Sample(len(m.Entries), percent, seed)
+	return verifySelected(ctx, fsys, m, workers, func(i int) bool { return chosen[i] })
+}
+
+// Sample reports which of n entries VerifySample chooses for the given
+// percent and seed, for callers that verify entries themselves.
+func Sample(n int, percent float64, seed int64) []bool {
+	chosen := make([]bool, n)
+	rng := rand.New(rand.NewSource(seed))
+	for i := range chosen {
+		chosen[i] = rng.Float64()*100 < percent
+	}
+	return chosen
+}
+
+// Coverage accumulates which paths have been checked across a series of
+// verification runs. The zero value is ready to use.
+type Coverage struct {
+	checked map[string]bool
+}
+
+// Add records every entry of r that was checked, whatever the outcome.
+func (c *Coverage) Add(r *Result) {
+	if c.checked == nil {
+		c.checked = make(map[string]bool)
+	}
+	for _, f := range r.Files {
+		if f.Status != StatusSkipped {
+			c.checked[f.Path] = true
+		}
+	}
+}
+
+// Of returns the fraction, between 0 and 1, of the entries of m that have
+// been checked at least once. An empty manifest is fully covered.
+func (c *Coverage) Of(m *Manifest) float64 {
+	if len(m.Entries) == 0 {
+		return 1
+	}
+	n := 0
+	for _, e := range m.Entries {
+		if c.checked[e.Path] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(m.Entries))
+}
@@ -0,0 +1,60 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestVerifySample(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for i := 0; i < 200; i++ {
+		fsys[fmt.Sprintf("f%03d", i)] = &fstest.MapFile{Data: []byte(fmt.Sprint(i))}
+	}
+	m, err := Generate(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := VerifySample(context.Background(), fsys, m, 25, 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.OK < 25 || a.OK > 75 || a.OK+a.Skipped != len(m.Entries) {
+		t.Errorf("unexpected counts for a 25%% sample: %+v", a)
+	}
+	if a.Passed() {
+		t.Error("a partial sample reported that every entry passed")
+	}
+
+	// The same seed picks the same entries, and Sample says which.
+	b, _ := VerifySample(context.Background(), fsys, m, 25, 1, 1)
+	chosen := Sample(len(m.Entries), 25, 1)
+	for i := range a.Files {
+		if a.Files[i].Status != b.Files[i].Status {
+			t.Fatalf("entry %d: selection differs between runs with one seed", i)
+		}
+		if chosen[i] != (a.Files[i].Status != StatusSkipped) {
+			t.Fatalf("entry %d: Sample disagrees with VerifySample", i)
+		}
+	}
+
+	var c Coverage
+	c.Add(a)
+	if got := c.Of(m); got != float64(a.OK)/float64(len(m.Entries)) {
+		t.Errorf("coverage after one run is %v", got)
+	}
+	for seed := int64(2); c.Of(m) < 1; seed++ {
+		if seed > 100 {
+			t.Fatal("coverage never reached the whole manifest")
+		}
+		r, _ := VerifySample(context.Background(), fsys, m, 25, seed, 4)
+		c.Add(r)
+	}
+
+	all, _ := VerifySample(context.Background(), fsys, m, 100, 7, 4)
+	if !all.Passed() {
+		t.Errorf("a 100%% sample did not check everything: %+v", all)
+	}
+}
@@ -0,0 +1,193 @@
+package manifest
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// scrubChunkSize bounds how much is read between rate-limit and pause
+// checks.
+const scrubChunkSize = 64 * 1024
+
+// Progress is a snapshot of a Scrubber's position.
+type Progress struct {
+	FilesDone, FilesTotal int
+	BytesDone             int64
+	Failed                int    // entries that were not StatusOK
+	Current               string // path being verified, if any
+	Paused                bool
+}
+
+// A Scrubber verifies a manifest in the background at a bounded read rate,
+// for storage daemons that continuously scrub their data. It can be paused,
+// resumed and re-rated while running, and polled for progress from any
+// goroutine.
+type Scrubber struct {
+	fsys fs.FS
+	m    *Manifest
+
+	mu       sync.Mutex
+	rate     int64 // bytes per second, or zero for unlimited
+	paused   bool
+	resumed  chan struct{} // closed on Resume
+	progress Progress
+
+	// Rate accounting since windowStart.
+	windowStart time.Time
+	windowBytes int64
+}
+
+// NewScrubber returns a Scrubber for m. A bytesPerSecond of zero means
+// unlimited.
+func NewScrubber(fsys fs.FS, m *Manifest, bytesPerSecond int64) *Scrubber {
+	return &Scrubber{
+		fsys:     fsys,
+		m:        m,
+		rate:     bytesPerSecond,
+		progress: Progress{FilesTotal: len(m.Entries)},
+	}
+}
+
+// SetRate changes the read rate limit. A bytesPerSecond of zero means
+// unlimited.
+func (s *Scrubber) SetRate(bytesPerSecond int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rate = bytesPerSecond
+	s.resetWindow()
+}
+
+// Pause stops reading at the next chunk boundary until Resume is called.
+func (s *Scrubber) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.paused {
+		s.paused = true
+		s.resumed = make(chan struct{})
+		s.progress.Paused = true
+	}
+}
+
+// Resume continues after Pause.
+func (s *Scrubber) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paused {
+		s.paused = false
+		close(s.resumed)
+		s.progress.Paused = false
+		s.resetWindow()
+	}
+}
+
+// Progress returns a snapshot of the current position.
+func (s *Scrubber) Progress() Progress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.progress
+}
+
+func (s *Scrubber) resetWindow() {
+	s.windowStart = time.Now()
+	s.windowBytes = 0
+}
+
+// Run verifies every entry in order and returns the result. If ctx is
+// cancelled, the remaining entries are reported as StatusSkipped and
+// ctx.Err() is returned with the partial result.
+func (s *Scrubber) Run(ctx context.Context) (*Result, error) {
+	files := make([]FileResult, len(s.m.Entries))
+	for i, e := range s.m.Entries {
+		files[i] = FileResult{Path: e.Path, Status: StatusSkipped}
+	}
+
+	s.mu.Lock()
+	s.resetWindow()
+	s.mu.Unlock()
+
+	for i, e := range s.m.Entries {
+		if err := s.wait(ctx, 0); err != nil {
+			return summarize(files), err
+		}
+		s.mu.Lock()
+		s.progress.Current = e.Path
+		s.mu.Unlock()
+
+		result := verifyEntry(s.fsys, e, func(r io.Reader) io.Reader {
+			return &throttledReader{ctx: ctx, s: s, r: r}
+		})
+		if err := ctx.Err(); err != nil {
+			// The entry was interrupted, so its outcome means nothing.
+			return summarize(files), err
+		}
+		files[i] = result
+
+		s.mu.Lock()
+		s.progress.FilesDone++
+		s.progress.Current = ""
+		if result.Status != StatusOK {
+			s.progress.Failed++
+		}
+		s.mu.Unlock()
+	}
+	return summarize(files), nil
+}
+
+// wait blocks while paused, then accounts for n bytes about to be read and
+// sleeps as long as needed to keep under the rate limit.
+func (s *Scrubber) wait(ctx context.Context, n int) error {
+	for {
+		s.mu.Lock()
+		if !s.paused {
+			break
+		}
+		resumed := s.resumed
+		s.mu.Unlock()
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	s.windowBytes += int64(n)
+	s.progress.BytesDone += int64(n)
+	var delay time.Duration
+	if s.rate > 0 {
+		due := time.Duration(float64(s.windowBytes) / float64(s.rate) * float64(time.Second))
+		delay = due - time.Since(s.windowStart)
+	}
+	s.mu.Unlock()
+
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type throttledReader struct {
+	ctx context.Context
+	s   *Scrubber
+	r   io.Reader
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > scrubChunkSize {
+		p = p[:scrubChunkSize]
+	}
+	n, err := t.r.Read(p)
+	if werr := t.s.wait(t.ctx, n); werr != nil {
+		return n, werr
+	}
+	return n, err
+}
@@ -0,0 +1,78 @@
+package manifest
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestScrubber(t *testing.T) {
+	fsys := testFS()
+	m, _ := Generate(fsys, ".")
+	fsys["a.txt"] = &fstest.MapFile{Data: []byte("changed")}
+
+	s := NewScrubber(fsys, m, 0)
+	r, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Mismatched != 1 || r.OK != len(m.Entries)-1 {
+		t.Errorf("unexpected result %+v", r)
+	}
+	p := s.Progress()
+	if p.FilesDone != len(m.Entries) || p.Failed != 1 || p.BytesDone == 0 {
+		t.Errorf("unexpected progress %+v", p)
+	}
+}
+
+func TestScrubberRate(t *testing.T) {
+	fsys := fstest.MapFS{"big": {Data: make([]byte, 200*1024)}}
+	m, _ := Generate(fsys, ".")
+
+	// 200KiB at 1MiB/s should take around 200ms.
+	s := NewScrubber(fsys, m, 1<<20)
+	start := time.Now()
+	if _, err := s.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("rate limit not applied, took %v", elapsed)
+	}
+}
+
+func TestScrubberPause(t *testing.T) {
+	fsys := testFS()
+	m, _ := Generate(fsys, ".")
+	s := NewScrubber(fsys, m, 0)
+	s.Pause()
+
+	done := make(chan *Result)
+	go func() {
+		r, _ := s.Run(context.Background())
+		done <- r
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if p := s.Progress(); !p.Paused || p.FilesDone != 0 {
+		t.Errorf("paused scrubber made progress: %+v", p)
+	}
+	s.Resume()
+	if r := <-done; !r.Passed() {
+		t.Errorf("unexpected result %+v", r)
+	}
+}
+
+func TestScrubberCancel(t *testing.T) {
+	fsys := testFS()
+	m, _ := Generate(fsys, ".")
+	s := NewScrubber(fsys, m, 0)
+	s.Pause()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r, err := s.Run(ctx)
+	if err != context.Canceled || r.Skipped != len(m.Entries) {
+		t.Errorf("unexpected cancellation result %+v, %v", r, err)
+	}
+}
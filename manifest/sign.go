@@ -0,0 +1,85 @@
+package manifest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gtank/blake2s"
+)
+
+var signPersona = []byte("manisig1")
+
+// ErrBadSignature is returned by VerifySignature when the signature does not
+// match the manifest.
+var ErrBadSignature = errors.New("manifest: bad signature")
+
+// CanonicalDigest returns the digest that Sign signs: the BLAKE2s hash of the
+// manifest's entries sorted by path, each as a tagged line with its
+// algorithm spelled out. Manifests listing the same digests for the same
+// paths have the same canonical digest regardless of entry order or whether
+// their lines are tagged.
+func CanonicalDigest(m *Manifest) []byte {
+	entries := append([]Entry(nil), m.Entries...)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	d, _ := blake2s.NewDigest(nil, nil, signPersona, blake2s.MaxOutput)
+	for _, e := range entries {
+		if e.Algorithm == "" {
+			e.Algorithm = DefaultAlgorithm
+		}
+		d.Write([]byte(FormatLine(e) + "\n"))
+	}
+	return d.Sum(nil)
+}
+
+// Sign signs the canonical digest of m with signer. The signature algorithm
+// is the signer's: Ed25519 keys sign the digest as their message, and other
+// keys sign it as a prehashed crypto.BLAKE2s_256 digest. That covers ECDSA,
+// and any signer of its own that accepts such digests, such as a wrapper
+// choosing RSA-PSS. RSA keys used directly are rejected, since PKCS #1 v1.5
+// has no encoding for BLAKE2s.
+func Sign(m *Manifest, signer crypto.Signer) ([]byte, error) {
+	var opts crypto.SignerOpts = crypto.BLAKE2s_256
+	switch signer.Public().(type) {
+	case ed25519.PublicKey:
+		opts = crypto.Hash(0)
+	case *rsa.PublicKey:
+		if _, ok := signer.(*rsa.PrivateKey); ok {
+			return nil, errors.New("manifest: RSA keys can't sign BLAKE2s digests with PKCS #1 v1.5")
+		}
+	}
+	return signer.Sign(rand.Reader, CanonicalDigest(m), opts)
+}
+
+// A VerifyFunc reports whether sig is a valid signature of a canonical
+// digest, as VerifySignatureFunc checks it.
+type VerifyFunc func(digest, sig []byte) bool
+
+// VerifySignature checks a signature made by Sign. Ed25519 and ECDSA public
+// keys are supported; use VerifySignatureFunc for any other signer.
+func VerifySignature(m *Manifest, pub crypto.PublicKey, sig []byte) error {
+	var verify VerifyFunc
+	switch pub := pub.(type) {
+	case ed25519.PublicKey:
+		verify = func(digest, sig []byte) bool { return ed25519.Verify(pub, digest, sig) }
+	case *ecdsa.PublicKey:
+		verify = func(digest, sig []byte) bool { return ecdsa.VerifyASN1(pub, digest, sig) }
+	default:
+		return fmt.Errorf("manifest: unsupported public key type %T", pub)
+	}
+	return VerifySignatureFunc(m, sig, verify)
+}
+
+// VerifySignatureFunc checks a signature made by Sign with verify, which is
+// given the canonical digest of m.
+func VerifySignatureFunc(m *Manifest, sig []byte, verify VerifyFunc) error {
+	if !verify(CanonicalDigest(m), sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
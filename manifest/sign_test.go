@@ -0,0 +1,78 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"testing"
+
+	_ "github.com/gtank/blake2s/register"
+)
+
+func TestSign(t *testing.T) {
+	m := &Manifest{Entries: []Entry{
+		{Path: "b", Digest: bytes.Repeat([]byte{2}, 32)},
+		{Path: "a", Digest: bytes.Repeat([]byte{1}, 32)},
+	}}
+	reordered := &Manifest{Entries: []Entry{
+		{Path: "a", Digest: bytes.Repeat([]byte{1}, 32), Algorithm: "BLAKE2s"},
+		{Path: "b", Digest: bytes.Repeat([]byte{2}, 32)},
+	}}
+	changed := &Manifest{Entries: []Entry{
+		{Path: "a", Digest: bytes.Repeat([]byte{1}, 32)},
+		{Path: "b", Digest: bytes.Repeat([]byte{3}, 32)},
+	}}
+	if !bytes.Equal(CanonicalDigest(m), CanonicalDigest(reordered)) {
+		t.Error("entry order or tagging changed the canonical digest")
+	}
+
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	for _, signer := range []crypto.Signer{edKey, ecKey} {
+		sig, err := Sign(m, signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub := signer.Public()
+		if err := VerifySignature(reordered, pub, sig); err != nil {
+			t.Errorf("%T: %v", signer, err)
+		}
+		if err := VerifySignature(changed, pub, sig); err != ErrBadSignature {
+			t.Errorf("%T: changed manifest returned %v", signer, err)
+		}
+	}
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	if err := VerifySignature(m, &rsaKey.PublicKey, nil); err == nil || err == ErrBadSignature {
+		t.Errorf("RSA key returned %v", err)
+	}
+	if _, err := Sign(m, rsaKey); err == nil {
+		t.Error("signed with PKCS #1 v1.5")
+	}
+
+	sig, err := Sign(m, pssSigner{rsaKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyPSS := func(digest, sig []byte) bool {
+		return rsa.VerifyPSS(&rsaKey.PublicKey, crypto.BLAKE2s_256, digest, sig, nil) == nil
+	}
+	if err := VerifySignatureFunc(reordered, sig, verifyPSS); err != nil {
+		t.Errorf("RSA-PSS: %v", err)
+	}
+	if err := VerifySignatureFunc(changed, sig, verifyPSS); err != ErrBadSignature {
+		t.Errorf("RSA-PSS: changed manifest returned %v", err)
+	}
+}
+
+// pssSigner signs with RSA-PSS rather than PKCS #1 v1.5.
+type pssSigner struct{ *rsa.PrivateKey }
+
+func (s pssSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.PrivateKey.Sign(rand, digest, &rsa.PSSOptions{Hash: opts.HashFunc()})
+}
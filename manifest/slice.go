@@ -0,0 +1,33 @@
+package manifest
+
+import (
+	"io/fs"
+	"time"
+)
+
+// VerifySlice checks entries of m in order, starting at index cursor, until
+// budget has elapsed, and returns the results for the entries it checked
+// along with the cursor to resume from. At least one entry is checked per
+// call, so repeated calls always make progress. The returned cursor equals
+// len(m.Entries) once the end of the manifest is reached; start the next
+// pass from zero. A cursor outside the manifest is treated as zero, so a
+// cursor saved against an older, longer manifest restarts the pass.
+//
+// It is meant for periodic jobs that scrub a large tree a little at a time:
+// persist the cursor between runs and pass it back in.
+func VerifySlice(fsys fs.FS, m *Manifest, cursor int, budget time.Duration) (*Result, int) {
+	if cursor < 0 || cursor >= len(m.Entries) {
+		cursor = 0
+	}
+	deadline := time.Now().Add(budget)
+
+	var files []FileResult
+	for cursor < len(m.Entries) {
+		files = append(files, VerifyEntry(fsys, m.Entries[cursor]))
+		cursor++
+		if !time.Now().Before(deadline) {
+			break
+		}
+	}
+	return summarize(files), cursor
+}
@@ -0,0 +1,39 @@
+package manifest
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestVerifySlice(t *testing.T) {
+	fsys := testFS()
+	m, err := Generate(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys["dir/b.txt"] = &fstest.MapFile{Data: []byte("tampered")}
+
+	// A zero budget still checks one entry per call.
+	cursor, mismatched := 0, 0
+	for i := 0; i < len(m.Entries); i++ {
+		var r *Result
+		r, cursor = VerifySlice(fsys, m, cursor, 0)
+		if len(r.Files) != 1 || r.Files[0].Path != m.Entries[i].Path {
+			t.Fatalf("call %d checked %v", i, r.Files)
+		}
+		mismatched += r.Mismatched
+	}
+	if cursor != len(m.Entries) || mismatched != 1 {
+		t.Errorf("cursor %d, %d mismatches after a full pass", cursor, mismatched)
+	}
+
+	// A finished or stale cursor starts over, and a generous budget covers
+	// everything in one call.
+	for _, c := range []int{cursor, -1, 100} {
+		r, next := VerifySlice(fsys, m, c, time.Hour)
+		if len(r.Files) != len(m.Entries) || next != len(m.Entries) {
+			t.Errorf("cursor %d: checked %d entries, next %d", c, len(r.Files), next)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package manifest
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+// Status is the outcome of verifying a single entry.
+type Status int
+
+const (
+	// StatusSkipped means the entry was not checked, because verification
+	// was cancelled first.
+	StatusSkipped Status = iota
+	// StatusOK means the file's digest matched.
+	StatusOK
+	// StatusMismatch means the file exists but its digest did not match.
+	StatusMismatch
+	// StatusMissing means the file does not exist.
+	StatusMissing
+	// StatusError means the file could not be read.
+	StatusError
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusSkipped:
+		return "SKIPPED"
+	case StatusOK:
+		return "OK"
+	case StatusMismatch:
+		return "FAILED"
+	case StatusMissing:
+		return "MISSING"
+	case StatusError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// FileResult is the outcome of verifying one manifest entry.
+type FileResult struct {
+	Path   string
+	Status Status
+	Err    error // set for StatusMissing and StatusError
+}
+
+// Result summarizes the verification of a whole manifest.
+type Result struct {
+	// Files holds one result per manifest entry, in manifest order.
+	Files []FileResult
+
+	// Counts of each outcome.
+	OK, Mismatched, Missing, Errors, Skipped int
+
+	// FirstErr is the first non-OK outcome in manifest order, as an error,
+	// or nil if every checked file matched.
+	FirstErr error
+}
+
+// Passed reports whether every entry was checked and matched.
+func (r *Result) Passed() bool {
+	return r.OK == len(r.Files)
+}
+
+// ErrMismatch is wrapped by Result.FirstErr when a file's digest differs
+// from the manifest.
+var ErrMismatch = errors.New("manifest: digest mismatch")
+
+// VerifyEntry hashes the file named by e in fsys with the entry's algorithm
+// and compares it with the expected digest in constant time.
+func VerifyEntry(fsys fs.FS, e Entry) FileResult {
+	return verifyEntry(fsys, e, nil)
+}
+
+func verifyEntry(fsys fs.FS, e Entry, wrap func(io.Reader) io.Reader) FileResult {
+	digest, err := hashFile(fsys, e.Path, e.Algorithm, len(e.Digest), wrap)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return FileResult{Path: e.Path, Status: StatusMissing, Err: err}
+	case err != nil:
+		return FileResult{Path: e.Path, Status: StatusError, Err: err}
+	case subtle.ConstantTimeCompare(digest, e.Digest) != 1:
+		return FileResult{Path: e.Path, Status: StatusMismatch}
+	}
+	return FileResult{Path: e.Path, Status: StatusOK}
+}
+
+// VerifyParallel checks every entry of m against the files in fsys, using up
+// to workers concurrent goroutines. If ctx is cancelled, entries that haven't
+// been started are reported as StatusSkipped and ctx.Err() is returned along
+// with the partial result.
+func VerifyParallel(ctx context.Context, fsys fs.FS, m *Manifest, workers int) (*Result, error) {
+	return verifySelected(ctx, fsys, m, workers, nil)
+}
+
+// verifySelected is VerifyParallel restricted to the entries for which
+// selected returns true, or all of them if selected is nil. The rest are
+// reported as StatusSkipped.
+func verifySelected(ctx context.Context, fsys fs.FS, m *Manifest, workers int, selected func(int) bool) (*Result, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	files := make([]FileResult, len(m.Entries))
+	for i, e := range m.Entries {
+		files[i] = FileResult{Path: e.Path, Status: StatusSkipped}
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				files[i] = VerifyEntry(fsys, m.Entries[i])
+			}
+		}()
+	}
+
+feed:
+	for i := range m.Entries {
+		if selected != nil && !selected(i) {
+			continue
+		}
+		select {
+		case indices <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indices)
+	wg.Wait()
+
+	return summarize(files), ctx.Err()
+}
+
+func summarize(files []FileResult) *Result {
+	r := &Result{Files: files}
+	for _, f := range files {
+		switch f.Status {
+		case StatusOK:
+			r.OK++
+		case StatusMismatch:
+			r.Mismatched++
+		case StatusMissing:
+			r.Missing++
+		case StatusError:
+			r.Errors++
+		case StatusSkipped:
+			r.Skipped++
+		}
+		if r.FirstErr == nil && f.Status != StatusOK && f.Status != StatusSkipped {
+			r.FirstErr = f.err()
+		}
+	}
+	return r
+}
+
+func (f FileResult) err() error {
+	if f.Status == StatusMismatch {
+		return fmt.Errorf("%s: %w", f.Path, ErrMismatch)
+	}
+	return fmt.Errorf("%s: %w", f.Path, f.Err)
+}
@@ -0,0 +1,107 @@
+package blake2s
+
+import "errors"
+
+// The serialized state starts with a magic string and version, followed by
+// the chaining value, the chaining value Reset returns to, the counter, the
+// parameters retained from construction and the pending block:
+//
+//	magic[4] h[32] init[32] t0[4] t1[4] size[1] lastNode[1]
+//	keyLen[1] key[32] salt[8] persona[8] offset[1] buf[64]
+const (
+	marshalMagic  = "b2s\x01"
+	marshaledSize = len(marshalMagic) + 8*4 + 8*4 + 4 + 4 + 1 + 1 +
+		1 + KeyLength + SaltLength + SeparatorLength + 1 + BlockSize
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler. The state of a keyed
+// Digest includes its key, so it must be stored as securely as the key.
+func (d *Digest) MarshalBinary() ([]byte, error) {
+	return d.AppendBinary(make([]byte, 0, marshaledSize))
+}
+
+// AppendBinary implements encoding.BinaryAppender, appending the same
+// encoding as MarshalBinary to b.
+func (d *Digest) AppendBinary(b []byte) ([]byte, error) {
+	if _, ok := d.backend.(reducedRounds); ok {
+		// The encoding has no room for the round count, and restoring
+		// it as a full-round Digest would silently change its digests.
+		return nil, errors.New("blake2s: can't marshal a reduced-round digest")
+	}
+	b = append(b, marshalMagic...)
+	for _, w := range d.h {
+		b = appendU32LE(b, w)
+	}
+	for _, w := range d.init {
+		b = appendU32LE(b, w)
+	}
+	b = appendU32LE(b, d.t0)
+	b = appendU32LE(b, d.t1)
+	b = append(b, byte(d.size), boolByte(d.lastNode), byte(d.keyLen))
+	b = append(b, d.key[:]...)
+	b = append(b, d.salt[:]...)
+	b = append(b, d.persona[:]...)
+	b = append(b, byte(d.offset))
+	b = append(b, d.buf[:]...)
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, restoring a state
+// saved by MarshalBinary, possibly in another process. The output size, key
+// and other parameters come from the saved state. Options set on d, such as
+// WithMaxInput or WithGuard, are kept, so d may be a Digest from New or a
+// zero Digest.
+func (d *Digest) UnmarshalBinary(b []byte) error {
+	if len(b) < len(marshalMagic) || string(b[:len(marshalMagic)]) != marshalMagic {
+		return errors.New("blake2s: invalid hash state identifier")
+	}
+	if len(b) != marshaledSize {
+		return errors.New("blake2s: invalid hash state size")
+	}
+	b = b[len(marshalMagic):]
+
+	var s Digest
+	for i := range s.h {
+		s.h[i] = u32LE(b[i*4:])
+	}
+	b = b[len(s.h)*4:]
+	for i := range s.init {
+		s.init[i] = u32LE(b[i*4:])
+	}
+	b = b[len(s.init)*4:]
+	s.t0, s.t1 = u32LE(b), u32LE(b[4:])
+	s.size, s.keyLen = int(b[8]), int(b[10])
+	switch b[9] {
+	case 0:
+	case 1:
+		s.lastNode = true
+	default:
+		return errors.New("blake2s: invalid hash state")
+	}
+	b = b[11:]
+	b = b[copy(s.key[:], b):]
+	b = b[copy(s.salt[:], b):]
+	b = b[copy(s.persona[:], b):]
+	s.offset = int(b[0])
+	copy(s.buf[:], b[1:])
+
+	if s.size < 1 || s.size > MaxOutput || s.keyLen > KeyLength || s.offset > BlockSize {
+		return errors.New("blake2s: invalid hash state")
+	}
+
+	s.backend = selectedBackend()
+	s.hooks, s.maxInput, s.guard = d.hooks, d.maxInput, d.guard
+	*d = s
+	return nil
+}
+
+func appendU32LE(b []byte, n uint32) []byte {
+	return append(b, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+package blake2s
+
+import (
+	"bytes"
+	"encoding"
+	"testing"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*Digest)(nil)
+	_ encoding.BinaryUnmarshaler = (*Digest)(nil)
+	_ encoding.BinaryAppender    = (*Digest)(nil)
+)
+
+func TestMarshalBinary(t *testing.T) {
+	msg := make([]byte, 300)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	tree := TreeParams{Fanout: 2, MaxDepth: 2, InnerLength: 32, NodeOffset: 1, LastNode: true}
+	for _, opts := range [][]Option{
+		nil,
+		{WithKey([]byte("key")), WithSize(20)},
+		{WithSalt([]byte("salt")), WithPersonalization([]byte("persona"))},
+		{WithTree(tree)},
+	} {
+		// Split at every interesting offset, including block boundaries
+		// where the pending block is full.
+		for _, split := range []int{0, 1, 63, 64, 65, 128, 300} {
+			want, _ := New(opts...)
+			want.Write(msg)
+
+			d, _ := New(opts...)
+			d.Write(msg[:split])
+			state, err := d.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			appended, _ := d.AppendBinary([]byte("prefix"))
+			if !bytes.Equal(appended[6:], state) {
+				t.Error("AppendBinary and MarshalBinary differ")
+			}
+
+			var restored Digest
+			if err := restored.UnmarshalBinary(state); err != nil {
+				t.Fatal(err)
+			}
+			restored.Write(msg[split:])
+			if got := restored.Sum(nil); !bytes.Equal(got, want.Sum(nil)) {
+				t.Errorf("opts %d, split %d: got %x, want %x", len(opts), split, got, want.Sum(nil))
+			}
+
+			restored.Reset()
+			restored.Write(msg)
+			if got := restored.Sum(nil); !bytes.Equal(got, want.Sum(nil)) {
+				t.Errorf("opts %d, split %d: restored state does not Reset", len(opts), split)
+			}
+		}
+	}
+}
+
+func TestUnmarshalBinaryErrors(t *testing.T) {
+	d, _ := NewDigest(nil, nil, nil, 32)
+	state, _ := d.MarshalBinary()
+
+	corrupt := func(i int, v byte) []byte {
+		b := bytes.Clone(state)
+		b[i] = v
+		return b
+	}
+	for name, b := range map[string][]byte{
+		"empty":     nil,
+		"magic":     corrupt(0, 'x'),
+		"short":     state[:len(state)-1],
+		"long":      append(bytes.Clone(state), 0),
+		"size":      corrupt(76, 33),
+		"last node": corrupt(77, 2),
+		"key":       corrupt(78, 33),
+		"offset":    corrupt(78+1+KeyLength+SaltLength+SeparatorLength, 65),
+	} {
+		if err := d.UnmarshalBinary(b); err == nil {
+			t.Errorf("%s: accepted invalid state", name)
+		}
+	}
+
+	// Options on the receiver survive.
+	g, _ := New(WithMaxInput(10))
+	if err := g.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Write(make([]byte, 11)); err != ErrMaxInput {
+		t.Errorf("WithMaxInput lost: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package blake2s
+
+// A Midstate is a frozen Digest state, typically taken after writing a
+// fixed prefix such as a protocol header, from which any number of digests
+// can continue without hashing the prefix again. It is immutable, so one
+// Midstate can be shared by many goroutines.
+//
+// A Midstate of a keyed Digest holds the key, like the Digest itself.
+type Midstate struct {
+	d Digest
+}
+
+// Checkpoint returns the current state of d, including any pending input,
+// as a Midstate. d is not affected and may go on being used.
+func (d *Digest) Checkpoint() *Midstate {
+	return &Midstate{d: *d}
+}
+
+// New returns a Digest in the checkpointed state, as if the prefix had just
+// been written to it. Its Reset returns to the state before the prefix, as
+// for the original Digest; use Restore to go back to the checkpoint. Options
+// carry over as with Clone.
+func (m *Midstate) New() *Digest {
+	d := m.d
+	return &d
+}
+
+// Restore puts d back in the checkpointed state without allocating, for
+// hashing many messages with one Digest: Restore, Write, Sum, repeat.
+func (m *Midstate) Restore(d *Digest) {
+	*d = m.d
+}
+
+// Wipe zeroes the Midstate, including any key it holds. It must not be used
+// again, or concurrently with Wipe.
+func (m *Midstate) Wipe() {
+	m.d.Wipe()
+}
@@ -0,0 +1,60 @@
+package blake2s
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/gtank/blake2s/testutil"
+)
+
+func TestMidstate(t *testing.T) {
+	prefix := testutil.Input(1000)
+	key := []byte("midstate key")
+	sumOf := func(msg []byte) []byte {
+		d, _ := NewDigest(key, nil, nil, 32)
+		d.Write(prefix)
+		d.Write(msg)
+		return d.Sum(nil)
+	}
+
+	d, _ := NewDigest(key, nil, nil, 32)
+	d.Write(prefix)
+	m := d.Checkpoint()
+	d.Write([]byte("keeps going"))
+	if !bytes.Equal(d.Sum(nil), sumOf([]byte("keeps going"))) {
+		t.Error("Checkpoint disturbed the Digest")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			msg := testutil.Input(i * 37)
+			c := m.New()
+			c.Write(msg)
+			if !bytes.Equal(c.Sum(nil), sumOf(msg)) {
+				t.Errorf("message %d: wrong digest from New", i)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var reused Digest
+	for _, msg := range [][]byte{[]byte("one"), nil, testutil.Input(200)} {
+		m.Restore(&reused)
+		reused.Write(msg)
+		if !bytes.Equal(reused.Sum(nil), sumOf(msg)) {
+			t.Errorf("%d-byte message: wrong digest after Restore", len(msg))
+		}
+	}
+	if n := testing.AllocsPerRun(100, func() { m.Restore(&reused) }); n != 0 {
+		t.Errorf("Restore allocated %v times", n)
+	}
+
+	m.Wipe()
+	if *m != (Midstate{}) {
+		t.Error("Wipe left state behind")
+	}
+}
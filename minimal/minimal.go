@@ -0,0 +1,103 @@
+// Package minimal is a reduced BLAKE2s for microcontrollers and other
+// targets where code size and RAM matter more than features, such as
+// firmware built with TinyGo.
+//
+// It supports unkeyed and keyed hashing with any output size, and nothing
+// else: no salt, personalization or tree parameters, no hash.Hash interface,
+// no options and no error values. All state lives in fixed-size arrays in
+// the Digest, which never allocates. It shares its compression function with
+// the full package, from the core package, so digests are identical.
+//
+// Budget: a Digest occupies at most 112 bytes of RAM, hashing uses no heap,
+// and the package adds at most 8 KiB of flash to a Cortex-M program built
+// with TinyGo. Tests enforce all three; the flash test is skipped when tinygo
+// isn't installed. The package imports only core, which imports only errors,
+// so its flash footprint is essentially the compression function.
+package minimal
+
+import (
+	"github.com/gtank/blake2s/core"
+)
+
+const (
+	// BlockSize is the size of a message block in bytes.
+	BlockSize = core.BlockSize
+	// MaxSize is the largest digest size in bytes.
+	MaxSize = 32
+	// MaxKeySize is the largest key size in bytes.
+	MaxKeySize = 32
+)
+
+// Digest is a BLAKE2s hash in progress. The zero value is not usable; call
+// Init first.
+type Digest struct {
+	h      [8]uint32
+	t0, t1 uint32
+	buf    [BlockSize]byte
+	offset uint8
+	size   uint8
+}
+
+// Init prepares d to produce a size-byte digest, keyed if key is not empty.
+// It panics if size is not between 1 and MaxSize or the key is longer than
+// MaxKeySize.
+func (d *Digest) Init(key []byte, size int) {
+	if size < 1 || size > MaxSize {
+		panic("minimal: invalid digest size")
+	}
+	if len(key) > MaxKeySize {
+		panic("minimal: key too long")
+	}
+
+	// Sequential mode: fanout and depth are 1, everything else zero.
+	d.h = [8]uint32{
+		core.IV0 ^ (0x01010000 | uint32(len(key))<<8 | uint32(size)),
+		core.IV1, core.IV2, core.IV3,
+		core.IV4, core.IV5, core.IV6, core.IV7,
+	}
+	d.t0, d.t1 = 0, 0
+	d.buf = [BlockSize]byte{}
+	d.offset = 0
+	d.size = uint8(size)
+
+	if len(key) > 0 {
+		copy(d.buf[:], key)
+		d.offset = BlockSize
+	}
+}
+
+// Write adds data to the running hash.
+func (d *Digest) Write(data []byte) {
+	for len(data) > 0 {
+		if d.offset == BlockSize {
+			d.t0 += BlockSize
+			if d.t0 < BlockSize {
+				d.t1++
+			}
+			core.Block(&d.h, &d.buf, d.t0, d.t1, 0, 0)
+			d.offset = 0
+		}
+		n := copy(d.buf[d.offset:], data)
+		d.offset += uint8(n)
+		data = data[n:]
+	}
+}
+
+// Sum finishes the hash and writes the digest to out, which must be at least
+// as long as the size passed to Init. Unlike hash.Hash, this consumes the
+// state: call Init again before reusing d.
+func (d *Digest) Sum(out []byte) {
+	for i := int(d.offset); i < BlockSize; i++ {
+		d.buf[i] = 0
+	}
+	d.t0 += uint32(d.offset)
+	if d.t0 < uint32(d.offset) {
+		d.t1++
+	}
+	core.Block(&d.h, &d.buf, d.t0, d.t1, 0xFFFFFFFF, 0)
+
+	out = out[:d.size]
+	for i := range out {
+		out[i] = byte(d.h[i/4] >> (8 * uint(i%4)))
+	}
+}
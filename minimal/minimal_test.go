@@ -0,0 +1,120 @@
+package minimal_test
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/gtank/blake2s"
+	"github.com/gtank/blake2s/minimal"
+	"github.com/gtank/blake2s/testutil"
+)
+
+func TestBoundaryVectors(t *testing.T) {
+	// Check against the full package rather than just testutil's unkeyed
+	// vectors, so keys and short outputs are covered too.
+	for _, key := range [][]byte{nil, []byte("k"), bytes.Repeat([]byte{7}, minimal.MaxKeySize)} {
+		for _, size := range []int{1, 16, 31, 32} {
+			for _, v := range testutil.BoundaryVectors {
+				input := testutil.Input(v.Length)
+				expected, _ := blake2s.NewDigest(key, nil, nil, size)
+				expected.Write(input)
+
+				var d minimal.Digest
+				d.Init(key, size)
+				d.Write(input[:v.Length/3])
+				d.Write(input[v.Length/3:])
+				out := make([]byte, size)
+				d.Sum(out)
+				if !bytes.Equal(out, expected.Sum(nil)) {
+					t.Fatalf("key %x, size %d, length %d: mismatch", key, size, v.Length)
+				}
+			}
+		}
+	}
+}
+
+func TestRAMBudget(t *testing.T) {
+	if size := unsafe.Sizeof(minimal.Digest{}); size > 112 {
+		t.Errorf("minimal.Digest is %d bytes, budget is 112", size)
+	}
+
+	var d minimal.Digest
+	var out [minimal.MaxSize]byte
+	data := make([]byte, 300)
+	allocs := testing.AllocsPerRun(100, func() {
+		d.Init(data[:minimal.MaxKeySize], minimal.MaxSize)
+		d.Write(data)
+		d.Sum(out[:])
+	})
+	if allocs != 0 {
+		t.Errorf("hashing allocated %v times", allocs)
+	}
+}
+
+// flashBudget is the most flash, in bytes, the minimal package may add to a
+// Cortex-M program.
+const flashBudget = 8 << 10
+
+// TestFlashBudget builds testdata/sizeprog and an empty program with TinyGo
+// and compares their flash sizes.
+func TestFlashBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds programs")
+	}
+	tinygo, err := exec.LookPath("tinygo")
+	if err != nil {
+		t.Skip("no tinygo command")
+	}
+	empty := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(empty, []byte("package main\n\nfunc main() { println(0) }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	flash := func(pkg string) int {
+		cmd := exec.Command(tinygo, "build", "-size", "short", "-target", "cortex-m-qemu", "-o", filepath.Join(t.TempDir(), "prog.elf"), pkg)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("tinygo build %s: %v\n%s", pkg, err, out)
+		}
+		// The last line is "code data bss | flash ram".
+		lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+		fields := strings.Fields(lines[len(lines)-1])
+		if len(fields) != 6 || fields[3] != "|" {
+			t.Fatalf("unexpected tinygo output:\n%s", out)
+		}
+		n, err := strconv.Atoi(fields[4])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if added := flash("./testdata/sizeprog") - flash(empty); added > flashBudget {
+		t.Errorf("minimal adds %d bytes of flash, budget is %d", added, flashBudget)
+	}
+}
+
+func TestInitPanics(t *testing.T) {
+	for _, test := range []struct {
+		key  []byte
+		size int
+	}{
+		{nil, 0},
+		{nil, minimal.MaxSize + 1},
+		{make([]byte, minimal.MaxKeySize+1), minimal.MaxSize},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Init(%d-byte key, %d) did not panic", len(test.key), test.size)
+				}
+			}()
+			var d minimal.Digest
+			d.Init(test.key, test.size)
+		}()
+	}
+}
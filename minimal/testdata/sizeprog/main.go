@@ -0,0 +1,16 @@
+// Command sizeprog hashes a buffer with the minimal package, for measuring
+// its code size with TinyGo.
+package main
+
+import "github.com/gtank/blake2s/minimal"
+
+var input [100]byte
+
+func main() {
+	var d minimal.Digest
+	var out [minimal.MaxSize]byte
+	d.Init(input[:minimal.MaxKeySize], minimal.MaxSize)
+	d.Write(input[:])
+	d.Sum(out[:])
+	println(out[0])
+}
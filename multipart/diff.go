@@ -0,0 +1,118 @@
+package multipart
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Kind classifies how an input differs from the checksums it was expected
+// to have.
+type Kind int
+
+const (
+	// Match means every part matched.
+	Match Kind = iota
+	// Truncated means the input has fewer parts than expected, and all
+	// but possibly the last of them match.
+	Truncated
+	// Extended means the input has more parts than expected, and all the
+	// expected parts but possibly the last match.
+	Extended
+	// Corrupted means some parts differ while others match. When only the
+	// last part differs, the input may instead have been truncated or
+	// extended within that part.
+	Corrupted
+	// Unrelated means no part matches, so the input is different content
+	// altogether, or the checksums were computed with different parameters.
+	Unrelated
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Match:
+		return "match"
+	case Truncated:
+		return "truncated"
+	case Extended:
+		return "extended"
+	case Corrupted:
+		return "corrupted"
+	case Unrelated:
+		return "unrelated"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// A Divergence describes where an input differs from its expected checksums.
+type Divergence struct {
+	Kind Kind
+
+	// Part is the first part that differs, counting from zero, and Offset
+	// the position of its first byte. Both are zero for Match.
+	Part   int
+	Offset int64
+
+	// Differing lists every part that differs or exists on only one side.
+	Differing []int
+}
+
+func (d *Divergence) String() string {
+	if d.Kind == Match {
+		return "all parts match"
+	}
+	return fmt.Sprintf("%s: first difference in part %d at offset %d, %d parts differ", d.Kind, d.Part, d.Offset, len(d.Differing))
+}
+
+// Diff hashes r with the part size of expected and reports where it
+// diverges, for triaging a failed verification: a truncated upload, bytes
+// appended to an object, damage to a few parts and a completely different
+// object each call for a different response.
+func Diff(expected *Checksums, r io.Reader) (*Divergence, error) {
+	actual, err := Compute(r, expected.PartSize)
+	if err != nil {
+		return nil, err
+	}
+	return compare(expected, actual), nil
+}
+
+func compare(expected, actual *Checksums) *Divergence {
+	e, a := expected.Parts, actual.Parts
+	common := len(e)
+	if len(a) < common {
+		common = len(a)
+	}
+
+	d := &Divergence{}
+	matched := 0
+	for i := 0; i < common; i++ {
+		if bytes.Equal(e[i], a[i]) {
+			matched++
+		} else {
+			d.Differing = append(d.Differing, i)
+		}
+	}
+	for i := common; i < len(e) || i < len(a); i++ {
+		d.Differing = append(d.Differing, i)
+	}
+	if len(d.Differing) == 0 {
+		return d
+	}
+	d.Part = d.Differing[0]
+	d.Offset = int64(d.Part) * expected.PartSize
+
+	// Only the last common part, which may have been partial on the shorter
+	// side, is allowed to differ for a change in length.
+	prefixMatches := d.Part >= common-1
+	switch {
+	case matched == 0:
+		d.Kind = Unrelated
+	case len(a) < len(e) && prefixMatches:
+		d.Kind = Truncated
+	case len(a) > len(e) && prefixMatches:
+		d.Kind = Extended
+	default:
+		d.Kind = Corrupted
+	}
+	return d
+}
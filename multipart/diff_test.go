@@ -0,0 +1,51 @@
+package multipart
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	expected, _ := Compute(bytes.NewReader(data), 100)
+
+	corrupt := append([]byte(nil), data...)
+	corrupt[350] ^= 1
+	corrupt[720] ^= 1
+
+	unrelated := make([]byte, 1000)
+
+	for _, tc := range []struct {
+		name      string
+		input     []byte
+		kind      Kind
+		part      int
+		differing []int
+	}{
+		{"same", data, Match, 0, nil},
+		{"truncated at a boundary", data[:500], Truncated, 5, []int{5, 6, 7, 8, 9}},
+		{"truncated mid-part", data[:550], Truncated, 5, []int{5, 6, 7, 8, 9}},
+		{"extended", append(append([]byte(nil), data...), 1, 2, 3), Extended, 10, []int{10}},
+		{"corrupted", corrupt, Corrupted, 3, []int{3, 7}},
+		{"unrelated", unrelated, Unrelated, 0, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+	} {
+		d, err := Diff(expected, bytes.NewReader(tc.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Kind != tc.kind || d.Part != tc.part || d.Offset != int64(tc.part)*100 || !reflect.DeepEqual(d.Differing, tc.differing) {
+			t.Errorf("%s: got %+v", tc.name, d)
+		}
+	}
+
+	// Checksums don't record the length, so a change in length that stays
+	// within the last part can't be told apart from damage to it.
+	short, _ := Compute(bytes.NewReader(data[:950]), 100)
+	if d, _ := Diff(short, bytes.NewReader(data)); d.Kind != Corrupted || d.Part != 9 {
+		t.Errorf("extended partial part: got %v", d)
+	}
+}
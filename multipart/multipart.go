@@ -0,0 +1,120 @@
+// Package multipart computes BLAKE2s checksums for multipart uploads.
+//
+// Each part gets its own digest, and the object as a whole gets the digest of
+// the concatenated part digests. This mirrors the "checksum of checksums"
+// convention object stores use for composite multipart checksums, so a store
+// that accepts custom checksums can use BLAKE2s end to end: it verifies each
+// part as it arrives and the combined value once the upload completes,
+// without rehashing the assembled object.
+package multipart
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/gtank/blake2s"
+)
+
+// A Writer splits everything written to it into parts of a fixed size and
+// hashes each one.
+type Writer struct {
+	partSize int64
+	current  *blake2s.Digest
+	written  int64 // bytes in the current part
+	parts    [][]byte
+}
+
+// NewWriter returns a Writer producing parts of partSize bytes. The final
+// part may be shorter.
+func NewWriter(partSize int64) (*Writer, error) {
+	if partSize <= 0 {
+		return nil, errors.New("multipart: part size must be positive")
+	}
+	return &Writer{partSize: partSize}, nil
+}
+
+// Write adds data to the current part, starting new parts as needed.
+func (w *Writer) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if w.current == nil {
+			d, err := blake2s.NewDigest(nil, nil, nil, blake2s.MaxOutput)
+			if err != nil {
+				return n - len(p), err
+			}
+			w.current = d
+			w.written = 0
+		}
+		chunk := p
+		if room := w.partSize - w.written; int64(len(chunk)) > room {
+			chunk = chunk[:room]
+		}
+		w.current.Write(chunk)
+		w.written += int64(len(chunk))
+		p = p[len(chunk):]
+		if w.written == w.partSize {
+			w.endPart()
+		}
+	}
+	return n, nil
+}
+
+func (w *Writer) endPart() {
+	w.parts = append(w.parts, w.current.Sum(nil))
+	w.current = nil
+}
+
+// Checksums finishes the current part, if any, and returns the results. An
+// empty input has a single empty part, since an upload always has at least
+// one. No more data should be written afterwards.
+func (w *Writer) Checksums() *Checksums {
+	if w.current != nil || len(w.parts) == 0 {
+		if w.current == nil {
+			w.current, _ = blake2s.NewDigest(nil, nil, nil, blake2s.MaxOutput)
+		}
+		w.endPart()
+	}
+
+	combined, _ := blake2s.NewDigest(nil, nil, nil, blake2s.MaxOutput)
+	for _, part := range w.parts {
+		combined.Write(part)
+	}
+	return &Checksums{
+		PartSize: w.partSize,
+		Parts:    w.parts,
+		Combined: combined.Sum(nil),
+	}
+}
+
+// Checksums holds the per-part and combined digests of an upload.
+type Checksums struct {
+	PartSize int64
+	Parts    [][]byte
+	Combined []byte
+}
+
+// String returns the combined digest in the composite form object stores
+// display: base64 followed by a dash and the number of parts.
+func (c *Checksums) String() string {
+	return base64.StdEncoding.EncodeToString(c.Combined) + "-" + strconv.Itoa(len(c.Parts))
+}
+
+// PartBase64 returns the digest of part i (counting from zero) in base64,
+// the form usually sent with each part upload.
+func (c *Checksums) PartBase64(i int) string {
+	return base64.StdEncoding.EncodeToString(c.Parts[i])
+}
+
+// Compute reads r to the end and returns its multipart checksums.
+func Compute(r io.Reader, partSize int64) (*Checksums, error) {
+	w, err := NewWriter(partSize)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		return nil, err
+	}
+	return w.Checksums(), nil
+}
@@ -0,0 +1,78 @@
+package multipart
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gtank/blake2s"
+)
+
+func sum(data []byte) []byte {
+	d, _ := blake2s.NewDigest(nil, nil, nil, blake2s.MaxOutput)
+	d.Write(data)
+	return d.Sum(nil)
+}
+
+func TestCompute(t *testing.T) {
+	data := make([]byte, 250)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	c, err := Compute(bytes.NewReader(data), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(c.Parts))
+	}
+	parts := [][]byte{data[:100], data[100:200], data[200:]}
+	var concat []byte
+	for i, p := range parts {
+		if !bytes.Equal(c.Parts[i], sum(p)) {
+			t.Errorf("part %d digest mismatch", i)
+		}
+		concat = append(concat, sum(p)...)
+	}
+	if !bytes.Equal(c.Combined, sum(concat)) {
+		t.Error("combined digest mismatch")
+	}
+	if !strings.HasSuffix(c.String(), "-3") {
+		t.Errorf("unexpected composite form %s", c)
+	}
+}
+
+func TestWriteSplitsIndependentOfCalls(t *testing.T) {
+	data := make([]byte, 300)
+	a, _ := Compute(bytes.NewReader(data), 64)
+
+	w, _ := NewWriter(64)
+	for i := 0; i < len(data); i += 7 {
+		end := i + 7
+		if end > len(data) {
+			end = len(data)
+		}
+		w.Write(data[i:end])
+	}
+	b := w.Checksums()
+	if !bytes.Equal(a.Combined, b.Combined) || len(a.Parts) != len(b.Parts) {
+		t.Error("result depends on write boundaries")
+	}
+}
+
+func TestExactAndEmpty(t *testing.T) {
+	c, _ := Compute(bytes.NewReader(make([]byte, 128)), 64)
+	if len(c.Parts) != 2 {
+		t.Errorf("exact multiple produced %d parts", len(c.Parts))
+	}
+
+	c, _ = Compute(bytes.NewReader(nil), 64)
+	if len(c.Parts) != 1 || !bytes.Equal(c.Parts[0], sum(nil)) {
+		t.Error("empty input should have one empty part")
+	}
+
+	if _, err := NewWriter(0); err == nil {
+		t.Error("accepted a zero part size")
+	}
+}
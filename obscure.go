@@ -0,0 +1,54 @@
+package blake2s
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// obscureSize is the token size for ObscurePath, in bytes. At 128 bits,
+// collisions between distinct paths are not a practical concern.
+const obscureSize = 16
+
+var obscurePersona = []byte("obscpath")
+
+func obscure(key []byte, s string) string {
+	d, err := NewDigest(key, nil, obscurePersona, obscureSize)
+	if err != nil {
+		panic(err)
+	}
+	defer d.Wipe()
+	d.Write([]byte(s))
+	return hex.EncodeToString(d.Sum(nil))
+}
+
+// ObscurePath returns a deterministic token standing in for path, for
+// telemetry that must pseudonymize file paths. The token is a keyed BLAKE2s
+// hash, so only holders of the key can link a token to a guessed path. The
+// key must be non-empty and at most KeyLength bytes; ObscurePath panics
+// otherwise.
+func ObscurePath(key []byte, path string) string {
+	checkObscureKey(key)
+	return obscure(key, path)
+}
+
+// ObscurePathSegments is like ObscurePath, but replaces each slash-separated
+// segment separately and keeps the slashes. The result preserves the shape
+// of the tree: paths that share a directory share a token prefix, so events
+// can still be grouped by directory without revealing its name. Empty
+// segments, as in a leading slash, stay empty.
+func ObscurePathSegments(key []byte, path string) string {
+	checkObscureKey(key)
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if s != "" {
+			segments[i] = obscure(key, s)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func checkObscureKey(key []byte) {
+	if len(key) == 0 || len(key) > KeyLength {
+		panic("blake2s: ObscurePath key must be 1 to 32 bytes")
+	}
+}
@@ -0,0 +1,47 @@
+package blake2s
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestObscurePath(t *testing.T) {
+	key := []byte("telemetry key")
+	a := ObscurePath(key, "/home/alice/notes.txt")
+	if a != ObscurePath(key, "/home/alice/notes.txt") {
+		t.Error("token is not deterministic")
+	}
+	if len(a) != 2*obscureSize || strings.Contains(a, "alice") {
+		t.Errorf("unexpected token %q", a)
+	}
+	if a == ObscurePath(key, "/home/alice/notes.txT") {
+		t.Error("different paths share a token")
+	}
+	if a == ObscurePath([]byte("other key"), "/home/alice/notes.txt") {
+		t.Error("different keys share a token")
+	}
+}
+
+func TestObscurePathSegments(t *testing.T) {
+	key := []byte("telemetry key")
+	a := strings.Split(ObscurePathSegments(key, "/home/alice/notes.txt"), "/")
+	b := strings.Split(ObscurePathSegments(key, "/home/alice/todo.txt"), "/")
+	if len(a) != 4 || a[0] != "" {
+		t.Fatalf("structure not preserved: %v", a)
+	}
+	if a[1] != b[1] || a[2] != b[2] || a[3] == b[3] {
+		t.Error("shared directories should share tokens and files should not")
+	}
+	if a[3] != ObscurePath(key, "notes.txt") {
+		t.Error("segment token differs from the whole-path token of the same string")
+	}
+}
+
+func TestObscurePathNeedsKey(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic without a key")
+		}
+	}()
+	ObscurePath(nil, "x")
+}
@@ -0,0 +1,134 @@
+// Package oci computes BLAKE2s digests of container image layers, in the
+// "blake2s:<hex>" reference form, for registries experimenting with digest
+// algorithms other than SHA-256.
+//
+// A layer has two digests: the digest of the blob as stored and transferred
+// (usually a compressed tarball), and the "diff ID", the digest of the
+// uncompressed tarball. DigestLayer computes both in a single pass.
+package oci
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gtank/blake2s"
+)
+
+// Algorithm is the algorithm component of a BLAKE2s digest reference.
+const Algorithm = "blake2s"
+
+// Reference formats a digest as "blake2s:<hex>".
+func Reference(digest []byte) string {
+	return Algorithm + ":" + hex.EncodeToString(digest)
+}
+
+// ParseReference decodes a "blake2s:<hex>" reference. The hex must be
+// lowercase and encode a full 32-byte digest, as the OCI digest grammar
+// expects for a fixed-size algorithm.
+func ParseReference(ref string) ([]byte, error) {
+	encoded := strings.TrimPrefix(ref, Algorithm+":")
+	if encoded == ref {
+		return nil, fmt.Errorf("oci: %q is not a %s reference", ref, Algorithm)
+	}
+	if len(encoded) != 2*blake2s.MaxOutput || strings.ToLower(encoded) != encoded {
+		return nil, fmt.Errorf("oci: malformed %s reference %q", Algorithm, ref)
+	}
+	return hex.DecodeString(encoded)
+}
+
+// A Decompressor wraps a compressed layer stream.
+type Decompressor func(io.Reader) (io.Reader, error)
+
+// Gzip decompresses gzip layers.
+func Gzip(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ErrNeedDecompressor is returned by DigestLayer for a zstd layer when no
+// Decompressor was supplied. The standard library has no zstd decoder, so
+// callers who need one bring their own.
+var ErrNeedDecompressor = errors.New("oci: zstd layer requires a Decompressor")
+
+// LayerDigests describes one layer blob.
+type LayerDigests struct {
+	Digest string // reference to the blob as stored
+	DiffID string // reference to the uncompressed tarball
+	Size   int64  // size of the blob as stored
+}
+
+// DigestLayer reads a layer blob to the end and returns its digests. If
+// decompress is nil, the compression is detected from the stream: gzip is
+// handled, uncompressed tarballs have a diff ID equal to their digest, and
+// zstd returns ErrNeedDecompressor.
+func DigestLayer(r io.Reader, decompress Decompressor) (*LayerDigests, error) {
+	blobDigest, err := blake2s.NewDigest(nil, nil, nil, blake2s.MaxOutput)
+	if err != nil {
+		return nil, err
+	}
+	counter := &countingWriter{}
+	blob := bufio.NewReader(io.TeeReader(r, io.MultiWriter(blobDigest, counter)))
+
+	if decompress == nil {
+		magic, err := blob.Peek(len(zstdMagic))
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		switch {
+		case bytes.HasPrefix(magic, gzipMagic):
+			decompress = Gzip
+		case bytes.HasPrefix(magic, zstdMagic):
+			return nil, ErrNeedDecompressor
+		}
+	}
+
+	var diffID []byte
+	if decompress != nil {
+		tar, err := decompress(blob)
+		if err != nil {
+			return nil, err
+		}
+		diffDigest, err := blake2s.NewDigest(nil, nil, nil, blake2s.MaxOutput)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(diffDigest, tar); err != nil {
+			return nil, err
+		}
+		diffID = diffDigest.Sum(nil)
+	}
+
+	// Whatever the decompressor didn't consume is still part of the blob.
+	if _, err := io.Copy(io.Discard, blob); err != nil {
+		return nil, err
+	}
+	digest := blobDigest.Sum(nil)
+	if diffID == nil {
+		diffID = digest
+	}
+
+	return &LayerDigests{
+		Digest: Reference(digest),
+		DiffID: Reference(diffID),
+		Size:   counter.n,
+	}, nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,87 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/gtank/blake2s"
+)
+
+func sum(data []byte) []byte {
+	d, _ := blake2s.NewDigest(nil, nil, nil, blake2s.MaxOutput)
+	d.Write(data)
+	return d.Sum(nil)
+}
+
+func testTar(t *testing.T) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	content := []byte("layer content")
+	tw.WriteHeader(&tar.Header{Name: "file", Mode: 0644, Size: int64(len(content))})
+	tw.Write(content)
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDigestLayer(t *testing.T) {
+	layer := testTar(t)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(layer)
+	zw.Close()
+
+	d, err := DigestLayer(bytes.NewReader(gz.Bytes()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Digest != Reference(sum(gz.Bytes())) || d.DiffID != Reference(sum(layer)) || d.Size != int64(gz.Len()) {
+		t.Errorf("unexpected gzip digests %+v", d)
+	}
+
+	d, err = DigestLayer(bytes.NewReader(layer), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Digest != d.DiffID || d.Digest != Reference(sum(layer)) {
+		t.Errorf("unexpected uncompressed digests %+v", d)
+	}
+}
+
+func TestZstdNeedsDecompressor(t *testing.T) {
+	blob := append([]byte{0x28, 0xb5, 0x2f, 0xfd}, "frame"...)
+	if _, err := DigestLayer(bytes.NewReader(blob), nil); err != ErrNeedDecompressor {
+		t.Errorf("expected ErrNeedDecompressor, got %v", err)
+	}
+
+	identity := func(r io.Reader) (io.Reader, error) { return r, nil }
+	d, err := DigestLayer(bytes.NewReader(blob), identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.DiffID != Reference(sum(blob)) {
+		t.Error("custom decompressor output was not hashed")
+	}
+}
+
+func TestParseReference(t *testing.T) {
+	digest := sum([]byte("x"))
+	parsed, err := ParseReference(Reference(digest))
+	if err != nil || !bytes.Equal(parsed, digest) {
+		t.Errorf("reference did not round-trip: %v", err)
+	}
+	bad := []string{
+		"sha256:" + Reference(digest)[8:],
+		"blake2s:00",
+		"blake2s:" + "AA" + Reference(digest)[10:],
+	}
+	for _, ref := range bad {
+		if _, err := ParseReference(ref); err == nil {
+			t.Errorf("accepted %q", ref)
+		}
+	}
+}
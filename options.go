@@ -0,0 +1,139 @@
+package blake2s
+
+import (
+	"errors"
+	"hash"
+)
+
+// ErrMaxInput is returned by Write when accepting the input would exceed the
+// limit configured with WithMaxInput. The rejected input is not hashed.
+var ErrMaxInput = errors.New("blake2s: input exceeds configured maximum")
+
+// An Option configures a Digest constructed by New.
+type Option func(*config) error
+
+type config struct {
+	key, salt, personalization []byte
+	size                       int
+	maxInput                   uint64
+	hooks                      *CompressHooks
+	guard                      *Guard
+	tree                       *TreeParams
+}
+
+// WithKey sets the key for a keyed (MAC) instance.
+func WithKey(key []byte) Option {
+	return func(c *config) error {
+		c.key = key
+		return nil
+	}
+}
+
+// WithSalt sets the salt field of the parameter block.
+func WithSalt(salt []byte) Option {
+	return func(c *config) error {
+		c.salt = salt
+		return nil
+	}
+}
+
+// WithPersonalization sets the personalization field of the parameter block.
+func WithPersonalization(personalization []byte) Option {
+	return func(c *config) error {
+		c.personalization = personalization
+		return nil
+	}
+}
+
+// WithSize sets the number of bytes of output, between 1 and MaxOutput.
+func WithSize(outputBytes int) Option {
+	return func(c *config) error {
+		c.size = outputBytes
+		return nil
+	}
+}
+
+// WithMaxInput limits the total number of bytes the Digest will accept. Once
+// a Write would take the total past n, it returns ErrMaxInput instead. This
+// lets servers hashing untrusted streams enforce a resource policy where the
+// hashing happens.
+func WithMaxInput(n uint64) Option {
+	return func(c *config) error {
+		if n == 0 {
+			return errors.New("blake2s: max input must be positive")
+		}
+		c.maxInput = n
+		return nil
+	}
+}
+
+// WithCompressHooks installs callbacks around every call to the compression
+// function of this Digest. See CompressHooks.
+func WithCompressHooks(hooks CompressHooks) Option {
+	return func(c *config) error {
+		c.hooks = &hooks
+		return nil
+	}
+}
+
+// New constructs a new instance of a BLAKE2s hash configured by opts. With no
+// options, it is an unkeyed hash producing MaxOutput bytes.
+//
+// New, NewKeyed and NewMAC are the preferred constructors for new code.
+// NewDigest remains supported and is equivalent to New with the matching
+// options.
+func New(opts ...Option) (*Digest, error) {
+	c := &config{size: MaxOutput}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	d, err := newDigest(c.key, c.salt, c.personalization, c.size, c.tree)
+	if err != nil {
+		return nil, err
+	}
+	d.maxInput = c.maxInput
+	d.hooks = c.hooks
+	d.guard = c.guard
+
+	return d, nil
+}
+
+// NewKeyed returns a keyed BLAKE2s hash producing MaxOutput bytes. It is
+// shorthand for New(WithKey(key)), except that the key must not be empty.
+func NewKeyed(key []byte) (*Digest, error) {
+	return NewMAC(key, MaxOutput)
+}
+
+// NewMAC returns a keyed BLAKE2s hash producing size bytes, for use as a
+// message authentication code. The key must not be empty.
+func NewMAC(key []byte, size int) (*Digest, error) {
+	if len(key) == 0 {
+		return nil, errors.New("blake2s: MAC requires a key")
+	}
+	return New(WithKey(key), WithSize(size))
+}
+
+// New256 returns a BLAKE2s-256 hash, keyed with key if it is not empty. It and
+// the other fixed-size constructors mirror golang.org/x/crypto/blake2s, and
+// make it easy to build the func() hash.Hash factories generic code expects.
+func New256(key []byte) (hash.Hash, error) { return newSized(key, 32) }
+
+// New224 returns a BLAKE2s-224 hash, keyed with key if it is not empty.
+func New224(key []byte) (hash.Hash, error) { return newSized(key, 28) }
+
+// New160 returns a BLAKE2s-160 hash, keyed with key if it is not empty.
+func New160(key []byte) (hash.Hash, error) { return newSized(key, 20) }
+
+// New128 returns a BLAKE2s-128 hash, keyed with key if it is not empty.
+func New128(key []byte) (hash.Hash, error) { return newSized(key, 16) }
+
+func newSized(key []byte, size int) (hash.Hash, error) {
+	d, err := New(WithKey(key), WithSize(size))
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
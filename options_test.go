@@ -0,0 +1,120 @@
+package blake2s
+
+import (
+	"bytes"
+	"hash"
+	"testing"
+)
+
+func TestNewMatchesNewDigest(t *testing.T) {
+	key := []byte("key")
+	d, err := New(WithKey(key), WithSalt([]byte("salt")), WithPersonalization([]byte("persona")), WithSize(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := NewDigest(key, []byte("salt"), []byte("persona"), 20)
+
+	d.Write([]byte("message"))
+	expected.Write([]byte("message"))
+	if !bytes.Equal(expected.Sum(nil), d.Sum(nil)) {
+		t.Error("New and NewDigest disagree")
+	}
+
+	if _, err := New(WithSize(MaxOutput + 1)); err == nil {
+		t.Error("New accepted an oversized output")
+	}
+}
+
+func TestMaxInput(t *testing.T) {
+	// The key block must not count against the limit.
+	d, err := New(WithKey([]byte("key")), WithMaxInput(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := d.Write(make([]byte, 70)); n != 70 || err != nil {
+		t.Fatalf("write under the limit failed: %d, %v", n, err)
+	}
+	before := d.Sum(nil)
+
+	if n, err := d.Write(make([]byte, 31)); n != 0 || err != ErrMaxInput {
+		t.Fatalf("write over the limit returned %d, %v", n, err)
+	}
+	if !bytes.Equal(before, d.Sum(nil)) {
+		t.Error("rejected write changed the hash state")
+	}
+
+	if n, err := d.Write(make([]byte, 30)); n != 30 || err != nil {
+		t.Fatalf("write up to the limit failed: %d, %v", n, err)
+	}
+	if _, err := d.Write([]byte{0}); err != ErrMaxInput {
+		t.Error("write past an exhausted limit succeeded")
+	}
+
+	if _, err := New(WithMaxInput(0)); err == nil {
+		t.Error("accepted a zero max input")
+	}
+}
+
+func TestKeyedAliases(t *testing.T) {
+	key := []byte("key")
+	msg := []byte("message")
+	for _, tc := range []struct {
+		name string
+		new  func() (*Digest, error)
+		size int
+	}{
+		{"NewKeyed", func() (*Digest, error) { return NewKeyed(key) }, MaxOutput},
+		{"NewMAC", func() (*Digest, error) { return NewMAC(key, 16) }, 16},
+	} {
+		d, err := tc.new()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := NewDigest(key, nil, nil, tc.size)
+		d.Write(msg)
+		expected.Write(msg)
+		if !bytes.Equal(d.Sum(nil), expected.Sum(nil)) {
+			t.Errorf("%s differs from NewDigest", tc.name)
+		}
+	}
+
+	if _, err := NewKeyed(nil); err == nil {
+		t.Error("NewKeyed accepted an empty key")
+	}
+	if _, err := NewMAC(nil, 16); err == nil {
+		t.Error("NewMAC accepted an empty key")
+	}
+}
+
+func TestFixedSizes(t *testing.T) {
+	msg := []byte("message")
+	for _, tc := range []struct {
+		new  func(key []byte) (hash.Hash, error)
+		size int
+	}{
+		{New256, 32},
+		{New224, 28},
+		{New160, 20},
+		{New128, 16},
+	} {
+		for _, key := range [][]byte{nil, []byte("key")} {
+			h, err := tc.new(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h.Size() != tc.size {
+				t.Errorf("size %d constructor returns %d bytes", tc.size, h.Size())
+			}
+			expected, _ := NewDigest(key, nil, nil, tc.size)
+			h.Write(msg)
+			expected.Write(msg)
+			if !bytes.Equal(h.Sum(nil), expected.Sum(nil)) {
+				t.Errorf("size %d, key %q differs from NewDigest", tc.size, key)
+			}
+		}
+		if _, err := tc.new(make([]byte, KeyLength+1)); err == nil {
+			t.Errorf("size %d constructor accepted a long key", tc.size)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package blake2s
+
+import (
+	"errors"
+
+	"github.com/gtank/blake2s/core"
+)
+
+// A ParameterBlock is the 32-byte BLAKE2s parameter block, which is mixed
+// into the initial chaining value and so determines every digest. NewDigest,
+// New and WithTree fill one in for the common cases; NewDigestFromParams takes
+// one as is, for BLAKE2X, tree modes and protocols that specify the block
+// byte for byte. It is the core package's type, whose Marshal and Unmarshal
+// methods encode it.
+type ParameterBlock = core.ParameterBlock
+
+// ValidateParameterBlock reports whether p can be hashed with: the digest, key and inner
+// lengths must be in range, the depth may only be 0 for a BLAKE2X output
+// node, which has a nonzero XOFLength, and in sequential mode (Fanout and
+// Depth 1) the leaf length, node offset, node depth and inner length must be
+// 0, since stray values there give digests that match neither sequential
+// mode nor a real tree. Other combinations that are valid but unusual are
+// accepted; InspectParameterBlock points them out.
+func ValidateParameterBlock(p *ParameterBlock) error {
+	if p.DigestSize == 0 {
+		return errors.New("blake2s: asked for negative or zero output")
+	}
+	if p.DigestSize > MaxOutput {
+		return errors.New("blake2s: asked for too much output")
+	}
+	if p.KeyLength > KeyLength {
+		return errors.New("blake2s: key too large")
+	}
+	if p.InnerLength > MaxOutput {
+		return errors.New("blake2s: inner length too large")
+	}
+	if p.Depth == 0 && p.XOFLength == 0 {
+		return errors.New("blake2s: depth 0 is only valid for BLAKE2X output nodes")
+	}
+	if p.Fanout == 1 && p.Depth == 1 {
+		if p.LeafLength != 0 {
+			return errors.New("blake2s: leaf length must be 0 in sequential mode")
+		}
+		if p.NodeOffset != 0 {
+			return errors.New("blake2s: node offset must be 0 in sequential mode")
+		}
+		if p.NodeDepth != 0 {
+			return errors.New("blake2s: node depth must be 0 in sequential mode")
+		}
+		if p.InnerLength != 0 {
+			return errors.New("blake2s: inner length must be 0 in sequential mode")
+		}
+	}
+	return nil
+}
+
+// NewDigestFromParams constructs a BLAKE2s instance from an arbitrary
+// parameter block, which must pass ValidateParameterBlock. The key must be exactly
+// p.KeyLength bytes long. Unlike NewDigest, it doesn't require the tree
+// fields to describe sequential mode; setting them sensibly is up to the
+// caller. p is not retained.
+func NewDigestFromParams(p *ParameterBlock, key []byte) (*Digest, error) {
+	if err := ValidateParameterBlock(p); err != nil {
+		return nil, err
+	}
+	if len(key) != int(p.KeyLength) {
+		return nil, errors.New("blake2s: key length doesn't match the parameter block")
+	}
+	return fromParams(p, key), nil
+}
+
+// fromParams builds a Digest from a valid parameter block and its key.
+func fromParams(p *ParameterBlock, key []byte) *Digest {
+	d := initFromParams(p)
+	d.backend = selectedBackend()
+	d.keyLen = copy(d.key[:], key)
+	d.salt = p.Salt
+	d.persona = p.Personalization
+
+	if len(key) > 0 {
+		// Write key to entire first block and compress
+		keyBuf := make([]byte, BlockSize)
+		copy(keyBuf, key)
+		d.Write(keyBuf)
+		clear(keyBuf)
+	}
+	return d
+}
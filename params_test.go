@@ -0,0 +1,104 @@
+package blake2s
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewDigestFromParams(t *testing.T) {
+	key := []byte("params key")
+	p := &ParameterBlock{DigestSize: 24, KeyLength: byte(len(key)), Fanout: 1, Depth: 1}
+	copy(p.Salt[:], "salt")
+	copy(p.Personalization[:], "persona")
+	d, err := NewDigestFromParams(p, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := NewDigest(key, []byte("salt"), []byte("persona"), 24)
+	d.Write([]byte("message"))
+	want.Write([]byte("message"))
+	if !bytes.Equal(d.Sum(nil), want.Sum(nil)) {
+		t.Error("sequential parameter block differs from NewDigest")
+	}
+	if d.salt != want.salt || d.persona != want.persona {
+		t.Error("salt or personalization not retained")
+	}
+
+	// A tree node matches the same node built with WithTree.
+	tree := TreeParams{Fanout: 2, MaxDepth: 2, NodeOffset: 1<<40 | 5, NodeDepth: 0, InnerLength: 32}
+	node := &ParameterBlock{DigestSize: 32, Fanout: 2, Depth: 2, NodeOffset: 5, XOFLength: 1 << 8, InnerLength: 32}
+	d, err = NewDigestFromParams(node, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ = New(WithTree(tree))
+	if !bytes.Equal(d.Sum(nil), want.Sum(nil)) {
+		t.Error("tree parameter block differs from WithTree")
+	}
+
+	for _, tc := range []struct {
+		p   ParameterBlock
+		key []byte
+	}{
+		{ParameterBlock{DigestSize: 0, Fanout: 1, Depth: 1}, nil},
+		{ParameterBlock{DigestSize: 33, Fanout: 1, Depth: 1}, nil},
+		{ParameterBlock{DigestSize: 32, KeyLength: 33, Fanout: 1, Depth: 1}, make([]byte, 33)},
+		{ParameterBlock{DigestSize: 32, Fanout: 2, Depth: 2, InnerLength: 33}, nil},
+		{ParameterBlock{DigestSize: 32, Fanout: 1}, nil},
+		{ParameterBlock{DigestSize: 32, KeyLength: 4, Fanout: 1, Depth: 1}, []byte("key")},
+		{ParameterBlock{DigestSize: 32, Fanout: 1, Depth: 1}, []byte("key")},
+		// Tree fields in sequential mode.
+		{ParameterBlock{DigestSize: 32, Fanout: 1, Depth: 1, LeafLength: 4096}, nil},
+		{ParameterBlock{DigestSize: 32, Fanout: 1, Depth: 1, NodeOffset: 1}, nil},
+		{ParameterBlock{DigestSize: 32, Fanout: 1, Depth: 1, NodeDepth: 1}, nil},
+		{ParameterBlock{DigestSize: 32, Fanout: 1, Depth: 1, InnerLength: 32}, nil},
+	} {
+		if _, err := NewDigestFromParams(&tc.p, tc.key); err == nil {
+			t.Errorf("accepted %+v with a %d-byte key", tc.p, len(tc.key))
+		}
+	}
+}
+
+func TestSequentialTreeFields(t *testing.T) {
+	// The same fields are fine outside sequential mode, and a BLAKE2X root
+	// is sequential apart from its XOF length.
+	for _, p := range []ParameterBlock{
+		{DigestSize: 32, Fanout: 2, Depth: 2, LeafLength: 4096, NodeOffset: 1, InnerLength: 32},
+		{DigestSize: 32, Fanout: 1, Depth: 2, NodeDepth: 1, InnerLength: 32},
+		{DigestSize: 32, Fanout: 1, Depth: 1, XOFLength: 100},
+	} {
+		if err := ValidateParameterBlock(&p); err != nil {
+			t.Errorf("%+v: %v", p, err)
+		}
+	}
+
+	if _, err := New(WithTree(TreeParams{Fanout: 1, MaxDepth: 1, LeafLength: 4096})); err == nil {
+		t.Error("WithTree accepted a leaf length in sequential mode")
+	}
+	if _, err := New(WithTree(TreeParams{Fanout: 1, MaxDepth: 1, LastNode: true})); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParameterBlockMarshal(t *testing.T) {
+	p := ParameterBlock{
+		DigestSize: 1, KeyLength: 2, Fanout: 3, Depth: 4,
+		LeafLength: 0x08070605, NodeOffset: 0x0c0b0a09, XOFLength: 0x0e0d,
+		NodeDepth: 15, InnerLength: 16,
+		Salt:            [SaltLength]byte{17, 18, 19, 20, 21, 22, 23, 24},
+		Personalization: [SeparatorLength]byte{25, 26, 27, 28, 29, 30, 31, 32},
+	}
+	b := p.Marshal()
+	for i, c := range b {
+		if c != byte(i+1) {
+			t.Fatalf("byte %d is %d, want %d", i, c, i+1)
+		}
+	}
+	var q ParameterBlock
+	if err := q.Unmarshal(b[:]); err != nil || q != p {
+		t.Errorf("round trip gave %+v, %v", q, err)
+	}
+	if err := q.Unmarshal(b[:31]); err == nil {
+		t.Error("accepted a short block")
+	}
+}
@@ -0,0 +1,107 @@
+package blake2s
+
+import (
+	"errors"
+	"io"
+)
+
+// A PassthroughWriter forwards data to an underlying writer and hashes it
+// on the way, buffering at most a fixed number of bytes. It is meant for
+// proxies that compute a digest of a body they relay: small writes are
+// coalesced, but once the buffer is full, Write blocks on the destination
+// rather than absorbing more data, so a fast client can't make the proxy
+// hold an unbounded amount of its input.
+//
+// The digest covers exactly the bytes delivered to the destination, so
+// Sum doesn't include Pending bytes until they are flushed.
+type PassthroughWriter struct {
+	w   io.Writer
+	d   *Digest
+	buf []byte
+	err error
+}
+
+// NewPassthroughWriter returns a PassthroughWriter forwarding to w and hashing
+// with d, which buffers up to maxPending bytes. With maxPending zero, every
+// Write goes straight through.
+func NewPassthroughWriter(w io.Writer, d *Digest, maxPending int) (*PassthroughWriter, error) {
+	if maxPending < 0 {
+		return nil, errors.New("blake2s: negative buffer size")
+	}
+	return &PassthroughWriter{w: w, d: d, buf: make([]byte, 0, maxPending)}, nil
+}
+
+// Write buffers p if it fits and otherwise forwards the buffer and p to the
+// destination before returning. After a destination error, every call
+// returns that error.
+func (pw *PassthroughWriter) Write(p []byte) (int, error) {
+	if pw.err != nil {
+		return 0, pw.err
+	}
+	if len(p) <= cap(pw.buf)-len(pw.buf) {
+		pw.buf = append(pw.buf, p...)
+		return len(p), nil
+	}
+	if err := pw.flushBuffer(); err != nil {
+		return 0, err
+	}
+	if len(p) <= cap(pw.buf) {
+		pw.buf = append(pw.buf, p...)
+		return len(p), nil
+	}
+	return pw.forward(p)
+}
+
+// forward writes p to the destination, hashing whatever was delivered.
+func (pw *PassthroughWriter) forward(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.d.Write(p[:n])
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		pw.err = err
+	}
+	return n, err
+}
+
+func (pw *PassthroughWriter) flushBuffer() error {
+	if len(pw.buf) == 0 {
+		return nil
+	}
+	n, err := pw.forward(pw.buf)
+	pw.buf = pw.buf[:copy(pw.buf, pw.buf[n:])]
+	return err
+}
+
+// Flush forwards any buffered bytes, then flushes the destination if it has
+// a Flush method, as http.ResponseWriter and bufio.Writer do.
+func (pw *PassthroughWriter) Flush() error {
+	if pw.err != nil {
+		return pw.err
+	}
+	if err := pw.flushBuffer(); err != nil {
+		return err
+	}
+	switch f := pw.w.(type) {
+	case interface{ Flush() error }:
+		if err := f.Flush(); err != nil {
+			pw.err = err
+			return err
+		}
+	case interface{ Flush() }:
+		f.Flush()
+	}
+	return nil
+}
+
+// Pending returns the number of bytes accepted by Write but not yet
+// delivered.
+func (pw *PassthroughWriter) Pending() int {
+	return len(pw.buf)
+}
+
+// Sum appends the digest of the bytes delivered so far to b.
+func (pw *PassthroughWriter) Sum(b []byte) []byte {
+	return pw.d.Sum(b)
+}
@@ -0,0 +1,88 @@
+package blake2s
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// countingWriter records the size of every write it receives.
+type countingWriter struct {
+	bytes.Buffer
+	writes []int
+	fail   bool
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	if w.fail {
+		return 0, errors.New("client went away")
+	}
+	w.writes = append(w.writes, len(p))
+	return w.Buffer.Write(p)
+}
+
+func TestPassthroughWriter(t *testing.T) {
+	dst := &countingWriter{}
+	d, _ := NewDigest(nil, nil, nil, MaxOutput)
+	pw, err := NewPassthroughWriter(dst, d, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	empty, _ := NewDigest(nil, nil, nil, MaxOutput)
+	pw.Write([]byte("abcd"))
+	pw.Write([]byte("efgh"))
+	if pw.Pending() != 8 || dst.Len() != 0 || !bytes.Equal(pw.Sum(nil), empty.Sum(nil)) {
+		t.Fatalf("small writes not buffered: pending %d, delivered %d", pw.Pending(), dst.Len())
+	}
+
+	// Overflowing the buffer delivers what was buffered first.
+	pw.Write([]byte("ijklm"))
+	if pw.Pending() != 5 || dst.String() != "abcdefgh" {
+		t.Fatalf("pending %d, delivered %q", pw.Pending(), dst.String())
+	}
+
+	// Writes larger than the buffer go straight through.
+	big := bytes.Repeat([]byte("x"), 100)
+	pw.Write(big)
+	if pw.Pending() != 0 || dst.writes[len(dst.writes)-1] != 100 {
+		t.Fatalf("large write was buffered: %v", dst.writes)
+	}
+
+	pw.Write([]byte("tail"))
+	if err := pw.Flush(); err != nil || pw.Pending() != 0 {
+		t.Fatal(err)
+	}
+	expected, _ := NewDigest(nil, nil, nil, MaxOutput)
+	expected.Write(dst.Bytes())
+	if dst.String() != "abcdefghijklm"+string(big)+"tail" || !bytes.Equal(pw.Sum(nil), expected.Sum(nil)) {
+		t.Error("delivered data or digest is wrong")
+	}
+
+	dst.fail = true
+	pw.Write([]byte("lost"))
+	if err := pw.Flush(); err == nil {
+		t.Error("destination error not reported")
+	}
+	if _, err := pw.Write([]byte("more")); err == nil {
+		t.Error("write after a destination error succeeded")
+	}
+	if !bytes.Equal(pw.Sum(nil), expected.Sum(nil)) {
+		t.Error("undelivered data was hashed")
+	}
+}
+
+func TestPassthroughWriterFlushesDestination(t *testing.T) {
+	var out bytes.Buffer
+	bw := bufio.NewWriter(&out)
+	d, _ := NewDigest(nil, nil, nil, MaxOutput)
+	pw, _ := NewPassthroughWriter(bw, d, 0)
+	pw.Write([]byte("hello"))
+	if err := pw.Flush(); err != nil || out.String() != "hello" {
+		t.Errorf("destination not flushed: %q, %v", out.String(), err)
+	}
+	if _, err := NewPassthroughWriter(bw, d, -1); err == nil {
+		t.Error("accepted a negative buffer size")
+	}
+}
@@ -0,0 +1,62 @@
+package blake2s
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+var personas = struct {
+	sync.Mutex
+	sites map[[SeparatorLength]byte]string
+}{sites: make(map[[SeparatorLength]byte]string)}
+
+// RegisterPersona declares a personalization string used within this
+// program and returns it ready for NewDigest or WithPersonalization. It is
+// meant for package-level variable initializers:
+//
+//	var manifestPersona = blake2s.RegisterPersona("manifst1")
+//
+// Registering the same personalization twice, even from different packages,
+// panics with both call sites. Since registration happens at init time, the
+// panic fires in any test binary that links the conflicting packages, which
+// keeps domain separation in a large codebase actually separate. Strings
+// that differ only in trailing zero bytes are the same personalization.
+// Longer than SeparatorLength bytes also panics.
+func RegisterPersona(persona string) []byte {
+	if len(persona) > SeparatorLength {
+		panic(fmt.Sprintf("blake2s: persona %q is longer than %d bytes", persona, SeparatorLength))
+	}
+	var key [SeparatorLength]byte
+	copy(key[:], persona)
+
+	site := "unknown"
+	if _, file, line, ok := runtime.Caller(1); ok {
+		site = fmt.Sprintf("%s:%d", file, line)
+	}
+
+	personas.Lock()
+	defer personas.Unlock()
+	if previous, ok := personas.sites[key]; ok {
+		panic(fmt.Sprintf("blake2s: persona %q registered at %s and again at %s", persona, previous, site))
+	}
+	personas.sites[key] = site
+	return []byte(persona)
+}
+
+// RegisteredPersonas returns every registered personalization, sorted.
+func RegisteredPersonas() []string {
+	personas.Lock()
+	defer personas.Unlock()
+	list := make([]string, 0, len(personas.sites))
+	for key := range personas.sites {
+		n := len(key)
+		for n > 0 && key[n-1] == 0 {
+			n--
+		}
+		list = append(list, string(key[:n]))
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,49 @@
+package blake2s
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegisterPersona(t *testing.T) {
+	p := RegisterPersona("testreg1")
+	if string(p) != "testreg1" {
+		t.Errorf("unexpected persona %q", p)
+	}
+	found := false
+	for _, name := range RegisteredPersonas() {
+		found = found || name == "testreg1"
+	}
+	if !found {
+		t.Error("persona not listed")
+	}
+
+	func() {
+		defer func() {
+			msg, _ := recover().(string)
+			if !strings.Contains(msg, "persona_test.go") {
+				t.Errorf("duplicate: unexpected panic %q", msg)
+			}
+		}()
+		RegisterPersona("testreg1")
+	}()
+
+	RegisterPersona("testreg")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("zero-padded duplicate did not panic")
+			}
+		}()
+		RegisterPersona("testreg\x00")
+	}()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("overlong persona did not panic")
+			}
+		}()
+		RegisterPersona("ninechars")
+	}()
+}
@@ -0,0 +1,103 @@
+package blake2s
+
+import (
+	"errors"
+	"sync"
+)
+
+// A Pool recycles Digests that share a key and output size. Get hands out a
+// copy of a cached freshly-initialized state, so neither the parameter block
+// nor the key block is recomputed per message.
+type Pool struct {
+	proto Digest
+	free  sync.Pool
+}
+
+// NewPool returns a Pool of unsalted, unpersonalized Digests with the given
+// key (which may be nil) and output size.
+func NewPool(key []byte, size int) (*Pool, error) {
+	d, err := NewDigest(key, nil, nil, size)
+	if err != nil {
+		return nil, err
+	}
+	return &Pool{proto: *d}, nil
+}
+
+// Get returns a Digest in its initial state.
+func (p *Pool) Get() *Digest {
+	d, _ := p.free.Get().(*Digest)
+	if d == nil {
+		d = new(Digest)
+	}
+	*d = p.proto
+	return d
+}
+
+// Put returns d to the pool. Digests constructed with different parameters
+// are dropped rather than recycled. d must not be used after Put.
+func (p *Pool) Put(d *Digest) {
+	if !p.matches(d) {
+		return
+	}
+	p.free.Put(d)
+}
+
+func (p *Pool) matches(d *Digest) bool {
+	return d.size == p.proto.size && d.keyLen == p.proto.keyLen &&
+		d.key == p.proto.key && d.salt == p.proto.salt && d.persona == p.proto.persona
+}
+
+type poolKey struct {
+	key  [KeyLength]byte
+	len  int
+	size int
+}
+
+// Pools is a set of Pools sharded by key and output size, for servers that
+// hash under a handful of configurations. The zero value is ready to use.
+type Pools struct {
+	mu    sync.Mutex
+	pools map[poolKey]*Pool
+}
+
+func (s *Pools) pool(key []byte, size int, create bool) (*Pool, error) {
+	if len(key) > KeyLength {
+		return nil, errors.New("blake2s: key too large")
+	}
+	k := poolKey{len: len(key), size: size}
+	copy(k.key[:], key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.pools[k]; ok || !create {
+		return p, nil
+	}
+	p, err := NewPool(key, size)
+	if err != nil {
+		return nil, err
+	}
+	if s.pools == nil {
+		s.pools = make(map[poolKey]*Pool)
+	}
+	s.pools[k] = p
+	return p, nil
+}
+
+// Get returns a Digest in its initial state for the given key and size,
+// creating the shard on first use.
+func (s *Pools) Get(key []byte, size int) (*Digest, error) {
+	p, err := s.pool(key, size, true)
+	if err != nil {
+		return nil, err
+	}
+	return p.Get(), nil
+}
+
+// Put returns d to the shard matching its parameters. Digests that no shard
+// could have produced are dropped. d must not be used after Put.
+func (s *Pools) Put(d *Digest) {
+	p, _ := s.pool(d.keyOrNil(), d.size, false)
+	if p != nil {
+		p.Put(d)
+	}
+}
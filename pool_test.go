@@ -0,0 +1,63 @@
+package blake2s
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	key := []byte("pool key")
+	p, err := NewPool(key, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh, _ := NewDigest(key, nil, nil, 20)
+	fresh.Write([]byte("message"))
+	expected := fresh.Sum(nil)
+
+	for i := 0; i < 3; i++ {
+		d := p.Get()
+		d.Write([]byte("message"))
+		if !bytes.Equal(d.Sum(nil), expected) {
+			t.Fatalf("round %d: pooled digest differs from a fresh one", i)
+		}
+		// Leave state behind for the next Get to clear.
+		d.Write([]byte("garbage"))
+		p.Put(d)
+	}
+
+	// A foreign digest is dropped, not handed out later.
+	other, _ := NewDigest(nil, nil, nil, 20)
+	p.Put(other)
+	if d := p.Get(); d == other {
+		t.Error("pool recycled a digest with different parameters")
+	}
+}
+
+func TestPools(t *testing.T) {
+	var s Pools
+	for _, tc := range []struct {
+		key  []byte
+		size int
+	}{
+		{nil, 32},
+		{nil, 16},
+		{[]byte("a"), 32},
+		{[]byte("b"), 32},
+	} {
+		d, err := s.Get(tc.key, tc.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fresh, _ := NewDigest(tc.key, nil, nil, tc.size)
+		d.Write([]byte("message"))
+		fresh.Write([]byte("message"))
+		if !bytes.Equal(d.Sum(nil), fresh.Sum(nil)) {
+			t.Errorf("key %q size %d: pooled digest differs from a fresh one", tc.key, tc.size)
+		}
+		s.Put(d)
+	}
+	if _, err := s.Get(make([]byte, KeyLength+1), 32); err == nil {
+		t.Error("accepted an oversized key")
+	}
+}
@@ -0,0 +1,127 @@
+package blake2s
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// A Range is a span of bytes within a larger input.
+type Range struct {
+	Off, Len int64
+}
+
+// HashRanges returns the unkeyed, MaxOutput-byte digest of each range of ra,
+// in the order given. Ranges are hashed concurrently by up to workers
+// goroutines, or GOMAXPROCS if workers is zero or negative, and may overlap.
+// A range extending past the end of ra is an error (io.ErrUnexpectedEOF), as
+// is any error from ra; the first in range order is returned.
+func HashRanges(ra io.ReaderAt, ranges []Range, workers int) ([][]byte, error) {
+	for _, r := range ranges {
+		if r.Off < 0 || r.Len < 0 {
+			return nil, errors.New("blake2s: negative range offset or length")
+		}
+	}
+	sums := make([][]byte, len(ranges))
+	errs := make([]error, len(ranges))
+	parallelRanges(len(ranges), workers, func(i int, buf []byte) {
+		sums[i], errs[i] = hashRange(ra, ranges[i], buf)
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sums, nil
+}
+
+// A Chunk is the expected digest of a range of an input, such as one entry
+// of a chunk map. The digest is the unkeyed, MaxOutput-byte BLAKE2s hash of
+// the range.
+type Chunk struct {
+	Range
+	Digest []byte
+}
+
+// VerifyChunks hashes each chunk's range of ra, using up to workers
+// goroutines as HashRanges does, and returns the indices of the chunks that
+// don't match, in increasing order, so that only those need repairing.
+// Chunks extending past the end of ra are reported as bad. Any other read
+// error is returned.
+func VerifyChunks(ra io.ReaderAt, chunks []Chunk, workers int) ([]int, error) {
+	for _, c := range chunks {
+		if c.Off < 0 || c.Len < 0 {
+			return nil, errors.New("blake2s: negative range offset or length")
+		}
+	}
+	bad := make([]bool, len(chunks))
+	errs := make([]error, len(chunks))
+	parallelRanges(len(chunks), workers, func(i int, buf []byte) {
+		sum, err := hashRange(ra, chunks[i].Range, buf)
+		switch {
+		case err == io.ErrUnexpectedEOF:
+			bad[i] = true
+		case err != nil:
+			errs[i] = err
+		default:
+			bad[i] = subtle.ConstantTimeCompare(sum, chunks[i].Digest) != 1
+		}
+	})
+
+	var indices []int
+	for i := range chunks {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if bad[i] {
+			indices = append(indices, i)
+		}
+	}
+	return indices, nil
+}
+
+// parallelRanges calls fn for every index below n from up to workers
+// goroutines, or GOMAXPROCS if workers is not positive, each with its own
+// read buffer.
+func parallelRanges(n, workers int, fn func(i int, buf []byte)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, readerChunkSize)
+			for i := range next {
+				fn(i, buf)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+func hashRange(ra io.ReaderAt, r Range, buf []byte) ([]byte, error) {
+	d, err := NewDigest(nil, nil, nil, MaxOutput)
+	if err != nil {
+		return nil, err
+	}
+	n, err := io.CopyBuffer(d, io.NewSectionReader(ra, r.Off, r.Len), buf)
+	if err != nil {
+		return nil, err
+	}
+	if n != r.Len {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return d.Sum(nil), nil
+}
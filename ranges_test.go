@@ -0,0 +1,85 @@
+package blake2s
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type failingReaderAt struct{}
+
+func (failingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	return 0, errors.New("disk on fire")
+}
+
+func TestHashRanges(t *testing.T) {
+	data := make([]byte, 300000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	ranges := []Range{{0, 0}, {0, 100}, {50, 100}, {100000, 100000}, {299999, 1}, {0, int64(len(data))}}
+	for _, workers := range []int{0, 1, 3, 100} {
+		sums, err := HashRanges(bytes.NewReader(data), ranges, workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, r := range ranges {
+			d, _ := NewDigest(nil, nil, nil, MaxOutput)
+			d.Write(data[r.Off : r.Off+r.Len])
+			if !bytes.Equal(sums[i], d.Sum(nil)) {
+				t.Errorf("workers=%d: range %v mismatch", workers, r)
+			}
+		}
+	}
+
+	if _, err := HashRanges(bytes.NewReader(data), []Range{{299999, 2}}, 1); err != io.ErrUnexpectedEOF {
+		t.Errorf("range past the end returned %v", err)
+	}
+	if _, err := HashRanges(bytes.NewReader(data), []Range{{-1, 2}}, 1); err == nil {
+		t.Error("accepted a negative offset")
+	}
+	if _, err := HashRanges(failingReaderAt{}, []Range{{0, 2}}, 1); err == nil {
+		t.Error("read error not returned")
+	}
+	if sums, err := HashRanges(failingReaderAt{}, nil, 4); err != nil || len(sums) != 0 {
+		t.Errorf("no ranges returned %v, %v", sums, err)
+	}
+}
+
+func TestVerifyChunks(t *testing.T) {
+	data := make([]byte, 100000)
+	for i := range data {
+		data[i] = byte(i * 3)
+	}
+	var chunks []Chunk
+	for off := int64(0); off < int64(len(data)); off += 10000 {
+		d, _ := NewDigest(nil, nil, nil, MaxOutput)
+		d.Write(data[off : off+10000])
+		chunks = append(chunks, Chunk{Range{off, 10000}, d.Sum(nil)})
+	}
+
+	if bad, err := VerifyChunks(bytes.NewReader(data), chunks, 4); err != nil || bad != nil {
+		t.Fatalf("intact input: %v, %v", bad, err)
+	}
+
+	damaged := append([]byte(nil), data...)
+	damaged[15000] ^= 1
+	damaged[70000] ^= 1
+	damaged[79999] ^= 1
+	bad, err := VerifyChunks(bytes.NewReader(damaged[:95000]), chunks, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(bad, []int{1, 7, 9}) {
+		t.Errorf("bad chunks %v, want [1 7 9]", bad)
+	}
+
+	if _, err := VerifyChunks(failingReaderAt{}, chunks, 2); err == nil {
+		t.Error("read error not returned")
+	}
+	if _, err := VerifyChunks(bytes.NewReader(data), []Chunk{{Range: Range{-1, 1}}}, 1); err == nil {
+		t.Error("accepted a negative offset")
+	}
+}
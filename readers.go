@@ -0,0 +1,179 @@
+package blake2s
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const readerChunkSize = 32 * 1024
+
+// ReadersEqualByHash reports whether a and b produce the same bytes. Both are
+// streamed through BLAKE2s instances keyed with a fresh random key, so
+// neither needs to be held in memory and an adversary who controls one input
+// cannot precompute a colliding one. Reading stops early, returning false, as
+// soon as one reader ends before the other.
+func ReadersEqualByHash(a, b io.Reader) (bool, error) {
+	var key [KeyLength]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return false, err
+	}
+	da, err := NewDigest(key[:], nil, nil, MaxOutput)
+	if err != nil {
+		return false, err
+	}
+	db, err := NewDigest(key[:], nil, nil, MaxOutput)
+	if err != nil {
+		return false, err
+	}
+
+	bufA := make([]byte, readerChunkSize)
+	bufB := make([]byte, readerChunkSize)
+	for {
+		na, errA := io.ReadFull(a, bufA)
+		if errA != nil && errA != io.EOF && errA != io.ErrUnexpectedEOF {
+			return false, errA
+		}
+		nb, errB := io.ReadFull(b, bufB)
+		if errB != nil && errB != io.EOF && errB != io.ErrUnexpectedEOF {
+			return false, errB
+		}
+		if na != nb {
+			return false, nil
+		}
+		da.Write(bufA[:na])
+		db.Write(bufB[:nb])
+		if errA != nil || errB != nil {
+			break
+		}
+	}
+
+	return subtle.ConstantTimeCompare(da.Sum(nil), db.Sum(nil)) == 1, nil
+}
+
+// ConstantTimeCompareReader reports whether a and b produce the same bytes,
+// for verification paths where an attacker can observe how long the
+// comparison takes. Unlike ReadersEqualByHash, it never stops early: both
+// readers are always consumed to the end, and the result doesn't depend on
+// where the first difference is. Both streams are hashed with a fresh random
+// key and the digests compared in constant time. If contents is true, the
+// bytes themselves are also compared, again without branching on them.
+//
+// The running time still depends on the lengths of the inputs, which are
+// not considered secret.
+func ConstantTimeCompareReader(a, b io.Reader, contents bool) (bool, error) {
+	var key [KeyLength]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return false, err
+	}
+	da, err := NewDigest(key[:], nil, nil, MaxOutput)
+	if err != nil {
+		return false, err
+	}
+	db, err := NewDigest(key[:], nil, nil, MaxOutput)
+	if err != nil {
+		return false, err
+	}
+
+	bufA := make([]byte, readerChunkSize)
+	bufB := make([]byte, readerChunkSize)
+	var diff byte
+	var doneA, doneB bool
+	var lenA, lenB uint64
+	for !doneA || !doneB {
+		na, nb := 0, 0
+		if !doneA {
+			na, err = io.ReadFull(a, bufA)
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				doneA = true
+			} else if err != nil {
+				return false, err
+			}
+		}
+		if !doneB {
+			nb, err = io.ReadFull(b, bufB)
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				doneB = true
+			} else if err != nil {
+				return false, err
+			}
+		}
+		da.Write(bufA[:na])
+		db.Write(bufB[:nb])
+		lenA += uint64(na)
+		lenB += uint64(nb)
+
+		if contents {
+			// Once the lengths diverge the chunks no longer line up, but
+			// the length check below already decides the result.
+			n := na
+			if nb < n {
+				n = nb
+			}
+			for i := 0; i < n; i++ {
+				diff |= bufA[i] ^ bufB[i]
+			}
+		}
+	}
+
+	equal := subtle.ConstantTimeCompare(da.Sum(nil), db.Sum(nil))
+	equal &= subtle.ConstantTimeByteEq(diff, 0)
+	equal &= subtle.ConstantTimeEq(int32(lenA>>32), int32(lenB>>32))
+	equal &= subtle.ConstantTimeEq(int32(lenA), int32(lenB))
+	return equal == 1, nil
+}
+
+// ErrMismatch is matched by the *MismatchError VerifyReader returns when the
+// stream's digest is not the expected one.
+var ErrMismatch = errors.New("blake2s: digest mismatch")
+
+// A MismatchError describes a failed VerifyReader. It matches ErrMismatch
+// under errors.Is.
+//
+// For a keyed digest, Actual is a valid MAC of the stream, so it must not be
+// shown to whoever supplied the stream and expected digest; the Error message
+// leaves both digests out in that case.
+type MismatchError struct {
+	Expected []byte
+	Actual   []byte
+	Keyed    bool
+}
+
+func (e *MismatchError) Error() string {
+	if e.Keyed {
+		return ErrMismatch.Error()
+	}
+	return fmt.Sprintf("%v: got %x, want %x", ErrMismatch, e.Actual, e.Expected)
+}
+
+func (e *MismatchError) Unwrap() error {
+	return ErrMismatch
+}
+
+// VerifyReader reads r to the end and checks that its BLAKE2s digest, keyed
+// with key if it isn't empty, equals expected. The digest length is taken from
+// len(expected), and the comparison is constant time. It returns a
+// *MismatchError if the digests differ, or an error reading r or from an
+// invalid length or key.
+func VerifyReader(r io.Reader, expected, key []byte) error {
+	d, err := NewDigest(key, nil, nil, len(expected))
+	if err != nil {
+		return err
+	}
+	defer d.Wipe()
+	if _, err := io.Copy(d, r); err != nil {
+		return err
+	}
+
+	sum := d.Sum(nil)
+	if subtle.ConstantTimeCompare(sum, expected) != 1 {
+		return &MismatchError{
+			Expected: append([]byte(nil), expected...),
+			Actual:   sum,
+			Keyed:    len(key) > 0,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package blake2s
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReadersEqualByHash(t *testing.T) {
+	data := make([]byte, 3*readerChunkSize+17)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	changed := append([]byte(nil), data...)
+	changed[readerChunkSize+5] ^= 1
+
+	tests := []struct {
+		a, b  []byte
+		equal bool
+	}{
+		{nil, nil, true},
+		{data, data, true},
+		{data, changed, false},
+		{data, data[:len(data)-1], false},
+		{data[:readerChunkSize], data, false},
+	}
+	for i, test := range tests {
+		// OneByteReader checks that short reads don't look like a length mismatch.
+		equal, err := ReadersEqualByHash(iotest.OneByteReader(bytes.NewReader(test.a)), bytes.NewReader(test.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if equal != test.equal {
+			t.Errorf("test %d: expected %v", i, test.equal)
+		}
+	}
+}
+
+func TestReadersEqualByHashError(t *testing.T) {
+	boom := errors.New("boom")
+	r := io.MultiReader(bytes.NewReader([]byte("abc")), iotest.ErrReader(boom))
+	if _, err := ReadersEqualByHash(r, bytes.NewReader([]byte("abc"))); err != boom {
+		t.Errorf("expected read error, got %v", err)
+	}
+}
+
+func TestConstantTimeCompareReader(t *testing.T) {
+	data := make([]byte, 2*readerChunkSize+3)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	changed := append([]byte(nil), data...)
+	changed[len(changed)-1] ^= 1
+
+	tests := []struct {
+		a, b  []byte
+		equal bool
+	}{
+		{nil, nil, true},
+		{data, data, true},
+		{data, changed, false},
+		{data, data[:len(data)-1], false},
+		{data[:5], data, false},
+	}
+	for i, test := range tests {
+		for _, contents := range []bool{false, true} {
+			equal, err := ConstantTimeCompareReader(iotest.HalfReader(bytes.NewReader(test.a)), bytes.NewReader(test.b), contents)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if equal != test.equal {
+				t.Errorf("test %d (contents %v): expected %v", i, contents, test.equal)
+			}
+		}
+	}
+}
+
+func TestConstantTimeCompareReaderConsumesBoth(t *testing.T) {
+	a := bytes.NewReader([]byte("short"))
+	b := bytes.NewReader(make([]byte, 3*readerChunkSize))
+	if equal, _ := ConstantTimeCompareReader(a, b, true); equal {
+		t.Error("different lengths compared equal")
+	}
+	if a.Len() != 0 || b.Len() != 0 {
+		t.Error("comparison stopped before the end of the inputs")
+	}
+}
+
+func TestVerifyReader(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	key := []byte("verify key")
+	d, _ := NewDigest(key, nil, nil, 16)
+	d.Write(data)
+	expected := d.Sum(nil)
+
+	if err := VerifyReader(iotest.HalfReader(bytes.NewReader(data)), expected, key); err != nil {
+		t.Errorf("valid stream: %v", err)
+	}
+	if err := VerifyReader(bytes.NewReader(data[1:]), expected, key); !errors.Is(err, ErrMismatch) {
+		t.Errorf("truncated stream: got %v, want ErrMismatch", err)
+	}
+	if err := VerifyReader(bytes.NewReader(data), expected, nil); !errors.Is(err, ErrMismatch) {
+		t.Errorf("missing key: got %v, want ErrMismatch", err)
+	}
+
+	unkeyed, _ := NewDigest(nil, nil, nil, 32)
+	unkeyed.Write(data)
+	if err := VerifyReader(bytes.NewReader(data), unkeyed.Sum(nil), nil); err != nil {
+		t.Errorf("unkeyed stream: %v", err)
+	}
+
+	var mismatch *MismatchError
+	err := VerifyReader(bytes.NewReader(data[1:]), unkeyed.Sum(nil), nil)
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("got %v, want a *MismatchError", err)
+	}
+	truncated, _ := NewDigest(nil, nil, nil, 32)
+	truncated.Write(data[1:])
+	if !bytes.Equal(mismatch.Expected, unkeyed.Sum(nil)) || !bytes.Equal(mismatch.Actual, truncated.Sum(nil)) {
+		t.Errorf("MismatchError carries %x, %x", mismatch.Expected, mismatch.Actual)
+	}
+	if !strings.Contains(err.Error(), hex.EncodeToString(mismatch.Actual)) {
+		t.Errorf("unkeyed mismatch message lacks the digest: %v", err)
+	}
+
+	err = VerifyReader(bytes.NewReader(data[1:]), expected, key)
+	errors.As(err, &mismatch)
+	if !mismatch.Keyed || strings.Contains(err.Error(), hex.EncodeToString(mismatch.Actual)) {
+		t.Errorf("keyed mismatch message reveals the tag: %v", err)
+	}
+
+	errRead := errors.New("read failed")
+	if err := VerifyReader(iotest.ErrReader(errRead), expected, key); err != errRead {
+		t.Errorf("read error: got %v", err)
+	}
+	if err := VerifyReader(bytes.NewReader(data), nil, key); err == nil || err == ErrMismatch {
+		t.Errorf("empty expected digest: got %v", err)
+	}
+}
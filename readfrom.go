@@ -0,0 +1,44 @@
+package blake2s
+
+import (
+	"io"
+	"sync"
+)
+
+// readFromBufs holds the buffers ReadFrom reads into, so that hashing a
+// stream doesn't allocate once the pool is warm.
+var readFromBufs = sync.Pool{
+	New: func() any { return new([readerChunkSize]byte) },
+}
+
+// ReadFrom hashes the contents of r until EOF. It implements io.ReaderFrom,
+// so io.Copy(d, r) uses it: r is read in large chunks and whole blocks are
+// compressed straight from them. It returns the number of bytes hashed and
+// the first error from r other than io.EOF, or any error Write returns, in
+// which case the rejected chunk is not hashed.
+func (d *Digest) ReadFrom(r io.Reader) (n int64, err error) {
+	buf := readFromBufs.Get().(*[readerChunkSize]byte)
+	used := 0
+	defer func() {
+		// The buffer may hold secrets, such as a key being hashed.
+		clear(buf[:used])
+		readFromBufs.Put(buf)
+	}()
+
+	for {
+		m, rerr := r.Read(buf[:])
+		used = max(used, m)
+		if m > 0 {
+			if _, err := d.Write(buf[:m]); err != nil {
+				return n, err
+			}
+			n += int64(m)
+		}
+		if rerr == io.EOF {
+			return n, nil
+		}
+		if rerr != nil {
+			return n, rerr
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package blake2s
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/gtank/blake2s/testutil"
+)
+
+func TestReadFrom(t *testing.T) {
+	patterns := []testutil.ReadPattern{
+		{},
+		{ChunkMin: 1, ChunkMax: 1},
+		{ChunkMin: 1, ChunkMax: 200, Seed: 1},
+		{ChunkMin: BlockSize, ChunkMax: BlockSize},
+	}
+	for _, n := range []int{0, 1, 63, 64, 65, 128, 129, 1000, 3*readerChunkSize + 17} {
+		data := testutil.Input(n)
+		// One byte at a time never compresses straight from the input.
+		reference, _ := NewDigest([]byte("key"), nil, nil, 32)
+		for i := range data {
+			reference.Write(data[i : i+1])
+		}
+		want := reference.Sum(nil)
+
+		for _, p := range patterns {
+			d, _ := NewDigest([]byte("key"), nil, nil, 32)
+			got, err := d.ReadFrom(testutil.SimulatedReader(bytes.NewReader(data), p))
+			if err != nil || got != int64(n) {
+				t.Errorf("%d bytes, pattern %+v: ReadFrom returned %d, %v", n, p, got, err)
+			}
+			if !bytes.Equal(d.Sum(nil), want) {
+				t.Errorf("%d bytes, pattern %+v: wrong digest", n, p)
+			}
+		}
+	}
+
+	// io.Copy goes through ReadFrom.
+	d, _ := NewDigest(nil, nil, nil, 32)
+	if _, err := io.Copy(d, iotest.HalfReader(bytes.NewReader(testutil.Input(500)))); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := NewDigest(nil, nil, nil, 32)
+	want.Write(testutil.Input(500))
+	if !bytes.Equal(d.Sum(nil), want.Sum(nil)) {
+		t.Error("io.Copy produced a different digest")
+	}
+}
+
+func TestReadFromErrors(t *testing.T) {
+	errRead := errors.New("read failed")
+	d, _ := NewDigest(nil, nil, nil, 32)
+	r := io.MultiReader(bytes.NewReader(make([]byte, 100)), iotest.ErrReader(errRead))
+	if n, err := d.ReadFrom(r); n != 100 || err != errRead {
+		t.Errorf("got %d, %v; want 100, %v", n, err, errRead)
+	}
+
+	d, _ = New(WithMaxInput(100))
+	if n, err := d.ReadFrom(bytes.NewReader(make([]byte, 101))); n != 0 || err != ErrMaxInput {
+		t.Errorf("got %d, %v; want 0, ErrMaxInput", n, err)
+	}
+}
+
+func TestReadFromAllocations(t *testing.T) {
+	data := testutil.Input(10000)
+	r := bytes.NewReader(data)
+	d, _ := NewDigest(nil, nil, nil, 32)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(data)
+		d.Reset()
+		d.ReadFrom(r)
+	})
+	if allocs != 0 {
+		t.Errorf("ReadFrom allocated %v times", allocs)
+	}
+}
@@ -0,0 +1,55 @@
+package blake2s
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/gtank/blake2s/testutil"
+)
+
+func TestReducedRoundDigest(t *testing.T) {
+	// Computed with an independent round-parameterized implementation.
+	for _, tc := range []struct {
+		rounds int
+		key    []byte
+		input  []byte
+		size   int
+		want   string
+	}{
+		{4, nil, []byte("abc"), 32, "00c6c8d86e0b35510d4d14d9026fb2bd13e8b7b5dc17ad2954049cef754de64b"},
+		{1, []byte("key"), testutil.Input(200), 16, "01da5d07e5c7445f0105a72e1701b8f0"},
+	} {
+		d, err := NewReducedRoundDigest(tc.rounds, tc.key, nil, nil, tc.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Write(tc.input)
+		if got := hex.EncodeToString(d.Sum(nil)); got != tc.want {
+			t.Errorf("%d rounds: got %s, want %s", tc.rounds, got, tc.want)
+		}
+		d.Reset()
+		d.Write(tc.input)
+		if got := hex.EncodeToString(d.Sum(nil)); got != tc.want {
+			t.Errorf("%d rounds after Reset: got %s, want %s", tc.rounds, got, tc.want)
+		}
+	}
+
+	// Ten rounds is BLAKE2s.
+	d, _ := NewReducedRoundDigest(10, []byte("key"), []byte("salt"), nil, 32)
+	full, _ := NewDigest([]byte("key"), []byte("salt"), nil, 32)
+	d.Write(testutil.Input(1000))
+	full.Write(testutil.Input(1000))
+	if !bytes.Equal(d.Sum(nil), full.Sum(nil)) {
+		t.Error("10 rounds differs from NewDigest")
+	}
+
+	if _, err := d.MarshalBinary(); err == nil {
+		t.Error("marshaled a reduced-round digest")
+	}
+	for _, rounds := range []int{0, 11, -1} {
+		if _, err := NewReducedRoundDigest(rounds, nil, nil, nil, 32); err == nil {
+			t.Errorf("accepted %d rounds", rounds)
+		}
+	}
+}
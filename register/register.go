@@ -0,0 +1,31 @@
+// Package register makes this module's BLAKE2s available as
+// crypto.BLAKE2s_256, for libraries that take a crypto.Hash. Import it for
+// its side effect:
+//
+//	import _ "github.com/gtank/blake2s/register"
+//
+// golang.org/x/crypto/blake2s registers the same crypto.Hash. If both are
+// linked into a program, whichever package initializes last wins; the two
+// compute the same function.
+package register
+
+import (
+	"crypto"
+	"hash"
+
+	"github.com/gtank/blake2s"
+)
+
+func init() {
+	crypto.RegisterHash(crypto.BLAKE2s_256, New)
+}
+
+// New returns an unkeyed BLAKE2s-256 hash. It is the constructor registered
+// for crypto.BLAKE2s_256.
+func New() hash.Hash {
+	d, err := blake2s.New()
+	if err != nil {
+		panic("register: " + err.Error())
+	}
+	return d
+}
@@ -0,0 +1,26 @@
+package register
+
+import (
+	"bytes"
+	"crypto"
+	"testing"
+
+	"github.com/gtank/blake2s"
+)
+
+func TestRegistered(t *testing.T) {
+	if !crypto.BLAKE2s_256.Available() {
+		t.Fatal("crypto.BLAKE2s_256 is not available")
+	}
+	h := crypto.BLAKE2s_256.New()
+	if _, ok := h.(*blake2s.Digest); !ok {
+		t.Fatalf("crypto.BLAKE2s_256.New returned a %T", h)
+	}
+	msg := []byte("message")
+	h.Write(msg)
+	d, _ := blake2s.New()
+	d.Write(msg)
+	if !bytes.Equal(h.Sum(nil), d.Sum(nil)) || h.Size() != crypto.BLAKE2s_256.Size() {
+		t.Error("registered hash differs from blake2s.New")
+	}
+}
@@ -0,0 +1,35 @@
+package blake2s
+
+import (
+	"crypto/rand"
+)
+
+// Salt is a full-length BLAKE2s salt.
+type Salt [SaltLength]byte
+
+// NewRandomSalt returns a salt filled from crypto/rand.
+func NewRandomSalt() (Salt, error) {
+	var s Salt
+	_, err := rand.Read(s[:])
+	return s, err
+}
+
+// WithRandomSalt salts the Digest with a fresh random salt. The salt is
+// needed to recompute the hash later, so retrieve it with Digest.Salt and
+// store it alongside the output.
+func WithRandomSalt() Option {
+	return func(c *config) error {
+		s, err := NewRandomSalt()
+		if err != nil {
+			return err
+		}
+		c.salt = s[:]
+		return nil
+	}
+}
+
+// Salt returns the salt this instance was constructed with, zero-padded to
+// full length.
+func (d *Digest) Salt() Salt {
+	return Salt(d.salt)
+}
@@ -0,0 +1,36 @@
+package blake2s
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRandomSalt(t *testing.T) {
+	a, err := New(WithRandomSalt())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(WithRandomSalt())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Salt() == b.Salt() {
+		t.Error("two random salts are equal")
+	}
+
+	// The recorded salt is enough to recompute the hash.
+	salt := a.Salt()
+	again, _ := NewDigest(nil, salt[:], nil, MaxOutput)
+	a.Write([]byte("message"))
+	again.Write([]byte("message"))
+	if !bytes.Equal(a.Sum(nil), again.Sum(nil)) {
+		t.Error("recorded salt does not reproduce the hash")
+	}
+}
+
+func TestSaltPadding(t *testing.T) {
+	d, _ := NewDigest(nil, []byte("abc"), nil, MaxOutput)
+	if d.Salt() != (Salt{'a', 'b', 'c'}) {
+		t.Errorf("unexpected salt %x", d.Salt())
+	}
+}
@@ -0,0 +1,221 @@
+// Package sealer protects append-only logs against tampering.
+//
+// Each line is written with a tag: the keyed BLAKE2s hash of the previous
+// tag, the line's sequence number and its contents. Because the tags chain,
+// a verifier holding the key detects any modified, inserted, deleted or
+// reordered line. Removing lines from the end of the log can only be
+// detected against something stored elsewhere, so the Sealer can write a
+// closing record when a log is finished and exposes Checkpoints that can be
+// kept out of band while it is still being written.
+//
+// A sealed log is plain text, one record per line:
+//
+//	<hex tag>  <line>
+//	<hex tag> #end
+package sealer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gtank/blake2s"
+)
+
+var persona = []byte("logseal1")
+
+const (
+	recordLine byte = iota
+	recordEnd
+)
+
+// maxRecord is the longest record Verify reads, including its newline.
+const maxRecord = 1 << 20
+
+// MaxLineLength is the longest line WriteLine accepts: a record holds the hex
+// tag, two spaces and the line, and must fit in Verify's buffer.
+const MaxLineLength = maxRecord - 2*blake2s.MaxOutput - 3
+
+var (
+	// ErrTagMismatch means a line was modified, inserted, removed or
+	// reordered, or the wrong key was used.
+	ErrTagMismatch = errors.New("sealer: tag mismatch")
+
+	// ErrTruncated means the log ends before a known checkpoint.
+	ErrTruncated = errors.New("sealer: log truncated")
+
+	// ErrClosed is returned when writing to a closed Sealer, and when a
+	// sealed log has records after its closing record.
+	ErrClosed = errors.New("sealer: log closed")
+)
+
+// A Checkpoint identifies a position in a sealed log. Any log that verifies
+// up to Lines with the same Tag has the same contents up to that point.
+type Checkpoint struct {
+	Lines  uint64
+	Tag    [blake2s.MaxOutput]byte
+	Closed bool
+}
+
+type chain struct {
+	key []byte
+	cp  Checkpoint
+}
+
+func (c *chain) next(kind byte, line []byte) ([blake2s.MaxOutput]byte, error) {
+	var tag [blake2s.MaxOutput]byte
+	d, err := blake2s.NewDigest(c.key, nil, persona, blake2s.MaxOutput)
+	if err != nil {
+		return tag, err
+	}
+	var header [9]byte
+	header[0] = kind
+	binary.LittleEndian.PutUint64(header[1:], c.cp.Lines)
+	d.Write(c.cp.Tag[:])
+	d.Write(header[:])
+	d.Write(line)
+	d.Sum(tag[:0])
+	return tag, nil
+}
+
+// A Sealer appends tagged lines to a log.
+type Sealer struct {
+	w io.Writer
+	c chain
+}
+
+// NewSealer returns a Sealer starting a new log on w.
+func NewSealer(w io.Writer, key []byte) (*Sealer, error) {
+	if len(key) == 0 {
+		return nil, errors.New("sealer: a key is required")
+	}
+	if len(key) > blake2s.KeyLength {
+		return nil, errors.New("sealer: key too large")
+	}
+	return &Sealer{w: w, c: chain{key: append([]byte(nil), key...)}}, nil
+}
+
+// WriteLine seals and writes one line, which must not contain a newline or
+// be longer than MaxLineLength.
+func (s *Sealer) WriteLine(line []byte) error {
+	if s.c.cp.Closed {
+		return ErrClosed
+	}
+	if len(line) > MaxLineLength {
+		return errors.New("sealer: line too long")
+	}
+	if bytes.ContainsAny(line, "\r\n") {
+		return errors.New("sealer: line contains a line break")
+	}
+	tag, err := s.c.next(recordLine, line)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "%x  %s\n", tag, line); err != nil {
+		return err
+	}
+	s.c.cp.Tag = tag
+	s.c.cp.Lines++
+	return nil
+}
+
+// Checkpoint returns the current position, which can be stored out of band
+// and later passed to Verify to detect truncation.
+func (s *Sealer) Checkpoint() Checkpoint {
+	return s.c.cp
+}
+
+// Close writes the closing record. It does not close the underlying writer.
+func (s *Sealer) Close() error {
+	if s.c.cp.Closed {
+		return ErrClosed
+	}
+	tag, err := s.c.next(recordEnd, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "%x #end\n", tag); err != nil {
+		return err
+	}
+	s.c.cp.Tag = tag
+	s.c.cp.Closed = true
+	return nil
+}
+
+// Verify checks every record of a sealed log and returns the checkpoint at
+// its end. If known is not nil, the log must also pass through it, which
+// detects truncation of a log that was never closed. A log that verifies
+// but isn't Closed may have lost lines from its end.
+func Verify(r io.Reader, key []byte, known *Checkpoint) (*Checkpoint, error) {
+	c := chain{key: key}
+	reachedKnown := known == nil
+	// check matches the known checkpoint against the current position,
+	// which may be the start of the log for a checkpoint taken before the
+	// first line.
+	check := func() error {
+		if !reachedKnown && c.cp.Lines == known.Lines && c.cp.Closed == known.Closed {
+			if c.cp.Tag != known.Tag {
+				return fmt.Errorf("sealer: checkpoint at line %d: %w", known.Lines, ErrTagMismatch)
+			}
+			reachedKnown = true
+		}
+		return nil
+	}
+	if err := check(); err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxRecord)
+	for scanner.Scan() {
+		record := scanner.Bytes()
+		if c.cp.Closed {
+			return nil, ErrClosed
+		}
+
+		const hexLen = 2 * blake2s.MaxOutput
+		if len(record) < hexLen+2 || record[hexLen] != ' ' {
+			return nil, fmt.Errorf("sealer: line %d: malformed record", c.cp.Lines+1)
+		}
+		var tag [blake2s.MaxOutput]byte
+		if _, err := hex.Decode(tag[:], record[:hexLen]); err != nil {
+			return nil, fmt.Errorf("sealer: line %d: malformed tag", c.cp.Lines+1)
+		}
+
+		kind, line := recordLine, record[hexLen+2:]
+		if string(record[hexLen+1:]) == "#end" {
+			kind, line = recordEnd, nil
+		} else if record[hexLen+1] != ' ' {
+			return nil, fmt.Errorf("sealer: line %d: malformed record", c.cp.Lines+1)
+		}
+
+		expected, err := c.next(kind, line)
+		if err != nil {
+			return nil, err
+		}
+		if subtle.ConstantTimeCompare(tag[:], expected[:]) != 1 {
+			return nil, fmt.Errorf("sealer: line %d: %w", c.cp.Lines+1, ErrTagMismatch)
+		}
+		c.cp.Tag = tag
+		if kind == recordEnd {
+			c.cp.Closed = true
+		} else {
+			c.cp.Lines++
+		}
+		if err := check(); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !reachedKnown {
+		return nil, ErrTruncated
+	}
+	return &c.cp, nil
+}
@@ -0,0 +1,114 @@
+package sealer
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func sealLines(t *testing.T, lines []string, close bool) (string, []Checkpoint) {
+	var buf bytes.Buffer
+	s, err := NewSealer(&buf, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cps []Checkpoint
+	for _, line := range lines {
+		if err := s.WriteLine([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		cps = append(cps, s.Checkpoint())
+	}
+	if close {
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.WriteLine([]byte("late")); err != ErrClosed {
+			t.Errorf("write after close returned %v", err)
+		}
+	}
+	return buf.String(), cps
+}
+
+var testLines = []string{"first", "second", "", "fourth has  spaces"}
+
+func TestVerify(t *testing.T) {
+	log, cps := sealLines(t, testLines, true)
+	cp, err := Verify(strings.NewReader(log), testKey, &cps[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cp.Closed || cp.Lines != uint64(len(testLines)) {
+		t.Errorf("unexpected final checkpoint %+v", cp)
+	}
+
+	if _, err := Verify(strings.NewReader(log), []byte("wrong key"), nil); !errors.Is(err, ErrTagMismatch) {
+		t.Errorf("wrong key returned %v", err)
+	}
+}
+
+func TestDetectTampering(t *testing.T) {
+	log, _ := sealLines(t, testLines, true)
+	records := strings.SplitAfter(log, "\n")
+
+	modified := strings.Replace(log, "second", "Second", 1)
+	reordered := records[1] + records[0] + strings.Join(records[2:], "")
+	deleted := records[0] + strings.Join(records[2:], "")
+	appended := log + records[0]
+
+	for name, tampered := range map[string]string{
+		"modified":  modified,
+		"reordered": reordered,
+		"deleted":   deleted,
+		"appended":  appended,
+	} {
+		if _, err := Verify(strings.NewReader(tampered), testKey, nil); err == nil {
+			t.Errorf("%s log verified", name)
+		}
+	}
+}
+
+func TestDetectTruncation(t *testing.T) {
+	log, cps := sealLines(t, testLines, false)
+	records := strings.SplitAfter(log, "\n")
+	truncated := strings.Join(records[:2], "")
+
+	cp, err := Verify(strings.NewReader(truncated), testKey, nil)
+	if err != nil || cp.Closed {
+		t.Errorf("truncated, unclosed log: %+v, %v", cp, err)
+	}
+	if _, err := Verify(strings.NewReader(truncated), testKey, &cps[3]); err != ErrTruncated {
+		t.Errorf("expected ErrTruncated, got %v", err)
+	}
+}
+
+func TestEmptyCheckpoint(t *testing.T) {
+	var buf bytes.Buffer
+	s, _ := NewSealer(&buf, testKey)
+	empty := s.Checkpoint()
+
+	if _, err := Verify(strings.NewReader(""), testKey, &empty); err != nil {
+		t.Errorf("empty log: %v", err)
+	}
+	log, _ := sealLines(t, testLines, true)
+	if _, err := Verify(strings.NewReader(log), testKey, &empty); err != nil {
+		t.Errorf("log after an empty checkpoint: %v", err)
+	}
+}
+
+func TestLongLine(t *testing.T) {
+	var buf bytes.Buffer
+	s, _ := NewSealer(&buf, testKey)
+	if err := s.WriteLine(bytes.Repeat([]byte("x"), MaxLineLength)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteLine(bytes.Repeat([]byte("x"), MaxLineLength+1)); err == nil {
+		t.Error("accepted a line longer than MaxLineLength")
+	}
+	if _, err := Verify(&buf, testKey, nil); err != nil {
+		t.Errorf("longest line: %v", err)
+	}
+}
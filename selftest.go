@@ -0,0 +1,62 @@
+package blake2s
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+)
+
+// ErrSelfTest is returned by SelfTest when a known-answer test fails.
+var ErrSelfTest = errors.New("blake2s: self-test failed")
+
+// selfTestVectors cover the unkeyed, keyed, salted and personalized modes,
+// full and truncated output, and inputs from under one block to many.
+// Messages are the byte sequence i % 251 of the given length, except "abc".
+var selfTestVectors = []struct {
+	length              int
+	key, salt, personal string
+	size                int
+	digest              string
+}{
+	{-1, "", "", "", 32, "508c5e8c327c14e2e1a72ba34eeb452f37458b209ed63a294d999b4c86675982"},
+	{64, "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", "", "", 32, "8975b0577fd35566d750b362b0897a26c399136df07bababbde6203ff2954ed4"},
+	{1000, "", "73616c7473616c74", "73656c6674657374", 16, "7f73ba7dfd00908e4237a8d07af683f7"},
+	{200, "73656c662d74657374206b6579", "0001020304050607", "6b6174", 20, "d6348e2cfc6a07211cde55e51ee56154d0ad1e6f"},
+}
+
+// SelfTest runs a fixed set of known-answer tests through NewDigest, and so
+// through whichever compression backend and instruction set extensions are
+// in use, for deployments that require a self-test at startup. Each message
+// is hashed both in one Write and in small pieces, to exercise the buffered
+// and direct paths. It returns ErrSelfTest if any digest is wrong.
+func SelfTest() error {
+	for _, v := range selfTestVectors {
+		msg := []byte("abc")
+		if v.length >= 0 {
+			msg = make([]byte, v.length)
+			for i := range msg {
+				msg[i] = byte(i % 251)
+			}
+		}
+		key, _ := hex.DecodeString(v.key)
+		salt, _ := hex.DecodeString(v.salt)
+		personal, _ := hex.DecodeString(v.personal)
+		want, _ := hex.DecodeString(v.digest)
+
+		for _, piece := range []int{len(msg), 7} {
+			d, err := NewDigest(key, salt, personal, v.size)
+			if err != nil {
+				return ErrSelfTest
+			}
+			for rest := msg; len(rest) > 0; {
+				n := min(piece, len(rest))
+				d.Write(rest[:n])
+				rest = rest[n:]
+			}
+			if !bytes.Equal(d.Sum(nil), want) {
+				return ErrSelfTest
+			}
+		}
+	}
+	return nil
+}
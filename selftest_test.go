@@ -0,0 +1,29 @@
+package blake2s
+
+import "testing"
+
+// flippingBackend flips a bit of the chaining value after every compression.
+type flippingBackend struct{}
+
+func (flippingBackend) Name() string    { return "flipping" }
+func (flippingBackend) Available() bool { return true }
+
+func (flippingBackend) CompressBlocks(h *[8]uint32, counter uint64, flags [2]uint32, blocks []byte) {
+	genericBackend{}.CompressBlocks(h, counter, flags, blocks)
+	h[0] ^= 1
+}
+
+func TestSelfTest(t *testing.T) {
+	if err := SelfTest(); err != nil {
+		t.Fatal(err)
+	}
+
+	RegisterBackend(flippingBackend{})
+	defer resetBackends()
+	if err := UseBackend("flipping"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SelfTest(); err != ErrSelfTest {
+		t.Errorf("flipping backend: got %v, want ErrSelfTest", err)
+	}
+}
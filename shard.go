@@ -0,0 +1,47 @@
+package blake2s
+
+import "math/bits"
+
+// The digest helpers below treat a digest as a big-endian number: its first
+// byte is the most significant. That matches the order of its hex form, so
+// the shard of a digest and the leading hex digits people see agree.
+
+// PrefixBits returns the first k bits of digest, 0 <= k <= 64, as an
+// unsigned integer. It panics if k is out of range or digest is shorter than
+// k bits.
+func PrefixBits(digest []byte, k int) uint64 {
+	if k < 0 || k > 64 {
+		panic("blake2s: PrefixBits wants between 0 and 64 bits")
+	}
+	if len(digest)*8 < k {
+		panic("blake2s: digest too short for PrefixBits")
+	}
+	if k == 0 {
+		return 0
+	}
+	return prefix64(digest) >> (64 - k)
+}
+
+// ShardOf maps digest to one of n shards, 0 <= shard < n, spreading uniform
+// digests evenly however n divides. Shards are assigned in digest order, so
+// each one holds a contiguous range of digests. It panics if n < 1.
+func ShardOf(digest []byte, n int) int {
+	if n < 1 {
+		panic("blake2s: ShardOf wants at least one shard")
+	}
+	hi, _ := bits.Mul64(prefix64(digest), uint64(n))
+	return int(hi)
+}
+
+// prefix64 returns the first 8 bytes of digest as a big-endian integer,
+// zero-padding short digests on the right.
+func prefix64(digest []byte) uint64 {
+	var x uint64
+	for i := 0; i < 8; i++ {
+		x <<= 8
+		if i < len(digest) {
+			x |= uint64(digest[i])
+		}
+	}
+	return x
+}
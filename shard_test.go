@@ -0,0 +1,74 @@
+package blake2s
+
+import "testing"
+
+func TestPrefixBits(t *testing.T) {
+	digest := []byte{0xab, 0xcd, 0xef, 0x01, 0x23, 0x45, 0x67, 0x89, 0xff}
+	for _, tc := range []struct {
+		k    int
+		want uint64
+	}{
+		{0, 0},
+		{1, 1},
+		{4, 0xa},
+		{8, 0xab},
+		{12, 0xabc},
+		{16, 0xabcd},
+		{64, 0xabcdef0123456789},
+	} {
+		if got := PrefixBits(digest, tc.k); got != tc.want {
+			t.Errorf("PrefixBits(%d) = %#x, want %#x", tc.k, got, tc.want)
+		}
+	}
+	if got := PrefixBits([]byte{0xf0}, 4); got != 0xf {
+		t.Errorf("short digest: %#x", got)
+	}
+
+	for _, k := range []int{-1, 65} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("PrefixBits(%d) did not panic", k)
+				}
+			}()
+			PrefixBits(digest, k)
+		}()
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("PrefixBits past the end of the digest did not panic")
+		}
+	}()
+	PrefixBits([]byte{1}, 9)
+}
+
+func TestShardOf(t *testing.T) {
+	// Shards follow digest order.
+	if ShardOf([]byte{0x00}, 7) != 0 || ShardOf([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 7) != 6 {
+		t.Error("extreme digests are not in the first and last shards")
+	}
+	if ShardOf([]byte{0x80}, 2) != 1 || ShardOf([]byte{0x7f, 0xff}, 2) != 0 {
+		t.Error("two shards do not split at the top bit")
+	}
+
+	// Hash outputs spread evenly over a shard count that isn't a power of two.
+	const n, samples = 10, 10000
+	var counts [n]int
+	for i := 0; i < samples; i++ {
+		d, _ := NewDigest(nil, nil, nil, 32)
+		d.Write([]byte{byte(i), byte(i >> 8)})
+		counts[ShardOf(d.Sum(nil), n)]++
+	}
+	for i, c := range counts {
+		if c < samples/n*8/10 || c > samples/n*12/10 {
+			t.Errorf("shard %d got %d of %d", i, c, samples)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("ShardOf with no shards did not panic")
+		}
+	}()
+	ShardOf([]byte{1}, 0)
+}
@@ -0,0 +1,8 @@
+package sparse
+
+const (
+	seekHole = 3
+	seekData = 4
+
+	holesSupported = true
+)
@@ -0,0 +1,8 @@
+package sparse
+
+const (
+	seekData = 3
+	seekHole = 4
+
+	holesSupported = true
+)
@@ -0,0 +1,10 @@
+//go:build !linux && !freebsd && !darwin
+
+package sparse
+
+const (
+	seekData = 0
+	seekHole = 0
+
+	holesSupported = false
+)
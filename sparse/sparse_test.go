@@ -0,0 +1,59 @@
+package sparse
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gtank/blake2s"
+)
+
+func sum(t *testing.T, copy func(io.Writer, *os.File) (int64, error), f *os.File) ([]byte, int64) {
+	d, _ := blake2s.NewDigest(nil, nil, nil, blake2s.MaxOutput)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	n, err := copy(d, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d.Sum(nil), n
+}
+
+func TestCopyMatchesPlainRead(t *testing.T) {
+	layouts := []struct {
+		name   string
+		size   int64
+		writes map[int64]string
+	}{
+		{"empty", 0, nil},
+		{"all hole", 3 << 20, nil},
+		{"dense", 0, map[int64]string{0: "no holes at all"}},
+		{"leading hole", 2 << 20, map[int64]string{1 << 20: "data after a hole"}},
+		{"trailing hole", 4 << 20, map[int64]string{0: "data before a hole"}},
+		{"several", 8 << 20, map[int64]string{0: "a", 2 << 20: "b", 5<<20 + 17: "c"}},
+	}
+	for _, l := range layouts {
+		f, err := os.Create(filepath.Join(t.TempDir(), "sparse"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Truncate(l.size); err != nil {
+			t.Fatal(err)
+		}
+		for off, data := range l.writes {
+			if _, err := f.WriteAt([]byte(data), off); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		expected, expectedN := sum(t, func(w io.Writer, f *os.File) (int64, error) { return io.Copy(w, f) }, f)
+		got, n := sum(t, Copy, f)
+		if !bytes.Equal(expected, got) || n != expectedN {
+			t.Errorf("%s: sparse copy disagrees with plain read (%d != %d bytes)", l.name, n, expectedN)
+		}
+		f.Close()
+	}
+}
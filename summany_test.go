@@ -0,0 +1,55 @@
+package blake2s
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gtank/blake2s/testutil"
+)
+
+func TestSumMany(t *testing.T) {
+	var inputs [][]byte
+	for _, n := range []int{0, 1, 63, 64, 65, 128, 129, 1000} {
+		inputs = append(inputs, testutil.Input(n))
+	}
+	inputs = append(inputs, nil)
+
+	for _, size := range []int{1, 16, 32} {
+		sums, err := SumMany(inputs, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sums) != len(inputs) {
+			t.Fatalf("got %d digests for %d inputs", len(sums), len(inputs))
+		}
+		for i, in := range inputs {
+			d, _ := NewDigest(nil, nil, nil, size)
+			d.Write(in)
+			if want := d.Sum(nil); !bytes.Equal(sums[i], want) {
+				t.Errorf("size %d, %d-byte input: got %x, want %x", size, len(in), sums[i], want)
+			}
+		}
+		// Appending to one digest must not overwrite the next.
+		if cap(sums[0]) != size {
+			t.Errorf("digest has capacity %d, want %d", cap(sums[0]), size)
+		}
+	}
+
+	if _, err := SumMany(inputs, 0); err == nil {
+		t.Error("accepted a zero size")
+	}
+	if sums, err := SumMany(nil, 32); err != nil || len(sums) != 0 {
+		t.Errorf("empty batch: %v, %v", sums, err)
+	}
+}
+
+func BenchmarkSumMany64(b *testing.B) {
+	inputs := make([][]byte, 1000)
+	for i := range inputs {
+		inputs[i] = testutil.Input(64)
+	}
+	b.SetBytes(int64(len(inputs) * 64))
+	for i := 0; i < b.N; i++ {
+		SumMany(inputs, 32)
+	}
+}
@@ -0,0 +1,95 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"hash"
+)
+
+// Contract checks the edge cases of the hash.Hash contract that wrappers most
+// often get wrong, using a fresh hash from newHash for each one. newHash must
+// return an unkeyed BLAKE2s instance with 32 bytes of output. The guarantees
+// checked are:
+//
+//   - Size is 32 and BlockSize is 64.
+//   - Write always returns len(p) and a nil error, and writing nil or an
+//     empty slice changes nothing.
+//   - Sum on a fresh hash is the digest of the empty input.
+//   - Sum appends to its argument and does not change the state, so calling
+//     it twice, or writing more after it, behaves as if it hadn't been
+//     called.
+//   - Input that ends exactly on a block boundary is hashed correctly
+//     whether or not Sum was called at the boundary.
+func Contract(newHash func() hash.Hash) error {
+	want := func(n int) []byte {
+		for _, v := range BoundaryVectors {
+			if v.Length == n {
+				d, _ := hex.DecodeString(v.Digest)
+				return d
+			}
+		}
+		panic("testutil: no vector for length " + fmt.Sprint(n))
+	}
+
+	h := newHash()
+	if h.Size() != 32 || h.BlockSize() != 64 {
+		return fmt.Errorf("testutil: Size %d and BlockSize %d, want 32 and 64", h.Size(), h.BlockSize())
+	}
+
+	h = newHash()
+	for _, empty := range [][]byte{nil, {}} {
+		if n, err := h.Write(empty); n != 0 || err != nil {
+			return fmt.Errorf("testutil: empty Write returned %d, %v", n, err)
+		}
+	}
+	if got := h.Sum(nil); !bytes.Equal(got, want(0)) {
+		return fmt.Errorf("testutil: empty writes changed the digest: got %x", got)
+	}
+
+	h = newHash()
+	if got := h.Sum(nil); !bytes.Equal(got, want(0)) {
+		return fmt.Errorf("testutil: Sum of a fresh hash: got %x", got)
+	}
+	prefix := []byte("prefix")
+	if got := h.Sum(prefix[:3:3]); string(got[:3]) != "pre" || !bytes.Equal(got[3:], want(0)) {
+		return fmt.Errorf("testutil: Sum did not append: got %x", got)
+	}
+	buf := make([]byte, 3, 64)
+	if got := h.Sum(buf); len(got) != 35 || !bytes.Equal(got[3:], want(0)) {
+		return fmt.Errorf("testutil: Sum into spare capacity: got %x", got)
+	}
+
+	for _, n := range []int{64, 128} {
+		input := Input(n)
+		h = newHash()
+		if w, err := h.Write(input); w != n || err != nil {
+			return fmt.Errorf("testutil: Write of %d bytes returned %d, %v", n, w, err)
+		}
+		first := h.Sum(nil)
+		if !bytes.Equal(first, want(n)) {
+			return fmt.Errorf("testutil: %d-byte input: got %x", n, first)
+		}
+		if again := h.Sum(nil); !bytes.Equal(again, first) {
+			return fmt.Errorf("testutil: second Sum after %d bytes differs", n)
+		}
+		h.Write(nil)
+		if again := h.Sum(nil); !bytes.Equal(again, first) {
+			return fmt.Errorf("testutil: empty Write after Sum changed the %d-byte digest", n)
+		}
+	}
+
+	// Calling Sum at every boundary length along the way must not disturb
+	// the digests that follow.
+	input := Input(BoundaryVectors[len(BoundaryVectors)-1].Length)
+	h = newHash()
+	written := 0
+	for _, v := range BoundaryVectors {
+		h.Write(input[written:v.Length])
+		written = v.Length
+		if got := h.Sum(nil); !bytes.Equal(got, want(written)) {
+			return fmt.Errorf("testutil: interleaved Sum after %d bytes: got %x", written, got)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package testutil
+
+import (
+	"io"
+	"math/rand"
+	"time"
+)
+
+// A ReadPattern describes how a simulated reader delivers its data: each
+// Read returns between ChunkMin and ChunkMax bytes (capped by the caller's
+// buffer) after a delay between LatencyMin and LatencyMax, both drawn
+// uniformly from a generator seeded with Seed. A zero ChunkMax means no limit
+// beyond the buffer.
+type ReadPattern struct {
+	ChunkMin, ChunkMax     int
+	LatencyMin, LatencyMax time.Duration
+	Seed                   int64
+}
+
+// SimulatedReader returns a reader delivering the contents of r in the
+// chunks and at the pace described by p, for testing and benchmarking
+// streaming code against I/O that looks like disks and networks rather than
+// in-memory buffers.
+func SimulatedReader(r io.Reader, p ReadPattern) io.Reader {
+	return &simReader{r: r, p: p, rng: rand.New(rand.NewSource(p.Seed))}
+}
+
+type simReader struct {
+	r   io.Reader
+	p   ReadPattern
+	rng *rand.Rand
+}
+
+func (s *simReader) Read(b []byte) (int, error) {
+	if d := s.p.LatencyMin + time.Duration(s.between(int64(s.p.LatencyMax-s.p.LatencyMin))); d > 0 {
+		time.Sleep(d)
+	}
+	if s.p.ChunkMax > 0 {
+		n := s.p.ChunkMin + int(s.between(int64(s.p.ChunkMax-s.p.ChunkMin)))
+		if n < 1 {
+			n = 1
+		}
+		if n < len(b) {
+			b = b[:n]
+		}
+	}
+	return s.r.Read(b)
+}
+
+// between returns a uniform value in [0, n], or 0 if n <= 0.
+func (s *simReader) between(n int64) int64 {
+	if n <= 0 {
+		return 0
+	}
+	return s.rng.Int63n(n + 1)
+}
+
+// A Trace is a recorded sequence of reads: the number of bytes each one
+// returned and how long it took. Traces are plain data and can be stored as
+// JSON alongside a benchmark.
+type Trace []TraceRead
+
+// TraceRead is one read in a Trace.
+type TraceRead struct {
+	N     int
+	Delay time.Duration
+}
+
+// A Recorder is a reader that passes reads through to an underlying reader
+// and records them.
+type Recorder struct {
+	r     io.Reader
+	Trace Trace
+}
+
+// Record returns a Recorder reading from r.
+func Record(r io.Reader) *Recorder {
+	return &Recorder{r: r}
+}
+
+func (rec *Recorder) Read(b []byte) (int, error) {
+	start := time.Now()
+	n, err := rec.r.Read(b)
+	rec.Trace = append(rec.Trace, TraceRead{N: n, Delay: time.Since(start)})
+	return n, err
+}
+
+// Replay returns a reader delivering the contents of r with the chunk sizes
+// and delays of the trace, repeating the trace from the start if it runs out
+// before r does. Delays are multiplied by scale: use 1 to replay in real time,
+// 0 to replay chunk sizes only.
+func (t Trace) Replay(r io.Reader, scale float64) io.Reader {
+	return &replayReader{r: r, t: t, scale: scale}
+}
+
+type replayReader struct {
+	r     io.Reader
+	t     Trace
+	i     int
+	scale float64
+}
+
+func (rr *replayReader) Read(b []byte) (int, error) {
+	if len(rr.t) == 0 {
+		return rr.r.Read(b)
+	}
+	step := rr.t[rr.i%len(rr.t)]
+	rr.i++
+	if d := time.Duration(float64(step.Delay) * rr.scale); d > 0 {
+		time.Sleep(d)
+	}
+	// Reads that returned nothing, such as the final one, don't limit the
+	// replay.
+	if step.N > 0 && step.N < len(b) {
+		b = b[:step.N]
+	}
+	return rr.r.Read(b)
+}
@@ -0,0 +1,97 @@
+// Package testutil exports canonical BLAKE2s digests for inputs that sit on
+// and around the 64-byte block boundary, so code that wraps or re-buffers a
+// BLAKE2s hash can check its buffering logic against this implementation.
+package testutil
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"hash"
+)
+
+// A Vector is the unkeyed, 32-byte BLAKE2s digest of Input(Length).
+type Vector struct {
+	Length int
+	Digest string // hex
+}
+
+// BoundaryVectors covers the empty input, a single byte, the lengths either
+// side of where a naive implementation would have to pad, and one, two and
+// three blocks plus or minus one byte.
+var BoundaryVectors = []Vector{
+	{0, "69217a3079908094e11121d042354a7c1f55b6482ca1a51e1b250dfd1ed0eef9"},
+	{1, "e34d74dbaf4ff4c6abd871cc220451d2ea2648846c7757fbaac82fe51ad64bea"},
+	{55, "f4495470f226c8c214be08fdfad4bc4a2a9dbea9136a210df0d4b64929e6fc14"},
+	{56, "e290dd270b467f34ab1c002d340fa016257ff19e5833fdbbf2cb401c3b2817de"},
+	{63, "e57cb79487dd57902432b250733813bd96a84efce59f650fac26e6696aefafc3"},
+	{64, "56f34e8b96557e90c1f24b52d0c89d51086acf1b00f634cf1dde9233b8eaaa3e"},
+	{65, "1b53ee94aaf34e4b159d48de352c7f0661d0a40edff95a0b1639b4090e974472"},
+	{127, "f18417b39d617ab1c18fdf91ebd0fc6d5516bb34cf39364037bce81fa04cecb1"},
+	{128, "1fa877de67259d19863a2a34bcc6962a2b25fcbf5cbecd7ede8f1fa36688a796"},
+	{129, "5bd169e67c82c2c2e98ef7008bdf261f2ddf30b1c00f9e7f275bb3e8a28dc9a2"},
+}
+
+// SplitPatterns are the write sizes used by Check. Each pattern is applied
+// cyclically until the input is exhausted, so {1} writes a byte at a time and
+// {63, 2} straddles every block boundary. A zero entry is an empty write.
+var SplitPatterns = [][]int{
+	{1},
+	{7},
+	{63},
+	{64},
+	{65},
+	{63, 2},
+	{1, 63, 1, 64},
+	{0, 32, 0, 32},
+}
+
+// Input returns the n-byte message the vectors are computed over: the byte
+// sequence 0, 1, 2, ... as in the reference known-answer tests.
+func Input(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	return b
+}
+
+// Write feeds data to h in chunks following pattern.
+func Write(h hash.Hash, data []byte, pattern []int) {
+	for i := 0; len(data) > 0; i++ {
+		n := pattern[i%len(pattern)]
+		if n > len(data) {
+			n = len(data)
+		}
+		h.Write(data[:n])
+		data = data[n:]
+	}
+}
+
+// Check hashes every boundary vector with every split pattern, using a fresh
+// hash from newHash each time, and reports the first mismatch. newHash must
+// return an unkeyed BLAKE2s instance with 32 bytes of output.
+func Check(newHash func() hash.Hash) error {
+	for _, v := range BoundaryVectors {
+		expected, err := hex.DecodeString(v.Digest)
+		if err != nil {
+			return err
+		}
+		input := Input(v.Length)
+
+		h := newHash()
+		h.Write(input)
+		if got := h.Sum(nil); !bytes.Equal(expected, got) {
+			return fmt.Errorf("testutil: %d-byte input in one write: got %x, want %s", v.Length, got, v.Digest)
+		}
+
+		for _, pattern := range SplitPatterns {
+			h := newHash()
+			Write(h, input, pattern)
+			if got := h.Sum(nil); !bytes.Equal(expected, got) {
+				return fmt.Errorf("testutil: %d-byte input split %v: got %x, want %s", v.Length, pattern, got, v.Digest)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package testutil
+
+import (
+	"bytes"
+	"hash"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gtank/blake2s"
+)
+
+func TestCheck(t *testing.T) {
+	err := Check(func() hash.Hash {
+		d, _ := blake2s.NewDigest(nil, nil, nil, 32)
+		return d
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckDetectsMismatch(t *testing.T) {
+	err := Check(func() hash.Hash {
+		d, _ := blake2s.NewDigest(nil, []byte("salt"), nil, 32)
+		return d
+	})
+	if err == nil {
+		t.Error("salted hash passed unkeyed vectors")
+	}
+}
+
+func TestContract(t *testing.T) {
+	err := Contract(func() hash.Hash {
+		d, _ := blake2s.NewDigest(nil, nil, nil, 32)
+		return d
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// sumMutates is a wrapper that gets Sum wrong by finalizing in place.
+type sumMutates struct {
+	hash.Hash
+}
+
+func (s sumMutates) Sum(b []byte) []byte {
+	out := s.Hash.Sum(b)
+	s.Hash.Write([]byte{0})
+	return out
+}
+
+func TestContractDetectsMutatingSum(t *testing.T) {
+	err := Contract(func() hash.Hash {
+		d, _ := blake2s.NewDigest(nil, nil, nil, 32)
+		return sumMutates{d}
+	})
+	if err == nil {
+		t.Error("Sum that changes the state passed")
+	}
+}
+
+func TestSimulatedReader(t *testing.T) {
+	data := Input(10000)
+	r := SimulatedReader(bytes.NewReader(data), ReadPattern{ChunkMin: 10, ChunkMax: 100, LatencyMax: time.Microsecond, Seed: 1})
+	rec := Record(r)
+	got, err := io.ReadAll(rec)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("simulated reader changed the data: %v", err)
+	}
+	for _, step := range rec.Trace[:len(rec.Trace)-1] {
+		if step.N < 10 || step.N > 100 {
+			t.Fatalf("read of %d bytes outside the pattern", step.N)
+		}
+	}
+
+	// Replaying the trace reproduces its chunking.
+	replay := Record(rec.Trace.Replay(bytes.NewReader(data), 0))
+	got, err = io.ReadAll(replay)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("replay changed the data: %v", err)
+	}
+	for i := range rec.Trace {
+		if replay.Trace[i].N != rec.Trace[i].N {
+			t.Fatalf("read %d: replayed %d bytes, recorded %d", i, replay.Trace[i].N, rec.Trace[i].N)
+		}
+	}
+}
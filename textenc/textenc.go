@@ -0,0 +1,148 @@
+// Package textenc encodes hash output as text while it streams, with memory
+// use bounded by a small fixed buffer regardless of how much output there
+// is. It is meant for arbitrarily long output, such as an extendable-output
+// function, where collecting the bytes before encoding them is not an
+// option.
+package textenc
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Encoding selects a text encoding.
+type Encoding int
+
+const (
+	Hex Encoding = iota
+	Base32
+	Base64
+)
+
+// ParseEncoding maps "hex", "base32" and "base64" to an Encoding.
+func ParseEncoding(name string) (Encoding, error) {
+	switch name {
+	case "hex":
+		return Hex, nil
+	case "base32":
+		return Base32, nil
+	case "base64":
+		return Base64, nil
+	}
+	return 0, fmt.Errorf("textenc: unknown encoding %q", name)
+}
+
+func (e Encoding) String() string {
+	switch e {
+	case Hex:
+		return "hex"
+	case Base32:
+		return "base32"
+	case Base64:
+		return "base64"
+	}
+	return fmt.Sprintf("Encoding(%d)", int(e))
+}
+
+// groupSize is the number of input bytes that encode to a whole number of
+// output characters without padding.
+func (e Encoding) groupSize() int {
+	switch e {
+	case Base32:
+		return 5
+	case Base64:
+		return 3
+	}
+	return 1
+}
+
+func (e Encoding) encode(dst, src []byte) {
+	switch e {
+	case Base32:
+		base32.StdEncoding.Encode(dst, src)
+	case Base64:
+		base64.StdEncoding.Encode(dst, src)
+	default:
+		hex.Encode(dst, src)
+	}
+}
+
+func (e Encoding) encodedLen(n int) int {
+	switch e {
+	case Base32:
+		return base32.StdEncoding.EncodedLen(n)
+	case Base64:
+		return base64.StdEncoding.EncodedLen(n)
+	}
+	return hex.EncodedLen(n)
+}
+
+// NewWriter returns a writer that encodes everything written to it onto w.
+// Close must be called to flush any final partial group and its padding; it
+// does not close w.
+func NewWriter(e Encoding, w io.Writer) io.WriteCloser {
+	switch e {
+	case Base32:
+		return base32.NewEncoder(base32.StdEncoding, w)
+	case Base64:
+		return base64.NewEncoder(base64.StdEncoding, w)
+	}
+	return nopCloser{hex.NewEncoder(w)}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// chunkGroups is the number of encoding groups a Reader handles at a time.
+const chunkGroups = 1024
+
+// NewReader returns a reader producing the encoding of everything read from
+// r, pulling from r only as the encoded output is consumed.
+func NewReader(e Encoding, r io.Reader) io.Reader {
+	return &reader{
+		e:   e,
+		r:   r,
+		src: make([]byte, chunkGroups*e.groupSize()),
+	}
+}
+
+type reader struct {
+	e       Encoding
+	r       io.Reader
+	src     []byte
+	encoded []byte // encoded but not yet returned
+	pending []byte // backing storage for encoded
+	err     error
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.encoded) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		// Read whole groups so padding only appears at the very end.
+		n, err := io.ReadFull(r.r, r.src)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		r.err = err
+		if n == 0 {
+			continue
+		}
+		size := r.e.encodedLen(n)
+		if cap(r.pending) < size {
+			r.pending = make([]byte, size)
+		}
+		r.encoded = r.pending[:size]
+		r.e.encode(r.encoded, r.src[:n])
+	}
+	n := copy(p, r.encoded)
+	r.encoded = r.encoded[n:]
+	return n, nil
+}
@@ -0,0 +1,67 @@
+package textenc
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func reference(e Encoding, data []byte) string {
+	switch e {
+	case Base32:
+		return base32.StdEncoding.EncodeToString(data)
+	case Base64:
+		return base64.StdEncoding.EncodeToString(data)
+	}
+	return hex.EncodeToString(data)
+}
+
+func TestStreams(t *testing.T) {
+	data := make([]byte, 20000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	for _, e := range []Encoding{Hex, Base32, Base64} {
+		for _, n := range []int{0, 1, 2, 3, 4, 5, 6, 3071, 3072, 5121, len(data)} {
+			expected := reference(e, data[:n])
+
+			var buf bytes.Buffer
+			w := NewWriter(e, &buf)
+			for i := 0; i < n; i += 7 {
+				end := i + 7
+				if end > n {
+					end = n
+				}
+				w.Write(data[i:end])
+			}
+			w.Close()
+			if buf.String() != expected {
+				t.Errorf("%v writer, %d bytes: mismatch", e, n)
+			}
+
+			out, err := io.ReadAll(iotest.OneByteReader(NewReader(e, iotest.HalfReader(bytes.NewReader(data[:n])))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != expected {
+				t.Errorf("%v reader, %d bytes: mismatch", e, n)
+			}
+		}
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	for _, e := range []Encoding{Hex, Base32, Base64} {
+		parsed, err := ParseEncoding(e.String())
+		if err != nil || parsed != e {
+			t.Errorf("%v did not round-trip", e)
+		}
+	}
+	if _, err := ParseEncoding("base58"); err == nil {
+		t.Error("accepted an unknown encoding")
+	}
+}
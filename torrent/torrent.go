@@ -0,0 +1,188 @@
+// Package torrent splits a file into fixed-size pieces and records their
+// BLAKE2s digests in a piece table, as BitTorrent does with SHA-1, so that
+// peer-to-peer and mirror-sync tools can fetch pieces from anywhere and
+// check each one on arrival.
+//
+// A serialized piece table is
+//
+//	"B2PT" | version byte | uvarint piece size | uvarint length |
+//	piece digests | check digest
+//
+// where every digest is 32 bytes and the check digest covers everything
+// before it, so a damaged table is rejected before any piece is trusted.
+package torrent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+
+	"github.com/gtank/blake2s"
+)
+
+var persona = []byte("torrent1")
+
+const (
+	magic   = "B2PT"
+	version = 1
+)
+
+var (
+	// ErrCorruptTable is returned by UnmarshalBinary for tables that are
+	// truncated, malformed or fail their check digest.
+	ErrCorruptTable = errors.New("torrent: corrupt piece table")
+
+	// ErrLength is returned by Verify when the input's length differs from
+	// the table's.
+	ErrLength = errors.New("torrent: input length differs from the table")
+)
+
+// A Table lists the digests of the pieces of one input.
+type Table struct {
+	PieceSize int64
+	Length    int64
+	Hashes    [][]byte
+}
+
+// Pieces hashes r in pieces of pieceSize bytes; the last piece may be
+// shorter. The length of r is taken from a Size method, as on
+// io.SectionReader and bytes.Reader, or from Stat, as on os.File.
+func Pieces(r io.ReaderAt, pieceSize int64) (*Table, error) {
+	if pieceSize <= 0 {
+		return nil, errors.New("torrent: piece size must be positive")
+	}
+	length, err := size(r)
+	if err != nil {
+		return nil, err
+	}
+	t := &Table{PieceSize: pieceSize, Length: length}
+	hashes, err := blake2s.HashRanges(r, t.ranges(), 0)
+	if err != nil {
+		return nil, err
+	}
+	t.Hashes = hashes
+	return t, nil
+}
+
+func size(r io.ReaderAt) (int64, error) {
+	switch r := r.(type) {
+	case interface{ Size() int64 }:
+		return r.Size(), nil
+	case interface{ Stat() (fs.FileInfo, error) }:
+		fi, err := r.Stat()
+		if err != nil {
+			return 0, err
+		}
+		return fi.Size(), nil
+	}
+	return 0, errors.New("torrent: can't determine the input's length")
+}
+
+// NumPieces returns the number of pieces in an input of the table's length.
+func (t *Table) NumPieces() int {
+	return int((t.Length + t.PieceSize - 1) / t.PieceSize)
+}
+
+// Piece returns the byte range of piece i.
+func (t *Table) Piece(i int) blake2s.Range {
+	off := int64(i) * t.PieceSize
+	return blake2s.Range{Off: off, Len: min(t.PieceSize, t.Length-off)}
+}
+
+func (t *Table) ranges() []blake2s.Range {
+	ranges := make([]blake2s.Range, t.NumPieces())
+	for i := range ranges {
+		ranges[i] = t.Piece(i)
+	}
+	return ranges
+}
+
+// Verify hashes r and returns the indices of the pieces that don't match.
+func (t *Table) Verify(r io.ReaderAt) ([]int, error) {
+	length, err := size(r)
+	if err != nil {
+		return nil, err
+	}
+	if length != t.Length {
+		return nil, ErrLength
+	}
+	return blake2s.VerifyChunks(r, t.Chunks(), 0)
+}
+
+// Chunks returns the table as a chunk map for blake2s.VerifyChunks.
+func (t *Table) Chunks() []blake2s.Chunk {
+	chunks := make([]blake2s.Chunk, len(t.Hashes))
+	for i, h := range t.Hashes {
+		chunks[i] = blake2s.Chunk{Range: t.Piece(i), Digest: h}
+	}
+	return chunks
+}
+
+// MarshalBinary encodes the table in the format described in the package
+// documentation.
+func (t *Table) MarshalBinary() ([]byte, error) {
+	if len(t.Hashes) != t.NumPieces() {
+		return nil, errors.New("torrent: table has the wrong number of pieces")
+	}
+	b := append([]byte(magic), version)
+	b = binary.AppendUvarint(b, uint64(t.PieceSize))
+	b = binary.AppendUvarint(b, uint64(t.Length))
+	for _, h := range t.Hashes {
+		if len(h) != blake2s.MaxOutput {
+			return nil, errors.New("torrent: piece digest has the wrong length")
+		}
+		b = append(b, h...)
+	}
+	return append(b, check(b)...), nil
+}
+
+// UnmarshalBinary decodes a table written by MarshalBinary.
+func (t *Table) UnmarshalBinary(data []byte) error {
+	if len(data) < len(magic)+1+blake2s.MaxOutput {
+		return ErrCorruptTable
+	}
+	body, sum := data[:len(data)-blake2s.MaxOutput], data[len(data)-blake2s.MaxOutput:]
+	if !bytes.Equal(check(body), sum) {
+		return ErrCorruptTable
+	}
+	if string(body[:len(magic)]) != magic || body[len(magic)] != version {
+		return ErrCorruptTable
+	}
+	body = body[len(magic)+1:]
+
+	pieceSize, n := binary.Uvarint(body)
+	if n <= 0 || pieceSize == 0 || pieceSize > 1<<62 {
+		return ErrCorruptTable
+	}
+	body = body[n:]
+	length, n := binary.Uvarint(body)
+	if n <= 0 || length > 1<<62 {
+		return ErrCorruptTable
+	}
+	body = body[n:]
+
+	// Bound the piece count by the hashes present before multiplying, so a
+	// forged length can't wrap the size check around to zero.
+	pieces := (length + pieceSize - 1) / pieceSize
+	if pieces > uint64(len(body)/blake2s.MaxOutput) || uint64(len(body)) != pieces*blake2s.MaxOutput {
+		return ErrCorruptTable
+	}
+	parsed := Table{PieceSize: int64(pieceSize), Length: int64(length)}
+	for len(body) > 0 {
+		parsed.Hashes = append(parsed.Hashes, append([]byte(nil), body[:blake2s.MaxOutput]...))
+		body = body[blake2s.MaxOutput:]
+	}
+	if len(parsed.Hashes) != parsed.NumPieces() {
+		return ErrCorruptTable
+	}
+	*t = parsed
+	return nil
+}
+
+func check(b []byte) []byte {
+	d, _ := blake2s.NewDigest(nil, nil, persona, blake2s.MaxOutput)
+	d.Write(b)
+	return d.Sum(nil)
+}
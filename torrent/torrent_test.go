@@ -0,0 +1,123 @@
+package torrent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gtank/blake2s"
+)
+
+func input(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i * 13)
+	}
+	return data
+}
+
+func TestPieces(t *testing.T) {
+	data := input(10000)
+	table, err := Pieces(bytes.NewReader(data), 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.NumPieces() != 3 || len(table.Hashes) != 3 {
+		t.Fatalf("got %d pieces", len(table.Hashes))
+	}
+	for i, h := range table.Hashes {
+		r := table.Piece(i)
+		d, _ := blake2s.NewDigest(nil, nil, nil, blake2s.MaxOutput)
+		d.Write(data[r.Off : r.Off+r.Len])
+		if !bytes.Equal(h, d.Sum(nil)) {
+			t.Errorf("piece %d mismatch", i)
+		}
+	}
+	if r := table.Piece(2); r.Off != 8192 || r.Len != 10000-8192 {
+		t.Errorf("last piece is %+v", r)
+	}
+
+	damaged := append([]byte(nil), data...)
+	damaged[5000] ^= 1
+	if bad, err := table.Verify(bytes.NewReader(damaged)); err != nil || !reflect.DeepEqual(bad, []int{1}) {
+		t.Errorf("Verify returned %v, %v", bad, err)
+	}
+	if bad, err := table.Verify(bytes.NewReader(data)); err != nil || bad != nil {
+		t.Errorf("Verify of the original returned %v, %v", bad, err)
+	}
+	if _, err := table.Verify(bytes.NewReader(data[:9999])); err != ErrLength {
+		t.Errorf("Verify of a short input returned %v", err)
+	}
+
+	// Files get their length from Stat.
+	path := filepath.Join(t.TempDir(), "input")
+	os.WriteFile(path, data, 0o644)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fromFile, err := Pieces(f, 4096)
+	if err != nil || !reflect.DeepEqual(fromFile, table) {
+		t.Errorf("file table differs: %v", err)
+	}
+
+	empty, err := Pieces(bytes.NewReader(nil), 4096)
+	if err != nil || len(empty.Hashes) != 0 {
+		t.Errorf("empty input gave %v, %v", empty, err)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	for _, n := range []int{0, 1, 4096, 10000} {
+		table, _ := Pieces(bytes.NewReader(input(n)), 4096)
+		b, err := table.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Table
+		if err := decoded.UnmarshalBinary(b); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.PieceSize != table.PieceSize || decoded.Length != table.Length || len(decoded.Hashes) != len(table.Hashes) {
+			t.Fatalf("decoded %+v", decoded)
+		}
+		for i := range decoded.Hashes {
+			if !bytes.Equal(decoded.Hashes[i], table.Hashes[i]) {
+				t.Errorf("piece %d mismatch after round trip", i)
+			}
+		}
+
+		for i := range b {
+			damaged := append([]byte(nil), b...)
+			damaged[i] ^= 1
+			if err := decoded.UnmarshalBinary(damaged); err != ErrCorruptTable {
+				t.Fatalf("damage at byte %d not detected", i)
+			}
+		}
+		if err := decoded.UnmarshalBinary(b[:len(b)-1]); err != ErrCorruptTable {
+			t.Error("truncation not detected")
+		}
+	}
+}
+
+func TestUnmarshalForgedLength(t *testing.T) {
+	// The check digest is unkeyed, so anyone can forge a table. 2^62 pieces
+	// of 32 bytes wraps a 64-bit size check around to zero.
+	for _, tc := range []struct {
+		length uint64
+		hashes int
+	}{{1 << 62, 0}, {1 << 62, 1}, {1<<62 - 1, 1}, {2, 1}} {
+		body := append([]byte(magic), version)
+		body = binary.AppendUvarint(body, 1)
+		body = binary.AppendUvarint(body, tc.length)
+		body = append(body, make([]byte, tc.hashes*blake2s.MaxOutput)...)
+		var decoded Table
+		if err := decoded.UnmarshalBinary(append(body, check(body)...)); err != ErrCorruptTable {
+			t.Errorf("length %d with %d hashes: got %v, want ErrCorruptTable", tc.length, tc.hashes, err)
+		}
+	}
+}
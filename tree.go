@@ -0,0 +1,81 @@
+package blake2s
+
+import "errors"
+
+// TreeParams are the tree-hashing fields of the parameter block, for building
+// BLAKE2 tree modes, such as BLAKE2sp, or custom constructions out of
+// individual node hashes. Sequential hashing corresponds to Fanout and
+// MaxDepth 1 with every other field zero, which is what digests get unless
+// WithTree is used.
+//
+// This package computes single nodes only. Splitting the input into leaves
+// and combining node digests is up to the caller, and the parameters are
+// only checked for being representable, not for describing a sensible tree,
+// except that Fanout and MaxDepth 1 mean sequential mode, where the other
+// fields must be zero (see ValidateParameterBlock).
+type TreeParams struct {
+	// Fanout is the maximum number of children per node, or 0 for
+	// unlimited.
+	Fanout byte
+
+	// MaxDepth is the maximum depth of the tree, at least 1, or 255 for
+	// unlimited.
+	MaxDepth byte
+
+	// LeafLength is the maximum number of bytes in a leaf, or 0 for
+	// unlimited.
+	LeafLength uint32
+
+	// NodeOffset is the position of this node within its level, counting
+	// from zero. It must be below 2^48.
+	NodeOffset uint64
+
+	// NodeDepth is the level of this node: 0 for leaves.
+	NodeDepth byte
+
+	// InnerLength is the digest size of the inner nodes, at most MaxOutput.
+	InnerLength byte
+
+	// LastNode marks this node as the last one in its level, which sets the
+	// second finalization flag.
+	LastNode bool
+}
+
+// WithTree sets the tree-hashing parameters of the Digest.
+func WithTree(t TreeParams) Option {
+	return func(c *config) error {
+		c.tree = &t
+		return nil
+	}
+}
+
+// SetLastNode marks d as the last node of its level, which sets the second
+// finalization flag when it is summed. It is for constructions that only
+// learn a node is the last one after writing to it, such as the rightmost
+// leaf of a stream; otherwise TreeParams.LastNode does the same up front. It
+// must be called before Sum, and it survives Reset.
+func (d *Digest) SetLastNode() {
+	d.lastNode = true
+}
+
+// apply validates t and copies it into p. BLAKE2s stores the 48-bit node
+// offset in the bytes that BLAKE2X uses for the XOF length.
+func (t *TreeParams) apply(p *ParameterBlock) error {
+	if t.MaxDepth == 0 {
+		return errors.New("blake2s: tree depth must be at least 1")
+	}
+	if t.InnerLength > MaxOutput {
+		return errors.New("blake2s: inner length too large")
+	}
+	if t.NodeOffset >= 1<<48 {
+		return errors.New("blake2s: node offset must be below 2^48")
+	}
+	p.Fanout = t.Fanout
+	p.Depth = t.MaxDepth
+	p.LeafLength = t.LeafLength
+	p.NodeOffset = uint32(t.NodeOffset)
+	p.XOFLength = uint16(t.NodeOffset >> 32)
+	p.NodeDepth = t.NodeDepth
+	p.InnerLength = t.InnerLength
+	return nil
+}
@@ -0,0 +1,85 @@
+package blake2s
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Expected values are from Python's hashlib.blake2s.
+func TestTreeParams(t *testing.T) {
+	data := make([]byte, 100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	for _, tc := range []struct {
+		opts []Option
+		want string
+	}{
+		{[]Option{WithTree(TreeParams{Fanout: 4, MaxDepth: 3, LeafLength: 4096, NodeOffset: 0x123456789abc, NodeDepth: 1, InnerLength: 32, LastNode: true})},
+			"eade79ae5f8470bf526246913e9a1f4dd4373bdcf6e4794b097946dac3c39027"},
+		{[]Option{WithKey([]byte("key")), WithSalt([]byte("salt")), WithSize(16), WithTree(TreeParams{MaxDepth: 255, NodeOffset: 7, InnerLength: 16})},
+			"eb67ed1e4161c773b12e619e8e12beae"},
+		{[]Option{WithTree(TreeParams{Fanout: 1, MaxDepth: 1, LastNode: true})},
+			"3ce454cbddaa5d8bd02442edd9633d28208dccc0d5ccb1fb03cdfc14d3046e06"},
+	} {
+		d, err := New(tc.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Write(data)
+		if got := hex.EncodeToString(d.Sum(nil)); got != tc.want {
+			t.Errorf("got %s, want %s", got, tc.want)
+		}
+	}
+
+	// Sequential parameters give the sequential digest.
+	d, _ := New(WithTree(TreeParams{Fanout: 1, MaxDepth: 1}))
+	seq, _ := NewDigest(nil, nil, nil, MaxOutput)
+	d.Write(data)
+	seq.Write(data)
+	if hex.EncodeToString(d.Sum(nil)) != hex.EncodeToString(seq.Sum(nil)) {
+		t.Error("sequential tree parameters differ from NewDigest")
+	}
+
+	for _, bad := range []TreeParams{
+		{Fanout: 2},
+		{MaxDepth: 2, InnerLength: 33},
+		{MaxDepth: 2, NodeOffset: 1 << 48},
+	} {
+		if _, err := New(WithTree(bad)); err == nil {
+			t.Errorf("accepted %+v", bad)
+		}
+	}
+}
+
+func TestSetLastNode(t *testing.T) {
+	data := make([]byte, 100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	// The same nodes as the LastNode cases of TestTreeParams, marked only
+	// after their input is written.
+	tree := TreeParams{Fanout: 4, MaxDepth: 3, LeafLength: 4096, NodeOffset: 0x123456789abc, NodeDepth: 1, InnerLength: 32}
+	d, err := New(WithTree(tree))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Write(data)
+	d.SetLastNode()
+	if got, want := hex.EncodeToString(d.Sum(nil)), "eade79ae5f8470bf526246913e9a1f4dd4373bdcf6e4794b097946dac3c39027"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	d.Reset()
+	d.Write(data)
+	if got, want := hex.EncodeToString(d.Sum(nil)), "eade79ae5f8470bf526246913e9a1f4dd4373bdcf6e4794b097946dac3c39027"; got != want {
+		t.Errorf("after Reset: got %s, want %s", got, want)
+	}
+
+	seq, _ := NewDigest(nil, nil, nil, MaxOutput)
+	seq.Write(data)
+	seq.SetLastNode()
+	if got, want := hex.EncodeToString(seq.Sum(nil)), "3ce454cbddaa5d8bd02442edd9633d28208dccc0d5ccb1fb03cdfc14d3046e06"; got != want {
+		t.Errorf("sequential: got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,202 @@
+// Package watch keeps an up-to-date map of BLAKE2s digests for a directory
+// tree and reports changes to it.
+//
+// Changes are found by polling: each scan walks the tree and rehashes only
+// the files whose size or modification time changed since the last one. This
+// keeps the package free of platform-specific notification APIs at the cost
+// of some latency, which callers control with the polling interval.
+package watch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gtank/blake2s"
+	"github.com/gtank/blake2s/manifest"
+)
+
+// Op describes what happened to a file.
+type Op int
+
+const (
+	Created Op = iota + 1
+	Modified
+	Removed
+)
+
+func (op Op) String() string {
+	switch op {
+	case Created:
+		return "CREATE"
+	case Modified:
+		return "MODIFY"
+	case Removed:
+		return "REMOVE"
+	}
+	return "UNKNOWN"
+}
+
+// An Event reports a change to one file. Digest is the new digest, or nil for
+// Removed.
+type Event struct {
+	Path   string
+	Op     Op
+	Digest []byte
+}
+
+// The personalization used for the rolled-up tree digest, so it can never be
+// confused with the digest of a file that happens to contain a manifest.
+var treePersona = []byte("b2swatch")
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+	digest  []byte
+}
+
+// A Watcher tracks the regular files under a root in a file system.
+type Watcher struct {
+	fsys fs.FS
+	root string
+
+	mu    sync.Mutex
+	files map[string]fileState
+}
+
+// New returns a Watcher for the tree rooted at root in fsys. It holds no
+// state until the first call to Scan or Run.
+func New(fsys fs.FS, root string) *Watcher {
+	return &Watcher{
+		fsys:  fsys,
+		root:  root,
+		files: make(map[string]fileState),
+	}
+}
+
+// Scan walks the tree once, updates the digest map and returns the changes
+// since the previous scan, sorted by path. The first scan reports every file
+// as Created. A file that disappears during the walk is treated as removed
+// rather than failing the scan. If the walk fails, the digest map is left as
+// it was, so the next scan reports the same changes again.
+func (w *Watcher) Scan() ([]Event, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var events []Event
+	files := make(map[string]fileState, len(w.files))
+	// gone skips an entry deleted between reading its directory and
+	// looking at it.
+	gone := func(path string, err error) bool {
+		return path != w.root && errors.Is(err, fs.ErrNotExist)
+	}
+	err := fs.WalkDir(w.fsys, w.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if gone(path, err) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if gone(path, err) {
+				return nil
+			}
+			return err
+		}
+		old, known := w.files[path]
+		if known && old.size == info.Size() && old.modTime.Equal(info.ModTime()) {
+			files[path] = old
+			return nil
+		}
+
+		digest, err := manifest.HashFile(w.fsys, path, blake2s.MaxOutput)
+		if err != nil {
+			if gone(path, err) {
+				return nil
+			}
+			return err
+		}
+		files[path] = fileState{size: info.Size(), modTime: info.ModTime(), digest: digest}
+
+		switch {
+		case !known:
+			events = append(events, Event{Path: path, Op: Created, Digest: digest})
+		case !bytes.Equal(old.digest, digest):
+			events = append(events, Event{Path: path, Op: Modified, Digest: digest})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for path := range w.files {
+		if _, ok := files[path]; !ok {
+			events = append(events, Event{Path: path, Op: Removed})
+		}
+	}
+	w.files = files
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+	return events, nil
+}
+
+// Run scans every interval until ctx is done, sending each change to events.
+// It returns the first scan error, or ctx.Err().
+func (w *Watcher) Run(ctx context.Context, interval time.Duration, events chan<- Event) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		changes, err := w.Scan()
+		if err != nil {
+			return err
+		}
+		for _, e := range changes {
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Manifest returns the current digest map as a manifest, sorted by path.
+func (w *Watcher) Manifest() *manifest.Manifest {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	m := &manifest.Manifest{Entries: make([]manifest.Entry, 0, len(w.files))}
+	for path, state := range w.files {
+		m.Entries = append(m.Entries, manifest.Entry{Path: path, Digest: state.digest})
+	}
+	sort.Slice(m.Entries, func(i, j int) bool { return m.Entries[i].Path < m.Entries[j].Path })
+	return m
+}
+
+// TreeDigest rolls the whole digest map up into a single digest: the
+// personalized BLAKE2s hash of the sorted manifest text. Two trees have the
+// same TreeDigest exactly when they have the same files with the same
+// contents.
+func (w *Watcher) TreeDigest() []byte {
+	d, err := blake2s.NewDigest(nil, nil, treePersona, blake2s.MaxOutput)
+	if err != nil {
+		panic(err)
+	}
+	w.Manifest().WriteTo(d)
+	return d.Sum(nil)
+}
@@ -0,0 +1,137 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestScan(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a": {Data: []byte("alpha"), ModTime: time.Unix(1, 0)},
+		"b": {Data: []byte("bravo"), ModTime: time.Unix(1, 0)},
+		"c": {Data: []byte("charlie"), ModTime: time.Unix(1, 0)},
+	}
+	w := New(fsys, ".")
+
+	events, err := w.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || events[0].Op != Created || events[1].Path != "b" {
+		t.Fatalf("unexpected initial events %v", events)
+	}
+	before := w.TreeDigest()
+
+	if events, _ := w.Scan(); len(events) != 0 {
+		t.Errorf("unchanged tree reported %v", events)
+	}
+
+	fsys["a"] = &fstest.MapFile{Data: []byte("ALPHA"), ModTime: time.Unix(2, 0)}
+	delete(fsys, "b")
+	// Touched but identical content is not a modification.
+	fsys["c"] = &fstest.MapFile{Data: []byte("charlie"), ModTime: time.Unix(3, 0)}
+	fsys["d"] = &fstest.MapFile{Data: []byte("delta")}
+
+	events, err = w.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Event{{Path: "a", Op: Modified}, {Path: "b", Op: Removed}, {Path: "d", Op: Created}}
+	if len(events) != len(expected) {
+		t.Fatalf("unexpected events %v", events)
+	}
+	for i := range expected {
+		if events[i].Path != expected[i].Path || events[i].Op != expected[i].Op {
+			t.Errorf("event %d: got %v, want %v", i, events[i], expected[i])
+		}
+	}
+	if bytes.Equal(before, w.TreeDigest()) {
+		t.Error("tree digest did not change")
+	}
+}
+
+// failingFS fails to open the files in fail, as if they were deleted or
+// unreadable after their directory was listed.
+type failingFS struct {
+	fstest.MapFS
+	fail map[string]error
+}
+
+func (f failingFS) Open(name string) (fs.File, error) {
+	if err := f.fail[name]; err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return f.MapFS.Open(name)
+}
+
+func TestScanErrors(t *testing.T) {
+	fsys := failingFS{
+		MapFS: fstest.MapFS{
+			"a": {Data: []byte("alpha")},
+			"b": {Data: []byte("bravo")},
+		},
+		fail: map[string]error{},
+	}
+	w := New(fsys, ".")
+
+	// A file deleted mid-walk is skipped, not fatal.
+	fsys.fail["b"] = fs.ErrNotExist
+	events, err := w.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Path != "a" {
+		t.Fatalf("unexpected events %v", events)
+	}
+
+	// A failed scan changes nothing, so its events come again next time.
+	fsys.fail["b"] = fs.ErrPermission
+	fsys.MapFS["a"] = &fstest.MapFile{Data: []byte("ALPHA"), ModTime: time.Unix(2, 0)}
+	if _, err := w.Scan(); !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("expected ErrPermission, got %v", err)
+	}
+	delete(fsys.fail, "b")
+	events, err = w.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Path != "a" || events[0].Op != Modified || events[1].Path != "b" || events[1].Op != Created {
+		t.Errorf("unexpected events after a failed scan %v", events)
+	}
+
+	// The root itself going missing is still an error.
+	if _, err := New(fsys, "missing").Scan(); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected ErrNotExist for a missing root, got %v", err)
+	}
+}
+
+func TestTreeDigestOrderIndependent(t *testing.T) {
+	a := New(fstest.MapFS{"x": {Data: []byte("1")}, "y": {Data: []byte("2")}}, ".")
+	b := New(fstest.MapFS{"y": {Data: []byte("2")}, "x": {Data: []byte("1")}}, ".")
+	a.Scan()
+	b.Scan()
+	if !bytes.Equal(a.TreeDigest(), b.TreeDigest()) {
+		t.Error("identical trees have different digests")
+	}
+}
+
+func TestRun(t *testing.T) {
+	w := New(fstest.MapFS{"a": {Data: []byte("alpha")}}, ".")
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan Event)
+	done := make(chan error)
+	go func() { done <- w.Run(ctx, time.Millisecond, events) }()
+
+	if e := <-events; e.Path != "a" || e.Op != Created {
+		t.Errorf("unexpected event %v", e)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+// Package wireguard implements the BLAKE2s-based primitives of the WireGuard
+// handshake, as defined in section 5.4 of the WireGuard paper: HASH, MAC,
+// HMAC and KDF1/KDF2/KDF3, and the keys for the mac1 and mac2 fields of
+// handshake messages. Noise IK implementations can build on it without a
+// second BLAKE2s dependency.
+//
+// All keys in the protocol are at most 32 bytes. The functions panic if given
+// a longer MAC key, which only a programming error can produce. Temporary
+// hash states and intermediate keys are zeroed before the functions return.
+package wireguard
+
+import (
+	"github.com/gtank/blake2s"
+)
+
+const (
+	// Size is the size of HASH, HMAC and KDF outputs in bytes.
+	Size = 32
+	// MACSize is the size of MAC outputs, including mac1 and mac2.
+	MACSize = 16
+
+	// Construction and Identifier seed the handshake's chaining key and
+	// hash.
+	Construction = "Noise_IKpsk2_25519_ChaChaPoly_BLAKE2s"
+	Identifier   = "WireGuard v1 zx2c4 Jason@zx2c4.com"
+
+	// LabelMAC1 and LabelCookie prefix the responder's public key when
+	// deriving the mac1 key and the cookie encryption key.
+	LabelMAC1   = "mac1----"
+	LabelCookie = "cookie--"
+)
+
+// Hash returns HASH(parts[0] || parts[1] || ...), unkeyed BLAKE2s-256.
+func Hash(parts ...[]byte) [Size]byte {
+	d := newHash(nil, Size)
+	for _, p := range parts {
+		d.Write(p)
+	}
+	var out [Size]byte
+	d.Sum(out[:0])
+	return out
+}
+
+// MAC returns MAC(key, data), keyed BLAKE2s-128.
+func MAC(key, data []byte) [MACSize]byte {
+	d := newHash(key, MACSize)
+	defer d.Wipe()
+	d.Write(data)
+	var out [MACSize]byte
+	d.Sum(out[:0])
+	return out
+}
+
+// HMAC returns HMAC(key, data), HMAC (RFC 2104) over BLAKE2s-256.
+func HMAC(key, data []byte) [Size]byte {
+	return hmacParts(key, data)
+}
+
+// hmacParts is HMAC over the concatenation of parts. It is written out
+// rather than using crypto/hmac so that the pads and inner states can be
+// wiped.
+func hmacParts(key []byte, parts ...[]byte) [Size]byte {
+	var pad [blake2s.BlockSize]byte
+	defer clear(pad[:])
+	if len(key) > len(pad) {
+		k := Hash(key)
+		copy(pad[:], k[:])
+		clear(k[:])
+	} else {
+		copy(pad[:], key)
+	}
+
+	for i := range pad {
+		pad[i] ^= 0x36
+	}
+	inner := newHash(nil, Size)
+	defer inner.Wipe()
+	inner.Write(pad[:])
+	for _, p := range parts {
+		inner.Write(p)
+	}
+	var innerSum [Size]byte
+	defer clear(innerSum[:])
+	inner.Sum(innerSum[:0])
+
+	for i := range pad {
+		pad[i] ^= 0x36 ^ 0x5c
+	}
+	outer := newHash(nil, Size)
+	defer outer.Wipe()
+	outer.Write(pad[:])
+	outer.Write(innerSum[:])
+	var out [Size]byte
+	outer.Sum(out[:0])
+	return out
+}
+
+// KDF1 returns the first output of KDF(key, input).
+func KDF1(key, input []byte) (t0 [Size]byte) {
+	prk := HMAC(key, input)
+	defer clear(prk[:])
+	return hmacParts(prk[:], []byte{1})
+}
+
+// KDF2 returns the first two outputs of KDF(key, input).
+func KDF2(key, input []byte) (t0, t1 [Size]byte) {
+	prk := HMAC(key, input)
+	defer clear(prk[:])
+	t0 = hmacParts(prk[:], []byte{1})
+	t1 = hmacParts(prk[:], t0[:], []byte{2})
+	return t0, t1
+}
+
+// KDF3 returns the first three outputs of KDF(key, input).
+func KDF3(key, input []byte) (t0, t1, t2 [Size]byte) {
+	prk := HMAC(key, input)
+	defer clear(prk[:])
+	t0 = hmacParts(prk[:], []byte{1})
+	t1 = hmacParts(prk[:], t0[:], []byte{2})
+	t2 = hmacParts(prk[:], t1[:], []byte{3})
+	return t0, t1, t2
+}
+
+// MAC1Key returns HASH(LabelMAC1 || publicKey), the key for the mac1 field
+// of messages sent to the holder of publicKey.
+func MAC1Key(publicKey []byte) [Size]byte {
+	return Hash([]byte(LabelMAC1), publicKey)
+}
+
+// CookieKey returns HASH(LabelCookie || publicKey), the key with which the
+// holder of publicKey encrypts cookie replies.
+func CookieKey(publicKey []byte) [Size]byte {
+	return Hash([]byte(LabelCookie), publicKey)
+}
+
+// MAC1 returns the mac1 field for msg, the bytes of a handshake message that
+// precede the field, sent to the holder of publicKey.
+func MAC1(publicKey, msg []byte) [MACSize]byte {
+	key := MAC1Key(publicKey)
+	return MAC(key[:], msg)
+}
+
+// MAC2 returns the mac2 field for msg, the bytes of a handshake message that
+// precede the field, under the 16-byte cookie from the latest cookie reply.
+func MAC2(cookie, msg []byte) [MACSize]byte {
+	return MAC(cookie, msg)
+}
+
+func newHash(key []byte, size int) *blake2s.Digest {
+	d, err := blake2s.NewDigest(key, nil, nil, size)
+	if err != nil {
+		panic("wireguard: " + err.Error())
+	}
+	return d
+}
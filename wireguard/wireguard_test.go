@@ -0,0 +1,65 @@
+package wireguard
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Expected values were computed with Python's hashlib.blake2s and hmac.
+func TestPrimitives(t *testing.T) {
+	key := make([]byte, 32)
+	pub := make([]byte, 32)
+	cookie := make([]byte, 16)
+	for i := range key {
+		key[i] = byte(i)
+		pub[i] = byte(100 + i)
+	}
+	for i := range cookie {
+		cookie[i] = byte(i)
+	}
+	input := []byte("input keying material")
+	msg := []byte("handshake initiation bytes")
+
+	// The handshake's initial chaining key and hash, as in wireguard-go.
+	ck := Hash([]byte(Construction))
+	h := Hash(ck[:], []byte(Identifier))
+	t0, t1, t2 := KDF3(key, input)
+	k1, k2 := KDF2(key, input)
+	j1 := KDF1(key, input)
+	hm := HMAC(key, input)
+	mac1 := MAC1(pub, msg)
+	mac2 := MAC2(cookie, msg)
+	cookieKey := CookieKey(pub)
+
+	for _, tc := range []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{"chaining key", ck[:], "60e26daef327efc02ec335e2a025d2d016eb4206f87277f52d38d1988b78cd36"},
+		{"hash", h[:], "2211b361081ac566691243db458ad5322d9c6c662293e8b70ee19c65ba079ef3"},
+		{"HMAC", hm[:], "72afdafd0cd053939bad07e75962baa867ab68db37b81197310b2c971ce71440"},
+		{"KDF3 t0", t0[:], "d49ec1ae587b690ec2d4c38d1802dd052d26ab3338bc0b665629ece5dfc61ae1"},
+		{"KDF3 t1", t1[:], "46d705e3e5cbc140bbfdf613d3733a0c3905bf21d5e1b34213c5a2a883b10553"},
+		{"KDF3 t2", t2[:], "17e6966e3a04136a899866a1650d1a323fdcdcb19956e0e97c6be5291850b302"},
+		{"KDF2 t0", k1[:], "d49ec1ae587b690ec2d4c38d1802dd052d26ab3338bc0b665629ece5dfc61ae1"},
+		{"KDF2 t1", k2[:], "46d705e3e5cbc140bbfdf613d3733a0c3905bf21d5e1b34213c5a2a883b10553"},
+		{"KDF1", j1[:], "d49ec1ae587b690ec2d4c38d1802dd052d26ab3338bc0b665629ece5dfc61ae1"},
+		{"mac1", mac1[:], "747abefb4aff1c3d227d6c7e092e619a"},
+		{"mac2", mac2[:], "c475674382df9eb7438db9688544570d"},
+		{"cookie key", cookieKey[:], "ec09a0226a408625ba85f9b057b3ac4bba6d3402d6662e04c4327913c289cf20"},
+	} {
+		if got := hex.EncodeToString(tc.got); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestLongMACKeyPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MAC accepted a 33-byte key")
+		}
+	}()
+	MAC(make([]byte, 33), nil)
+}